@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"strings"
+	"time"
+)
+
+// Action is an audited operation.
+type Action string
+
+// Audited actions. Actions of file operations match the hook events.
+const (
+	ActionUpload   Action = "upload"
+	ActionSave     Action = "save"
+	ActionDelete   Action = "delete"
+	ActionRename   Action = "rename"
+	ActionCopy     Action = "copy"
+	ActionCompress Action = "compress"
+	ActionShare    Action = "share"
+	ActionDownload Action = "download"
+	ActionLogin    Action = "login"
+	ActionRevoke   Action = "revoke"
+)
+
+// Outcome tells if an audited operation succeeded.
+type Outcome string
+
+// Possible outcomes.
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Entry is a single record of the audit log.
+type Entry struct {
+	ID          uint64    `json:"id" storm:"id,increment"`
+	Time        time.Time `json:"time"`
+	UserID      uint      `json:"userId"`
+	Username    string    `json:"username"`
+	IP          string    `json:"ip"`
+	Scope       string    `json:"scope"`
+	Action      Action    `json:"action"`
+	Path        string    `json:"path"`
+	Destination string    `json:"destination,omitempty"`
+	Size        int64     `json:"size"`
+	Outcome     Outcome   `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Filter selects audit entries. Zero values match everything.
+type Filter struct {
+	Username string
+	Action   Action
+	Outcome  Outcome
+	// PathPrefix matches entries whose path or destination begins
+	// with it.
+	PathPrefix string
+	Since      time.Time
+	Until      time.Time
+	// Limit is the maximum number of entries to return.
+	Limit  int
+	Offset int
+}
+
+// Matches tells if an entry is selected by the filter.
+func (f *Filter) Matches(e *Entry) bool {
+	switch {
+	case f.Username != "" && e.Username != f.Username:
+		return false
+	case f.Action != "" && e.Action != f.Action:
+		return false
+	case f.Outcome != "" && e.Outcome != f.Outcome:
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	case f.PathPrefix != "" &&
+		!strings.HasPrefix(e.Path, f.PathPrefix) &&
+		!strings.HasPrefix(e.Destination, f.PathPrefix):
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+)
+
+// FileBackend stores the audit log in a JSON Lines file.
+type FileBackend struct {
+	path string
+	mux  sync.Mutex
+}
+
+// NewFileBackend creates a backend that appends to the JSON Lines file
+// at the given path.
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path}
+}
+
+// Append implements StorageBackend.
+func (b *FileBackend) Append(e *Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	fd, err := os.OpenFile(b.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = fd.Write(append(line, '\n')); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// Each implements StorageBackend. The file is read in full since lines
+// are stored oldest first.
+func (b *FileBackend) Each(fn func(e *Entry) error) error {
+	b.mux.Lock()
+	entries, err := b.read()
+	b.mux.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := fn(entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *FileBackend) read() ([]*Entry, error) {
+	fd, err := os.Open(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var entries []*Entry
+	scanner := bufio.NewScanner(fd)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+
+	return entries, scanner.Err()
+}
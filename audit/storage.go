@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+)
+
+// ErrStop can be returned by the function given to StorageBackend.Each
+// to stop the iteration.
+var ErrStop = errors.New("stop iteration")
+
+// StorageBackend is the interface to implement for an audit log storage.
+// Entries are only ever appended.
+type StorageBackend interface {
+	Append(e *Entry) error
+	// Each calls fn for every entry, newest first.
+	Each(fn func(e *Entry) error) error
+}
+
+// Storage is an audit log storage.
+type Storage struct {
+	back StorageBackend
+	now  func() time.Time
+}
+
+// NewStorage creates an audit log storage from a backend.
+func NewStorage(back StorageBackend) *Storage {
+	return &Storage{back: back, now: time.Now}
+}
+
+// Record appends an entry to the audit log. Failures are logged instead
+// of returned since they must not make the audited operation fail. It
+// does nothing on a nil storage.
+func (s *Storage) Record(e *Entry) {
+	if s == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = s.now()
+	}
+	if e.Outcome == "" {
+		e.Outcome = OutcomeSuccess
+	}
+
+	if err := s.back.Append(e); err != nil {
+		log.Printf("WARNING: couldn't write audit log entry: %v", err)
+	}
+}
+
+// Query returns the entries selected by the filter, newest first.
+func (s *Storage) Query(f Filter) ([]*Entry, error) {
+	entries := []*Entry{}
+	skipped := 0
+
+	err := s.back.Each(func(e *Entry) error {
+		if !f.Matches(e) {
+			return nil
+		}
+		if skipped < f.Offset {
+			skipped++
+			return nil
+		}
+
+		entries = append(entries, e)
+		if f.Limit > 0 && len(entries) >= f.Limit {
+			return ErrStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrStop) {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Subscribe records the operations published on the bus, once they are
+// done, whatever their outcome.
+func (s *Storage) Subscribe(bus *events.Bus) {
+	bus.Subscribe(func(e *events.Event) error {
+		if e.Phase != events.After {
+			return nil
+		}
+
+		entry := &Entry{
+			Time:        e.Time,
+			Username:    e.Username(),
+			IP:          e.IP,
+			Scope:       e.Scope.Name,
+			Action:      Action(e.Type),
+			Path:        e.Path,
+			Destination: e.Destination,
+			Size:        e.Size,
+			Outcome:     OutcomeSuccess,
+		}
+		if e.User != nil {
+			entry.UserID = e.User.ID
+		}
+		if e.Err != nil {
+			entry.Outcome = OutcomeFailure
+			entry.Error = e.Err.Error()
+		}
+
+		s.Record(entry)
+		return nil
+	})
+}
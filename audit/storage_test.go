@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type memBackend struct {
+	entries []*Entry
+}
+
+func (m *memBackend) Append(e *Entry) error {
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func (m *memBackend) Each(fn func(e *Entry) error) error {
+	for i := len(m.entries) - 1; i >= 0; i-- {
+		if err := fn(m.entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func record(s *Storage) {
+	s.Record(&Entry{Username: "alice", Action: ActionUpload, Path: "/docs/a.txt", Size: 10})
+	s.Record(&Entry{Username: "bob", Action: ActionDelete, Path: "/docs/b.txt"})
+	s.Record(&Entry{Username: "alice", Action: ActionRename, Path: "/tmp/c.txt", Destination: "/docs/c.txt"})
+	s.Record(&Entry{Username: "alice", Action: ActionLogin, Outcome: OutcomeFailure, Error: "denied"})
+}
+
+func TestStorageQuery(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	now := start
+
+	s := NewStorage(&memBackend{})
+	s.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	record(s)
+
+	tests := map[string]struct {
+		filter Filter
+		want   []string
+	}{
+		"all newest first": {Filter{}, []string{"login", "rename", "delete", "upload"}},
+		"user":             {Filter{Username: "alice"}, []string{"login", "rename", "upload"}},
+		"action":           {Filter{Action: ActionDelete}, []string{"delete"}},
+		"outcome":          {Filter{Outcome: OutcomeFailure}, []string{"login"}},
+		"path destination": {Filter{PathPrefix: "/docs/"}, []string{"rename", "delete", "upload"}},
+		"time range": {
+			Filter{Since: start.Add(2 * time.Minute), Until: start.Add(4 * time.Minute)},
+			[]string{"rename", "delete"},
+		},
+		"limit offset": {Filter{Limit: 2, Offset: 1}, []string{"rename", "delete"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			entries, err := s.Query(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, len(entries))
+			for i, e := range entries {
+				got[i] = string(e.Action)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStorageRecordDefaults(t *testing.T) {
+	var s *Storage
+	s.Record(&Entry{}) // must not panic
+
+	s = NewStorage(&memBackend{})
+	s.Record(&Entry{Action: ActionSave})
+
+	entries, err := s.Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Outcome != OutcomeSuccess || entries[0].Time.IsZero() {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
+
+func TestFileBackend(t *testing.T) {
+	back := NewFileBackend(filepath.Join(t.TempDir(), "audit.jsonl"))
+
+	err := back.Each(func(*Entry) error { return errors.New("no entries expected") })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewStorage(back)
+	record(s)
+
+	entries, err := s.Query(Filter{Username: "alice", Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Action != ActionLogin || entries[1].Destination != "/docs/c.txt" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
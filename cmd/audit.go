@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/futureharmony/storagebrowser/v2/audit"
+)
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Audit log utility",
+	Long:  `Audit log utility.`,
+	Args:  cobra.NoArgs,
+}
+
+func addAuditFilterFlags(flags *pflag.FlagSet) {
+	flags.String("user", "", "only entries of this username")
+	flags.String("action", "", "only entries of this action")
+	flags.String("outcome", "", "only entries with this outcome (success, failure)")
+	flags.String("path", "", "only entries of paths beginning with this prefix")
+	flags.String("since", "", "only entries at or after this time (RFC 3339 or YYYY-MM-DD)")
+	flags.String("until", "", "only entries before this time (RFC 3339 or YYYY-MM-DD)")
+	flags.Int("limit", 0, "maximum number of entries (0 for no limit)")
+}
+
+func getAuditFilter(flags *pflag.FlagSet) (audit.Filter, error) {
+	var (
+		filter audit.Filter
+		err    error
+		val    string
+	)
+
+	if filter.Username, err = getString(flags, "user"); err != nil {
+		return filter, err
+	}
+	if val, err = getString(flags, "action"); err != nil {
+		return filter, err
+	}
+	filter.Action = audit.Action(val)
+	if val, err = getString(flags, "outcome"); err != nil {
+		return filter, err
+	}
+	filter.Outcome = audit.Outcome(val)
+	if filter.PathPrefix, err = getString(flags, "path"); err != nil {
+		return filter, err
+	}
+	if filter.Since, err = getAuditTime(flags, "since"); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = getAuditTime(flags, "until"); err != nil {
+		return filter, err
+	}
+	filter.Limit, err = flags.GetInt("limit")
+	return filter, err
+}
+
+func getAuditTime(flags *pflag.FlagSet, flag string) (time.Time, error) {
+	val, err := getString(flags, flag)
+	if err != nil || val == "" {
+		return time.Time{}, err
+	}
+
+	if t, err := time.ParseInLocation(time.DateOnly, val, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, val)
+}
+
+func printAuditEntries(entries []*audit.Entry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Time\tUser\tIP\tScope\tAction\tPath\tDestination\tSize\tOutcome\tError")
+
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t\n",
+			e.Time.Format(time.RFC3339),
+			e.Username,
+			e.IP,
+			e.Scope,
+			e.Action,
+			e.Path,
+			e.Destination,
+			e.Size,
+			e.Outcome,
+			e.Error,
+		)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	auditCmd.AddCommand(auditExportCmd)
+	addAuditFilterFlags(auditExportCmd.Flags())
+	auditExportCmd.Flags().StringP("output", "o", "", "file to write to (standard output if empty)")
+}
+
+var auditExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export audit log entries",
+	Long: `Export audit log entries matching the given filters as
+JSON Lines, oldest first.`,
+	Args: cobra.NoArgs,
+	RunE: python(func(cmd *cobra.Command, _ []string, d *pythonData) error {
+		filter, err := getAuditFilter(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		output, err := getString(cmd.Flags(), "output")
+		if err != nil {
+			return err
+		}
+
+		entries, err := d.store.Audit.Query(filter)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if output != "" {
+			fd, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer fd.Close()
+			out = fd
+		}
+
+		encoder := json.NewEncoder(out)
+		for i := len(entries) - 1; i >= 0; i-- {
+			if err := encoder.Encode(entries[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, pythonConfig{}),
+}
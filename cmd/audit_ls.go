@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	auditCmd.AddCommand(auditLsCmd)
+	addAuditFilterFlags(auditLsCmd.Flags())
+}
+
+var auditLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List audit log entries",
+	Long:  `List audit log entries, newest first, matching the given filters.`,
+	Args:  cobra.NoArgs,
+	RunE: python(func(cmd *cobra.Command, _ []string, d *pythonData) error {
+		filter, err := getAuditFilter(cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		entries, err := d.store.Audit.Query(filter)
+		if err != nil {
+			return err
+		}
+
+		printAuditEntries(entries)
+		return nil
+	}, pythonConfig{}),
+}
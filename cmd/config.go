@@ -14,6 +14,7 @@ import (
 	"github.com/futureharmony/storagebrowser/v2/auth"
 	"github.com/futureharmony/storagebrowser/v2/errors"
 	"github.com/futureharmony/storagebrowser/v2/settings"
+	"github.com/futureharmony/storagebrowser/v2/upload"
 )
 
 func init() {
@@ -35,6 +36,22 @@ func addConfigFlags(flags *pflag.FlagSet) {
 	flags.Uint("minimum-password-length", settings.DefaultMinimumPasswordLength, "minimum password length for new users")
 	flags.String("shell", "", "shell command to which other commands should be appended")
 
+	flags.Bool("lockout.disabled", false, "disable login brute-force protection")
+	flags.Uint("lockout.maxAttempts", settings.DefaultLockoutMaxAttempts, "failed logins before a username or IP is locked out")
+	flags.Uint("lockout.duration", settings.DefaultLockoutDuration, "lockout duration in seconds")
+	flags.Uint("lockout.backoff", settings.DefaultLockoutBackoff, "seconds a failed login is delayed by, doubled on each failure")
+	flags.Uint("lockout.maxBackoff", settings.DefaultLockoutMaxBackoff, "maximum delay of a failed login in seconds")
+	flags.String("webhooks.secret", "", "secret to sign webhook payloads with HMAC-SHA256")
+	flags.Uint("webhooks.maxAttempts", settings.DefaultWebhookMaxAttempts, "delivery attempts per webhook notification")
+	flags.Uint("webhooks.backoff", settings.DefaultWebhookBackoff, "seconds before retrying a delivery, doubled on each retry")
+	flags.Uint("webhooks.timeout", settings.DefaultWebhookTimeout, "webhook request timeout in seconds")
+	flags.Uint64("upload.maxSize", 0, "maximum size in bytes of an uploaded file (0 for unlimited)")
+	flags.StringSlice("upload.allowedExtensions", nil, "only allow uploading files with these extensions")
+	flags.StringSlice("upload.blockedExtensions", nil, "block uploading files with these extensions")
+	flags.StringSlice("upload.allowedTypes", nil, "only allow uploading files of these sniffed MIME types (e.g. image/*)")
+	flags.StringSlice("upload.blockedTypes", nil, "block uploading files of these sniffed MIME types")
+	flags.Bool("upload.normalizeNames", false, "normalize uploaded file names to Unicode NFC and replace reserved characters")
+
 	flags.String("auth.method", string(auth.MethodJSONAuth), "authentication type")
 	flags.String("auth.header", "", "HTTP header for auth.method=proxy")
 	flags.String("auth.command", "", "command for auth.method=hook")
@@ -196,6 +213,23 @@ func printSettings(ser *settings.Server, set *settings.Settings, auther auth.Aut
 	fmt.Fprintf(w, "Minimum Password Length:\t%d\n", set.MinimumPasswordLength)
 	fmt.Fprintf(w, "Auth method:\t%s\n", set.AuthMethod)
 	fmt.Fprintf(w, "Shell:\t%s\t\n", strings.Join(set.Shell, " "))
+	fmt.Fprintln(w, "\nLogin Lockout:")
+	fmt.Fprintf(w, "\tDisabled:\t%t\n", set.Lockout.Disabled)
+	fmt.Fprintf(w, "\tMax Attempts:\t%d\n", set.Lockout.MaxAttempts)
+	fmt.Fprintf(w, "\tDuration:\t%ds\n", set.Lockout.Duration)
+	fmt.Fprintf(w, "\tBackoff:\t%ds (max %ds)\n", set.Lockout.Backoff, set.Lockout.MaxBackoff)
+	fmt.Fprintln(w, "\nWebhooks:")
+	fmt.Fprintf(w, "\tSigned:\t%t\n", set.Webhooks.Secret != "")
+	fmt.Fprintf(w, "\tMax Attempts:\t%d\n", set.Webhooks.MaxAttempts)
+	fmt.Fprintf(w, "\tBackoff:\t%ds\n", set.Webhooks.Backoff)
+	fmt.Fprintf(w, "\tTimeout:\t%ds\n", set.Webhooks.Timeout)
+	fmt.Fprintln(w, "\nUpload Policy:")
+	fmt.Fprintf(w, "\tMax Size:\t%d\n", set.Upload.MaxSize)
+	fmt.Fprintf(w, "\tAllowed Extensions:\t%s\n", strings.Join(set.Upload.AllowedExtensions, " "))
+	fmt.Fprintf(w, "\tBlocked Extensions:\t%s\n", strings.Join(set.Upload.BlockedExtensions, " "))
+	fmt.Fprintf(w, "\tAllowed Types:\t%s\n", strings.Join(set.Upload.AllowedTypes, " "))
+	fmt.Fprintf(w, "\tBlocked Types:\t%s\n", strings.Join(set.Upload.BlockedTypes, " "))
+	fmt.Fprintf(w, "\tNormalize Names:\t%t\n", set.Upload.NormalizeNames)
 	fmt.Fprintln(w, "\nBranding:")
 	fmt.Fprintf(w, "\tName:\t%s\n", set.Branding.Name)
 	fmt.Fprintf(w, "\tFiles override:\t%s\n", set.Branding.Files)
@@ -205,6 +239,10 @@ func printSettings(ser *settings.Server, set *settings.Settings, auther auth.Aut
 	fmt.Fprintf(w, "\tTheme:\t%s\n", set.Branding.Theme)
 	fmt.Fprintln(w, "\nServer:")
 	fmt.Fprintf(w, "\tLog:\t%s\n", ser.Log)
+	fmt.Fprintf(w, "\tAudit Log:\t%s\n", ser.AuditLog)
+	fmt.Fprintf(w, "\tContent Index:\t%s\n", ser.ContentIndex)
+	fmt.Fprintf(w, "\tMetadata Index:\t%s\n", ser.MetadataIndex)
+	fmt.Fprintf(w, "\tMetadata Rescan:\t%s\n", ser.MetadataRescan)
 	fmt.Fprintf(w, "\tPort:\t%s\n", ser.Port)
 	fmt.Fprintf(w, "\tBase URL:\t%s\n", ser.BaseURL)
 	fmt.Fprintf(w, "\tRoot:\t%s\n", ser.Root)
@@ -218,6 +256,7 @@ func printSettings(ser *settings.Server, set *settings.Settings, auther auth.Aut
 	fmt.Fprintf(w, "\tAccess Key:\t%s\n", ser.S3AccessKey)
 	fmt.Fprintf(w, "\tSecret Key:\t%s\n", "***")
 	fmt.Fprintf(w, "\tRegion:\t%s\n", ser.S3Region)
+	fmt.Fprintf(w, "\tBucket Events:\t%t\n", ser.BucketEventsToken != "")
 	fmt.Fprintln(w, "\nDefaults:")
 	fmt.Fprintf(w, "\tScope:\t%s\n", set.Defaults.Scope)
 	fmt.Fprintf(w, "\tLocale:\t%s\n", set.Defaults.Locale)
@@ -238,6 +277,9 @@ func printSettings(ser *settings.Server, set *settings.Settings, auther auth.Aut
 	fmt.Fprintf(w, "\t\tDelete:\t%t\n", set.Defaults.Perm.Delete)
 	fmt.Fprintf(w, "\t\tShare:\t%t\n", set.Defaults.Perm.Share)
 	fmt.Fprintf(w, "\t\tDownload:\t%t\n", set.Defaults.Perm.Download)
+	fmt.Fprintf(w, "\tQuota:\n")
+	fmt.Fprintf(w, "\t\tBytes:\t%d\n", set.Defaults.Quota.Bytes)
+	fmt.Fprintf(w, "\t\tObjects:\t%d\n", set.Defaults.Quota.Objects)
 	w.Flush()
 
 	b, err := json.MarshalIndent(auther, "", "  ")
@@ -247,3 +289,74 @@ func printSettings(ser *settings.Server, set *settings.Settings, auther auth.Aut
 	fmt.Printf("\nAuther configuration (raw):\n\n%s\n\n", string(b))
 	return nil
 }
+
+func getLockout(flags *pflag.FlagSet, lockout *settings.Lockout, all bool) error {
+	var visitErr error
+	visit := func(flag *pflag.Flag) {
+		if visitErr != nil {
+			return
+		}
+		var err error
+		switch flag.Name {
+		case "lockout.disabled":
+			lockout.Disabled, err = getBool(flags, flag.Name)
+		case "lockout.maxAttempts":
+			lockout.MaxAttempts, err = getUint(flags, flag.Name)
+		case "lockout.duration":
+			lockout.Duration, err = getUint(flags, flag.Name)
+		case "lockout.backoff":
+			lockout.Backoff, err = getUint(flags, flag.Name)
+		case "lockout.maxBackoff":
+			lockout.MaxBackoff, err = getUint(flags, flag.Name)
+		}
+		if err != nil {
+			visitErr = err
+		}
+	}
+
+	if all {
+		flags.VisitAll(visit)
+	} else {
+		flags.Visit(visit)
+	}
+	return visitErr
+}
+
+func getUploadPolicy(flags *pflag.FlagSet, policy *upload.Policy, all bool) error {
+	var visitErr error
+	visit := func(flag *pflag.Flag) {
+		if visitErr != nil {
+			return
+		}
+		var err error
+		switch flag.Name {
+		case "upload.maxSize":
+			policy.MaxSize, err = flags.GetUint64(flag.Name)
+		case "upload.allowedExtensions":
+			policy.AllowedExtensions, err = flags.GetStringSlice(flag.Name)
+		case "upload.blockedExtensions":
+			policy.BlockedExtensions, err = flags.GetStringSlice(flag.Name)
+		case "upload.allowedTypes":
+			policy.AllowedTypes, err = flags.GetStringSlice(flag.Name)
+		case "upload.blockedTypes":
+			policy.BlockedTypes, err = flags.GetStringSlice(flag.Name)
+		case "upload.normalizeNames":
+			policy.NormalizeNames, err = getBool(flags, flag.Name)
+		}
+		if err != nil {
+			visitErr = err
+		}
+	}
+
+	if all {
+		flags.VisitAll(visit)
+	} else {
+		flags.Visit(visit)
+	}
+	if visitErr != nil {
+		return visitErr
+	}
+
+	policy.Clean()
+	return nil
+}
@@ -98,6 +98,16 @@ override the options.`,
 			},
 		}
 
+		err = getLockout(flags, &s.Lockout, true)
+		if err != nil {
+			return err
+		}
+
+		err = getUploadPolicy(flags, &s.Upload, true)
+		if err != nil {
+			return err
+		}
+
 		s.FileMode, err = getMode(flags, "file-mode")
 		if err != nil {
 			return err
@@ -148,15 +158,21 @@ override the options.`,
 			return err
 		}
 
+		auditLog, err := getString(flags, "audit-log")
+		if err != nil {
+			return err
+		}
+
 		ser := &settings.Server{
-			Address: address,
-			Socket:  socket,
-			Root:    root,
-			BaseURL: baseURL,
-			TLSKey:  tlsKey,
-			TLSCert: cert,
-			Port:    port,
-			Log:     log,
+			Address:  address,
+			Socket:   socket,
+			Root:     root,
+			BaseURL:  baseURL,
+			TLSKey:   tlsKey,
+			TLSCert:  cert,
+			Port:     port,
+			Log:      log,
+			AuditLog: auditLog,
 		}
 
 		err = d.store.Settings.Save(s)
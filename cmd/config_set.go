@@ -50,6 +50,16 @@ you want to change. Other options will remain unchanged.`,
 				ser.Port, err = getString(flags, flag.Name)
 			case "log":
 				ser.Log, err = getString(flags, flag.Name)
+			case "audit-log":
+				ser.AuditLog, err = getString(flags, flag.Name)
+			case "content-index":
+				ser.ContentIndex, err = getString(flags, flag.Name)
+			case "metadata-index":
+				ser.MetadataIndex, err = getString(flags, flag.Name)
+			case "metadata-rescan":
+				ser.MetadataRescan, err = getString(flags, flag.Name)
+			case "bucket-events-token":
+				ser.BucketEventsToken, err = getString(flags, flag.Name)
 			case "signup":
 				set.Signup, err = getBool(flags, flag.Name)
 			case "auth.method":
@@ -62,6 +72,14 @@ you want to change. Other options will remain unchanged.`,
 				set.CreateUserDir, err = getBool(flags, flag.Name)
 			case "minimum-password-length":
 				set.MinimumPasswordLength, err = getUint(flags, flag.Name)
+			case "webhooks.secret":
+				set.Webhooks.Secret, err = getString(flags, flag.Name)
+			case "webhooks.maxAttempts":
+				set.Webhooks.MaxAttempts, err = getUint(flags, flag.Name)
+			case "webhooks.backoff":
+				set.Webhooks.Backoff, err = getUint(flags, flag.Name)
+			case "webhooks.timeout":
+				set.Webhooks.Timeout, err = getUint(flags, flag.Name)
 			case "branding.name":
 				set.Branding.Name, err = getString(flags, flag.Name)
 			case "branding.color":
@@ -98,6 +116,16 @@ you want to change. Other options will remain unchanged.`,
 			return err
 		}
 
+		err = getLockout(flags, &set.Lockout, false)
+		if err != nil {
+			return err
+		}
+
+		err = getUploadPolicy(flags, &set.Upload, false)
+		if err != nil {
+			return err
+		}
+
 		// read the defaults
 		auther, err := d.store.Auth.Get(set.AuthMethod)
 		if err != nil {
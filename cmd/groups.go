@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func init() {
+	rootCmd.AddCommand(groupsCmd)
+}
+
+var groupsCmd = &cobra.Command{
+	Use:   "groups",
+	Short: "Groups management utility",
+	Long:  `Groups management utility.`,
+	Args:  cobra.NoArgs,
+}
+
+func printGroups(groups []*users.Group) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tName\tScopes\tAdmin\tExecute\tCreate\tRename\tModify\tDelete\tShare\tDownload\tRules")
+
+	for _, g := range groups {
+		scopes := make([]string, len(g.AvailableScopes))
+		for i, scope := range g.AvailableScopes {
+			scopes[i] = formatScope(scope)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%t\t%t\t%t\t%t\t%t\t%t\t%t\t%d\t\n",
+			g.ID,
+			g.Name,
+			strings.Join(scopes, ","),
+			g.Perm.Admin,
+			g.Perm.Execute,
+			g.Perm.Create,
+			g.Perm.Rename,
+			g.Perm.Modify,
+			g.Perm.Delete,
+			g.Perm.Share,
+			g.Perm.Download,
+			len(g.Rules),
+		)
+	}
+
+	w.Flush()
+}
+
+func addGroupFlags(flags *pflag.FlagSet) {
+	flags.Bool("perm.admin", false, "admin perm for the group")
+	flags.Bool("perm.execute", false, "execute perm for the group")
+	flags.Bool("perm.create", false, "create perm for the group")
+	flags.Bool("perm.rename", false, "rename perm for the group")
+	flags.Bool("perm.modify", false, "modify perm for the group")
+	flags.Bool("perm.delete", false, "delete perm for the group")
+	flags.Bool("perm.share", false, "share perm for the group")
+	flags.Bool("perm.download", false, "download perm for the group")
+	flags.StringSlice("commands", nil, "a list of the commands the group members can execute")
+	flags.StringSlice("scopes", nil, "a list of scopes as bucket or bucket:/prefix")
+}
+
+// getGroupFlags applies the flags that were set, or all of them
+// if all is true, to the group.
+func getGroupFlags(flags *pflag.FlagSet, g *users.Group, all bool) error {
+	var visitErr error
+	visit := func(flag *pflag.Flag) {
+		if visitErr != nil {
+			return
+		}
+		var err error
+		switch flag.Name {
+		case "perm.admin":
+			g.Perm.Admin, err = getBool(flags, flag.Name)
+		case "perm.execute":
+			g.Perm.Execute, err = getBool(flags, flag.Name)
+		case "perm.create":
+			g.Perm.Create, err = getBool(flags, flag.Name)
+		case "perm.rename":
+			g.Perm.Rename, err = getBool(flags, flag.Name)
+		case "perm.modify":
+			g.Perm.Modify, err = getBool(flags, flag.Name)
+		case "perm.delete":
+			g.Perm.Delete, err = getBool(flags, flag.Name)
+		case "perm.share":
+			g.Perm.Share, err = getBool(flags, flag.Name)
+		case "perm.download":
+			g.Perm.Download, err = getBool(flags, flag.Name)
+		case "commands":
+			g.Commands, err = flags.GetStringSlice(flag.Name)
+		case "scopes":
+			var raw []string
+			raw, err = flags.GetStringSlice(flag.Name)
+			g.AvailableScopes = parseScopes(raw)
+		}
+		if err != nil {
+			visitErr = err
+		}
+	}
+
+	if all {
+		flags.VisitAll(visit)
+	} else {
+		flags.Visit(visit)
+	}
+	return visitErr
+}
+
+func parseScopes(raw []string) []users.Scope {
+	scopes := make([]users.Scope, 0, len(raw))
+	for _, s := range raw {
+		name, prefix, _ := strings.Cut(s, ":")
+		if prefix == "" {
+			prefix = "/"
+		}
+		scopes = append(scopes, users.Scope{Name: name, RootPrefix: prefix})
+	}
+	return scopes
+}
+
+func formatScope(scope users.Scope) string {
+	if scope.RootPrefix == "" || scope.RootPrefix == "/" {
+		return scope.Name
+	}
+	return scope.Name + ":" + scope.RootPrefix
+}
+
+func getGroup(d *pythonData, arg string) (*users.Group, error) {
+	name, id := parseUsernameOrID(arg)
+	if name != "" {
+		return d.store.Groups.Get(name)
+	}
+	return d.store.Groups.Get(id)
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func init() {
+	groupsCmd.AddCommand(groupsAddCmd)
+	addGroupFlags(groupsAddCmd.Flags())
+}
+
+var groupsAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Create a new group",
+	Long:  `Create a new group and add it to the database.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: python(func(cmd *cobra.Command, args []string, d *pythonData) error {
+		group := &users.Group{Name: args[0]}
+		err := getGroupFlags(cmd.Flags(), group, true)
+		if err != nil {
+			return err
+		}
+
+		err = d.store.Groups.Save(group)
+		if err != nil {
+			return err
+		}
+		printGroups([]*users.Group{group})
+		return nil
+	}, pythonConfig{}),
+}
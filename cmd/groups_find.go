@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func init() {
+	groupsCmd.AddCommand(groupsFindCmd)
+	groupsCmd.AddCommand(groupsLsCmd)
+}
+
+var groupsFindCmd = &cobra.Command{
+	Use:   "find <id|name>",
+	Short: "Find a group by name or id",
+	Long:  `Find a group by name or id.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  findGroups,
+}
+
+var groupsLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List all groups.",
+	Args:  cobra.NoArgs,
+	RunE:  findGroups,
+}
+
+var findGroups = python(func(_ *cobra.Command, args []string, d *pythonData) error {
+	var (
+		list  []*users.Group
+		group *users.Group
+		err   error
+	)
+
+	if len(args) == 1 {
+		group, err = getGroup(d, args[0])
+		list = []*users.Group{group}
+	} else {
+		list, err = d.store.Groups.Gets()
+		if errors.Is(err, fbErrors.ErrNotExist) {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	printGroups(list)
+	return nil
+}, pythonConfig{})
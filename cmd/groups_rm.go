@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	groupsCmd.AddCommand(groupsRmCmd)
+}
+
+var groupsRmCmd = &cobra.Command{
+	Use:   "rm <id|name>",
+	Short: "Delete a group by name or id",
+	Long: `Delete a group by name or id. Users that belong to it
+lose the permissions and scopes it granted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: python(func(_ *cobra.Command, args []string, d *pythonData) error {
+		name, id := parseUsernameOrID(args[0])
+		var err error
+
+		if name != "" {
+			err = d.store.Groups.Delete(name)
+		} else {
+			err = d.store.Groups.Delete(id)
+		}
+
+		if err != nil {
+			return err
+		}
+		fmt.Println("group deleted successfully")
+		return nil
+	}, pythonConfig{}),
+}
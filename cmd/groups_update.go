@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func init() {
+	groupsCmd.AddCommand(groupsUpdateCmd)
+
+	groupsUpdateCmd.Flags().StringP("name", "n", "", "new name")
+	addGroupFlags(groupsUpdateCmd.Flags())
+}
+
+var groupsUpdateCmd = &cobra.Command{
+	Use:   "update <id|name>",
+	Short: "Updates an existing group",
+	Long: `Updates an existing group. Set the flags for the
+options you want to change.`,
+	Args: cobra.ExactArgs(1),
+	RunE: python(func(cmd *cobra.Command, args []string, d *pythonData) error {
+		flags := cmd.Flags()
+		group, err := getGroup(d, args[0])
+		if err != nil {
+			return err
+		}
+
+		newName, err := getString(flags, "name")
+		if err != nil {
+			return err
+		}
+		if newName != "" {
+			group.Name = newName
+		}
+
+		err = getGroupFlags(flags, group, false)
+		if err != nil {
+			return err
+		}
+
+		err = d.store.Groups.Save(group)
+		if err != nil {
+			return err
+		}
+		printGroups([]*users.Group{group})
+		return nil
+	}, pythonConfig{}),
+}
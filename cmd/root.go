@@ -40,6 +40,12 @@ var (
 	cfgFile string
 )
 
+// shareSweepInterval is how often the expired share links are deleted.
+const shareSweepInterval = 10 * time.Minute
+
+// jobSweepInterval is how often the records of old jobs are deleted.
+const jobSweepInterval = time.Hour
+
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.SilenceUsage = true
@@ -62,6 +68,10 @@ func init() {
 func addServerFlags(flags *pflag.FlagSet) {
 	flags.StringP("address", "a", "127.0.0.1", "address to listen on")
 	flags.StringP("log", "l", "stdout", "log output")
+	flags.String("audit-log", "", "audit log JSON Lines file (kept in the database if empty)")
+	flags.String("content-index", "", "full-text content index database (disabled if empty)")
+	flags.String("metadata-index", "", "S3 object metadata index database (disabled if empty)")
+	flags.String("metadata-rescan", "1h", "interval between the rescans of the buckets by the metadata index")
 	flags.StringP("port", "p", "8080", "port to listen on")
 	flags.StringP("cert", "t", "", "tls certificate")
 	flags.StringP("key", "k", "", "tls key")
@@ -81,6 +91,7 @@ func addServerFlags(flags *pflag.FlagSet) {
 	flags.String("s3-access-key", "", "S3 access key")
 	flags.String("s3-secret-key", "", "S3 secret key")
 	flags.String("s3-region", "us-east-1", "S3 region")
+	flags.String("bucket-events-token", "", "token of the S3 bucket notifications sent to /api/bucket-events (disabled if empty)")
 }
 
 var rootCmd = &cobra.Command{
@@ -175,6 +186,36 @@ user created with the credentials from options "username" and "password".`,
 		}
 
 		setupLog(server.Log)
+		useAuditLog(d.store, server.AuditLog)
+
+		if server.ContentIndex != "" {
+			indexDB, indexErr := openContentIndex(d.store, server.ContentIndex)
+			if indexErr != nil {
+				return indexErr
+			}
+			defer indexDB.Close()
+		}
+
+		if server.MetadataIndex != "" && server.StorageType == "s3" {
+			indexDB, indexErr := openMetadataIndex(d.store, server.MetadataIndex)
+			if indexErr != nil {
+				return indexErr
+			}
+			defer indexDB.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go d.store.Metadata.Run(ctx, server.GetMetadataRescan(time.Hour), minio.ListBuckets)
+		}
+
+		sweepCtx, cancelSweep := context.WithCancel(context.Background())
+		defer cancelSweep()
+		go d.store.Share.Sweep(sweepCtx, shareSweepInterval)
+
+		if err := d.store.Jobs.Recover(); err != nil {
+			return err
+		}
+		go d.store.Jobs.Sweep(sweepCtx, jobSweepInterval)
 
 		root, err := filepath.Abs(server.Root)
 		if err != nil {
@@ -298,6 +339,26 @@ func getRunParams(flags *pflag.FlagSet, st *storage.Storage) (*settings.Server,
 		server.Log = val
 	}
 
+	if val, set := getStringParamB(flags, "audit-log"); set {
+		server.AuditLog = val
+	}
+
+	if val, set := getStringParamB(flags, "content-index"); set {
+		server.ContentIndex = val
+	}
+
+	if val, set := getStringParamB(flags, "metadata-index"); set {
+		server.MetadataIndex = val
+	}
+
+	if val, set := getStringParamB(flags, "metadata-rescan"); set {
+		server.MetadataRescan = val
+	}
+
+	if val, set := getStringParamB(flags, "bucket-events-token"); set {
+		server.BucketEventsToken = val
+	}
+
 	isSocketSet := false
 	isAddrSet := false
 
@@ -516,18 +577,24 @@ func quickSetup(flags *pflag.FlagSet, d pythonData) error {
 	}
 
 	ser := &settings.Server{
-		BaseURL:     getStringParam(flags, "baseurl"),
-		Port:        getStringParam(flags, "port"),
-		Log:         getStringParam(flags, "log"),
-		TLSKey:      getStringParam(flags, "key"),
-		TLSCert:     getStringParam(flags, "cert"),
-		Address:     getStringParam(flags, "address"),
-		Root:        getStringParam(flags, "root"),
-		StorageType: getStringParam(flags, "storage-type"),
-		S3Endpoint:  getStringParam(flags, "s3-endpoint"),
-		S3AccessKey: getStringParam(flags, "s3-access-key"),
-		S3SecretKey: getStringParam(flags, "s3-secret-key"),
-		S3Region:    getStringParam(flags, "s3-region"),
+		BaseURL:        getStringParam(flags, "baseurl"),
+		Port:           getStringParam(flags, "port"),
+		Log:            getStringParam(flags, "log"),
+		AuditLog:       getStringParam(flags, "audit-log"),
+		ContentIndex:   getStringParam(flags, "content-index"),
+		MetadataIndex:  getStringParam(flags, "metadata-index"),
+		MetadataRescan: getStringParam(flags, "metadata-rescan"),
+		TLSKey:         getStringParam(flags, "key"),
+		TLSCert:        getStringParam(flags, "cert"),
+		Address:        getStringParam(flags, "address"),
+		Root:           getStringParam(flags, "root"),
+		StorageType:    getStringParam(flags, "storage-type"),
+		S3Endpoint:     getStringParam(flags, "s3-endpoint"),
+		S3AccessKey:    getStringParam(flags, "s3-access-key"),
+		S3SecretKey:    getStringParam(flags, "s3-secret-key"),
+		S3Region:       getStringParam(flags, "s3-region"),
+
+		BucketEventsToken: getStringParam(flags, "bucket-events-token"),
 	}
 
 	err = d.store.Settings.SaveServer(ser)
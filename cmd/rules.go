@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -96,18 +97,52 @@ func printRules(rulez []rules.Rule, id interface{}) {
 
 	for id, rule := range rulez {
 		fmt.Printf("(%d) ", id)
-		if rule.Regex {
+		ops := ""
+		if len(rule.Ops) > 0 {
+			names := make([]string, len(rule.Ops))
+			for i, op := range rule.Ops {
+				names[i] = string(op)
+			}
+			ops = " [" + strings.Join(names, ",") + "]"
+		}
+
+		switch {
+		case rule.Regex:
+			if rule.Allow {
+				fmt.Printf("Allow Regex: \t%s%s\n", rule.Regexp.Raw, ops)
+			} else {
+				fmt.Printf("Disallow Regex: \t%s%s\n", rule.Regexp.Raw, ops)
+			}
+		case rule.Glob:
 			if rule.Allow {
-				fmt.Printf("Allow Regex: \t%s\n", rule.Regexp.Raw)
+				fmt.Printf("Allow Glob: \t%s%s\n", rule.Path, ops)
 			} else {
-				fmt.Printf("Disallow Regex: \t%s\n", rule.Regexp.Raw)
+				fmt.Printf("Disallow Glob: \t%s%s\n", rule.Path, ops)
 			}
-		} else {
+		default:
 			if rule.Allow {
-				fmt.Printf("Allow Path: \t%s\n", rule.Path)
+				fmt.Printf("Allow Path: \t%s%s\n", rule.Path, ops)
 			} else {
-				fmt.Printf("Disallow Path: \t%s\n", rule.Path)
+				fmt.Printf("Disallow Path: \t%s%s\n", rule.Path, ops)
 			}
 		}
 	}
 }
+
+func getOperations(flags *pflag.FlagSet) ([]rules.Operation, error) {
+	names, err := flags.GetStringSlice("ops")
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []rules.Operation
+	for _, name := range names {
+		op, err := rules.ParseOperation(name)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
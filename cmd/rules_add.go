@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"regexp"
 
 	"github.com/spf13/cobra"
@@ -14,6 +15,8 @@ func init() {
 	rulesCmd.AddCommand(rulesAddCmd)
 	rulesAddCmd.Flags().BoolP("allow", "a", false, "indicates this is an allow rule")
 	rulesAddCmd.Flags().BoolP("regex", "r", false, "indicates this is a regex rule")
+	rulesAddCmd.Flags().BoolP("glob", "g", false, "indicates this is a glob rule")
+	rulesAddCmd.Flags().StringSlice("ops", nil, "operations the rule applies to (read, create, modify, rename, delete, share, download); all if empty")
 }
 
 var rulesAddCmd = &cobra.Command{
@@ -30,15 +33,33 @@ var rulesAddCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		glob, err := getBool(cmd.Flags(), "glob")
+		if err != nil {
+			return err
+		}
+		if regex && glob {
+			return errors.New("a rule can't be both regex and glob")
+		}
+		ops, err := getOperations(cmd.Flags())
+		if err != nil {
+			return err
+		}
 		exp := args[0]
 
 		if regex {
 			regexp.MustCompile(exp)
 		}
+		if glob {
+			if _, err = rules.MatchGlob(exp, ""); err != nil {
+				return err
+			}
+		}
 
 		rule := rules.Rule{
 			Allow: allow,
 			Regex: regex,
+			Glob:  glob,
+			Ops:   ops,
 		}
 
 		if regex {
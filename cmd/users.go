@@ -69,10 +69,13 @@ func addUserFlags(flags *pflag.FlagSet) {
 	flags.Bool("perm.delete", true, "delete perm for users")
 	flags.Bool("perm.share", true, "share perm for users")
 	flags.Bool("perm.download", true, "download perm for users")
+	flags.Uint64("quota.bytes", 0, "maximum bytes a user can store (0 for unlimited)")
+	flags.Uint64("quota.objects", 0, "maximum files a user can store (0 for unlimited)")
 	flags.String("sorting.by", "name", "sorting mode (name, size or modified)")
 	flags.Bool("sorting.asc", false, "sorting by ascending order")
 	flags.Bool("lockPassword", false, "lock password")
 	flags.StringSlice("commands", nil, "a list of the commands a user can execute")
+	flags.UintSlice("groups", nil, "a list of the ids of the groups a user belongs to")
 	flags.String("scope", ".", "scope for users")
 	flags.String("locale", "en", "locale for users")
 	flags.String("viewMode", string(users.ListViewMode), "view mode for users")
@@ -126,6 +129,10 @@ func getUserDefaults(flags *pflag.FlagSet, defaults *settings.UserDefaults, all
 			defaults.Perm.Share, err = getBool(flags, flag.Name)
 		case "perm.download":
 			defaults.Perm.Download, err = getBool(flags, flag.Name)
+		case "quota.bytes":
+			defaults.Quota.Bytes, err = flags.GetUint64(flag.Name)
+		case "quota.objects":
+			defaults.Quota.Objects, err = flags.GetUint64(flag.Name)
 		case "commands":
 			defaults.Commands, err = flags.GetStringSlice(flag.Name)
 		case "sorting.by":
@@ -46,12 +46,18 @@ var usersAddCmd = &cobra.Command{
 			return err
 		}
 
+		groups, err := cmd.Flags().GetUintSlice("groups")
+		if err != nil {
+			return err
+		}
+
 		user := &users.User{
 			Username:     args[0],
 			Password:     password,
 			LockPassword: lockPassword,
 			DateFormat:   dateFormat,
 			HideDotfiles: hideDotfiles,
+			Groups:       groups,
 		}
 
 		s.Defaults.Apply(user)
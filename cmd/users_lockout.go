@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/lockout"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func init() {
+	usersCmd.AddCommand(usersLockoutsCmd)
+	usersCmd.AddCommand(usersUnlockCmd)
+
+	usersUnlockCmd.Flags().String("ip", "", "client IP to unlock instead of a user")
+}
+
+var usersLockoutsCmd = &cobra.Command{
+	Use:   "lockouts",
+	Short: "List failed login attempts",
+	Long: `List the failed login attempts recorded for every
+username and client IP, and whether they are locked out.`,
+	Args: cobra.NoArgs,
+	RunE: python(func(_ *cobra.Command, _ []string, d *pythonData) error {
+		attempts, err := d.store.Lockout.All()
+		if err != nil && !errors.Is(err, fbErrors.ErrNotExist) {
+			return err
+		}
+
+		printLockouts(attempts)
+		return nil
+	}, pythonConfig{}),
+}
+
+var usersUnlockCmd = &cobra.Command{
+	Use:   "unlock [id|username]",
+	Short: "Clear failed login attempts",
+	Long: `Clear the failed login attempts and lockout of a user
+by username or id, or of a client IP with the "ip" flag.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: python(func(cmd *cobra.Command, args []string, d *pythonData) error {
+		ip, err := getString(cmd.Flags(), "ip")
+		if err != nil {
+			return err
+		}
+
+		var key string
+		switch {
+		case ip != "" && len(args) == 0:
+			key = lockout.IPKey(ip)
+		case ip == "" && len(args) == 1:
+			var user *users.User
+			username, id := parseUsernameOrID(args[0])
+			if username != "" {
+				user, err = d.store.Users.Get("", username)
+			} else {
+				user, err = d.store.Users.Get("", id)
+			}
+			if err != nil {
+				return err
+			}
+			key = lockout.UserKey(user.Username)
+		default:
+			return errors.New("you must set either a user or the flag 'ip'")
+		}
+
+		if err := d.store.Lockout.Reset(key); err != nil {
+			return err
+		}
+		fmt.Printf("%s unlocked successfully\n", key)
+		return nil
+	}, pythonConfig{}),
+}
+
+func printLockouts(attempts []*lockout.Attempt) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Key\tFailures\tLast Failure\tLocked\tLocked Until")
+
+	now := time.Now()
+	for _, a := range attempts {
+		lockedUntil := "-"
+		if a.Locked(now) {
+			lockedUntil = time.Unix(a.LockedUntil, 0).Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%s\t%t\t%s\t\n",
+			a.Key,
+			a.Failures,
+			time.Unix(a.LastFailure, 0).Format(time.RFC3339),
+			a.Locked(now),
+			lockedUntil,
+		)
+	}
+
+	w.Flush()
+}
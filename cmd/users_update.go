@@ -58,6 +58,7 @@ options you want to change.`,
 			ViewMode:    user.ViewMode,
 			SingleClick: user.SingleClick,
 			Perm:        user.Perm,
+			Quota:       user.Quota,
 			Sorting:     user.Sorting,
 			Commands:    user.Commands,
 		}
@@ -70,6 +71,7 @@ options you want to change.`,
 		user.ViewMode = defaults.ViewMode
 		user.SingleClick = defaults.SingleClick
 		user.Perm = defaults.Perm
+		user.Quota = defaults.Quota
 		user.Commands = defaults.Commands
 		user.Sorting = defaults.Sorting
 		user.LockPassword, err = getBool(flags, "lockPassword")
@@ -85,6 +87,13 @@ options you want to change.`,
 			return err
 		}
 
+		if flags.Changed("groups") {
+			user.Groups, err = flags.GetUintSlice("groups")
+			if err != nil {
+				return err
+			}
+		}
+
 		if newUsername != "" {
 			user.Username = newUsername
 		}
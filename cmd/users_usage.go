@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/quota"
+)
+
+func init() {
+	usersCmd.AddCommand(usersUsageCmd)
+
+	usersUsageCmd.Flags().String("reset", "", "usage key to reset; scopes are recounted on their next write")
+}
+
+var usersUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "List tracked storage usage",
+	Long: `List the storage usage tracked for users and for scopes
+with a quota. Use the "reset" flag with a key from the
+list to forget its usage.`,
+	Args: cobra.NoArgs,
+	RunE: python(func(cmd *cobra.Command, _ []string, d *pythonData) error {
+		key, err := getString(cmd.Flags(), "reset")
+		if err != nil {
+			return err
+		}
+
+		if key != "" {
+			if err := d.store.Usage.Delete(key); err != nil {
+				return err
+			}
+			fmt.Printf("%s reset successfully\n", key)
+			return nil
+		}
+
+		usage, err := d.store.Usage.All()
+		if err != nil && !errors.Is(err, fbErrors.ErrNotExist) {
+			return err
+		}
+
+		printUsage(usage)
+		return nil
+	}, pythonConfig{}),
+}
+
+func printUsage(usage []*quota.Usage) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Key\tBytes\tObjects\tUpdated")
+
+	for _, u := range usage {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t\n",
+			u.Key,
+			u.Bytes,
+			u.Objects,
+			time.Unix(u.UpdatedAt, 0).Format(time.RFC3339),
+		)
+	}
+
+	w.Flush()
+}
@@ -16,6 +16,9 @@ import (
 	"github.com/spf13/pflag"
 	yaml "gopkg.in/yaml.v2"
 
+	"github.com/futureharmony/storagebrowser/v2/audit"
+	"github.com/futureharmony/storagebrowser/v2/metaindex"
+	"github.com/futureharmony/storagebrowser/v2/minio"
 	"github.com/futureharmony/storagebrowser/v2/settings"
 	"github.com/futureharmony/storagebrowser/v2/storage"
 	"github.com/futureharmony/storagebrowser/v2/storage/bolt"
@@ -131,6 +134,9 @@ func python(fn pythonFunc, cfg pythonConfig) cobraFunc {
 		if err != nil {
 			return err
 		}
+		if server, err := data.store.Settings.GetServer(); err == nil {
+			useAuditLog(data.store, server.AuditLog)
+		}
 		return fn(cmd, args, data)
 	}
 }
@@ -223,3 +229,43 @@ func convertCmdStrToCmdArray(cmd string) []string {
 	}
 	return cmdArray
 }
+
+// useAuditLog makes the storage keep the audit log in a JSON Lines file
+// instead of the database when a path is given.
+func useAuditLog(st *storage.Storage, path string) {
+	if path != "" {
+		st.Audit = audit.NewStorage(audit.NewFileBackend(path))
+	}
+}
+
+// openContentIndex opens the full-text content index database at path
+// and makes the storage use it.
+func openContentIndex(st *storage.Storage, path string) (*storm.DB, error) {
+	db, err := storm.Open(path, storm.BoltOptions(dbPerms, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	st.Content, err = bolt.NewContentIndex(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// openMetadataIndex opens the S3 object metadata index database at path
+// and makes the storage use it.
+func openMetadataIndex(st *storage.Storage, path string) (*storm.DB, error) {
+	db, err := storm.Open(path, storm.BoltOptions(dbPerms, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	st.Metadata, err = bolt.NewMetadataIndex(db, &metaindex.S3Source{Client: minio.GetS3Client()})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/futureharmony/storagebrowser/v2/webhook"
+)
+
+func init() {
+	rootCmd.AddCommand(webhooksCmd)
+}
+
+var webhooksCmd = &cobra.Command{
+	Use:   "webhooks",
+	Short: "Webhooks management utility",
+	Long:  `Webhooks management utility.`,
+	Args:  cobra.NoArgs,
+}
+
+func printDeliveries(deliveries []*webhook.Delivery) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Time\tEvent\tURL\tAttempts\tStatus\tSuccess\tError")
+
+	for _, d := range deliveries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%t\t%s\t\n",
+			d.Time.Format(time.RFC3339),
+			d.Event,
+			d.URL,
+			d.Attempts,
+			d.StatusCode,
+			d.Success,
+			d.Error,
+		)
+	}
+
+	w.Flush()
+}
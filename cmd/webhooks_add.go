@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	webhooksCmd.AddCommand(webhooksAddCmd)
+}
+
+var webhooksAddCmd = &cobra.Command{
+	Use:   "add <event> <url>",
+	Short: "Add a webhook to notify of a specific event",
+	Long: `Add a webhook to notify of a specific event. Events are
+named like the command runner ones, e.g. "after_upload".`,
+	Args: cobra.ExactArgs(2),
+	RunE: python(func(_ *cobra.Command, args []string, d *pythonData) error {
+		if _, err := url.ParseRequestURI(args[1]); err != nil {
+			return err
+		}
+
+		s, err := d.store.Settings.Get()
+		if err != nil {
+			return err
+		}
+		if s.Webhooks.Events == nil {
+			s.Webhooks.Events = map[string][]string{}
+		}
+		s.Webhooks.Events[args[0]] = append(s.Webhooks.Events[args[0]], args[1])
+		err = d.store.Settings.Save(s)
+		if err != nil {
+			return err
+		}
+		printEvents(s.Webhooks.Events)
+		return nil
+	}, pythonConfig{}),
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	webhooksCmd.AddCommand(webhooksDeliveriesCmd)
+	webhooksDeliveriesCmd.Flags().Int("limit", 50, "maximum number of deliveries (0 for no limit)") //nolint:mnd
+}
+
+var webhooksDeliveriesCmd = &cobra.Command{
+	Use:   "deliveries",
+	Short: "List the latest webhook deliveries",
+	Long:  `List the latest webhook deliveries, newest first.`,
+	Args:  cobra.NoArgs,
+	RunE: python(func(cmd *cobra.Command, _ []string, d *pythonData) error {
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return err
+		}
+
+		deliveries, err := d.store.Webhooks.List(limit)
+		if err != nil {
+			return err
+		}
+
+		printDeliveries(deliveries)
+		return nil
+	}, pythonConfig{}),
+}
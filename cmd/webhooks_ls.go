@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	webhooksCmd.AddCommand(webhooksLsCmd)
+}
+
+var webhooksLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List all webhooks for each event",
+	Long:  `List all webhooks for each event.`,
+	Args:  cobra.NoArgs,
+	RunE: python(func(_ *cobra.Command, _ []string, d *pythonData) error {
+		s, err := d.store.Settings.Get()
+		if err != nil {
+			return err
+		}
+		printEvents(s.Webhooks.Events)
+		return nil
+	}, pythonConfig{}),
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	webhooksCmd.AddCommand(webhooksRmCmd)
+}
+
+var webhooksRmCmd = &cobra.Command{
+	Use:   "rm <event> <index>",
+	Short: "Removes a webhook from an event",
+	Long: `Removes a webhook from an event. The provided index
+is the same that's printed when you run 'webhooks ls'.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return err
+		}
+
+		_, err := strconv.Atoi(args[1])
+		return err
+	},
+	RunE: python(func(_ *cobra.Command, args []string, d *pythonData) error {
+		s, err := d.store.Settings.Get()
+		if err != nil {
+			return err
+		}
+		evt := args[0]
+
+		i, err := strconv.Atoi(args[1])
+		if err != nil {
+			return err
+		}
+		if i < 0 || i >= len(s.Webhooks.Events[evt]) {
+			return fmt.Errorf("no webhook %d for event %s", i, evt)
+		}
+
+		s.Webhooks.Events[evt] = append(s.Webhooks.Events[evt][:i], s.Webhooks.Events[evt][i+1:]...)
+		err = d.store.Settings.Save(s)
+		if err != nil {
+			return err
+		}
+		printEvents(s.Webhooks.Events)
+		return nil
+	}, pythonConfig{}),
+}
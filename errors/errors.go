@@ -21,6 +21,7 @@ var (
 	ErrEmptyPassword        = errors.New("password is empty")
 	ErrEasyPassword         = errors.New("password is too easy")
 	ErrEmptyUsername        = errors.New("username is empty")
+	ErrEmptyGroupName       = errors.New("group name is empty")
 	ErrEmptyRequest         = errors.New("empty request")
 	ErrScopeIsRelative      = errors.New("scope is a relative path")
 	ErrInvalidDataType      = errors.New("invalid data type")
@@ -32,6 +33,10 @@ var (
 	ErrSourceIsParent       = errors.New("source is parent")
 	ErrRootUserDeletion     = errors.New("user with id 1 can't be deleted")
 	ErrNoAvailableScopes    = errors.New("user must have at least one available scope when using S3 storage")
+	ErrQuotaExceeded        = errors.New("storage quota exceeded")
+	ErrUploadTooLarge       = errors.New("file is larger than the upload limit")
+	ErrUploadNotAllowed     = errors.New("file type not allowed")
+	ErrUploadLimit          = errors.New("the share reached its upload limit")
 	ErrSigTerm              = errors.New("exit on signal: sigterm")
 	ErrSighup               = errors.New("exit on signal: sighup")
 	ErrSigint               = errors.New("exit on signal: sigint")
@@ -0,0 +1,90 @@
+package events
+
+import (
+	"errors"
+	"sync"
+)
+
+// Handler handles a published event. Errors returned for events in the
+// Before phase abort the operation, those of the After phase are only
+// reported.
+type Handler func(e *Event) error
+
+type subscription struct {
+	id      uint64
+	handler Handler
+	types   map[Type]bool
+}
+
+// Bus delivers events to its subscribers, synchronously and in the order
+// they subscribed.
+type Bus struct {
+	mux    sync.RWMutex
+	nextID uint64
+	subs   []subscription
+}
+
+// NewBus creates an event bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for the events of the given types, or
+// for every event when none is given. It returns a function that
+// cancels the subscription.
+func (b *Bus) Subscribe(h Handler, types ...Type) func() {
+	sub := subscription{handler: h}
+	if len(types) > 0 {
+		sub.types = map[Type]bool{}
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mux.Lock()
+	b.nextID++
+	sub.id = b.nextID
+	b.subs = append(b.subs, sub)
+	b.mux.Unlock()
+
+	return func() {
+		b.mux.Lock()
+		defer b.mux.Unlock()
+		for i := range b.subs {
+			if b.subs[i].id == sub.id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers an event to the subscribers of its type. In the Before
+// phase it stops and returns the error of the first handler that fails,
+// vetoing the operation. An operation that happened can't be vetoed: in
+// the After phase every subscriber gets the event, and the errors of
+// those that fail are joined. It does nothing on a nil bus.
+func (b *Bus) Publish(e *Event) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mux.RLock()
+	subs := b.subs
+	b.mux.RUnlock()
+
+	var errs []error
+	for _, sub := range subs {
+		if sub.types != nil && !sub.types[e.Type] {
+			continue
+		}
+		if err := sub.handler(e); err != nil {
+			if e.Phase == Before {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBusPublish(t *testing.T) {
+	bus := NewBus()
+
+	var got []string
+	record := func(name string) Handler {
+		return func(e *Event) error {
+			got = append(got, name+":"+e.Name())
+			return nil
+		}
+	}
+
+	bus.Subscribe(record("all"))
+	bus.Subscribe(record("deletes"), Delete)
+	cancel := bus.Subscribe(record("cancelled"))
+	cancel()
+
+	if err := bus.Publish(&Event{Type: Upload, Phase: Before}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(&Event{Type: Delete, Phase: After}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"all:before_upload", "all:after_delete", "deletes:after_delete"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestBusPublishError(t *testing.T) {
+	bus := NewBus()
+	errVeto := errors.New("veto")
+
+	called := false
+	bus.Subscribe(func(*Event) error { return errVeto })
+	bus.Subscribe(func(*Event) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(&Event{Type: Save, Phase: Before}); !errors.Is(err, errVeto) {
+		t.Fatalf("got error %v, want %v", err, errVeto)
+	}
+	if called {
+		t.Fatal("handlers after a failing one must not be called")
+	}
+
+	var nilBus *Bus
+	if err := nilBus.Publish(&Event{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBusPublishAfterError(t *testing.T) {
+	bus := NewBus()
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+
+	called := false
+	bus.Subscribe(func(*Event) error { return errFirst })
+	bus.Subscribe(func(*Event) error { return errSecond })
+	bus.Subscribe(func(*Event) error {
+		called = true
+		return nil
+	})
+
+	err := bus.Publish(&Event{Type: Save, Phase: After})
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Fatalf("got error %v, want both failures", err)
+	}
+	if !called {
+		t.Fatal("every handler must get an event of the after phase")
+	}
+}
@@ -0,0 +1,70 @@
+package events
+
+import (
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+// Type is the kind of operation an event is about.
+type Type string
+
+// Event types of file operations.
+const (
+	Upload Type = "upload"
+	Save   Type = "save"
+	Delete Type = "delete"
+	Rename Type = "rename"
+	Copy   Type = "copy"
+	// Compress is the writing of an archive of Path to Destination.
+	Compress Type = "compress"
+)
+
+// Phase tells if an event is published before or after its operation.
+type Phase string
+
+// Event phases.
+const (
+	Before Phase = "before"
+	After  Phase = "after"
+)
+
+// Event describes an operation on a file.
+type Event struct {
+	Type  Type
+	Phase Phase
+	Time  time.Time
+	// User made the operation. It is nil for operations that didn't
+	// come from a user.
+	User *users.User
+	IP   string
+	// Scope the paths are relative to. For S3 its name is the bucket.
+	Scope users.Scope
+	// Fs is the filesystem of the scope, when known.
+	Fs          afero.Fs
+	Path        string
+	Destination string
+	// Size and ETag describe the file once written, or before it was
+	// removed or moved. They are unknown when empty.
+	Size int64
+	ETag string
+	// Err is the error the operation failed with, only ever set in
+	// the after phase.
+	Err error
+}
+
+// Name returns the name of the event as used by the command runner
+// and webhook settings, e.g. "after_upload".
+func (e *Event) Name() string {
+	return string(e.Phase) + "_" + string(e.Type)
+}
+
+// Username returns the name of the user of the event, if any.
+func (e *Event) Username() string {
+	if e.User == nil {
+		return ""
+	}
+	return e.User.Username
+}
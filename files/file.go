@@ -67,6 +67,17 @@ type FileOptions struct {
 	Checker    rules.Checker
 	Content    bool
 	Limit      int
+	// Lister, when set, lists the directories instead of the file system
+	// when it can.
+	Lister Lister
+}
+
+// Lister lists directories faster than their file system, from an index
+// for instance.
+type Lister interface {
+	// List returns the content of a directory of a file system, or false
+	// when it can't list it.
+	List(fs afero.Fs, dir string) ([]os.FileInfo, bool, error)
 }
 
 type ImageResolution struct {
@@ -94,7 +105,7 @@ func NewFileInfo(opts *FileOptions) (*FileInfo, error) {
 
 	if opts.Expand {
 		if file.IsDir {
-			if err := file.simpleReadListingFromS3(opts.Checker, opts.ReadHeader, opts.Limit); err != nil { //nolint:govet
+			if err := file.simpleReadListingFromS3(opts.Checker, opts.Lister, opts.ReadHeader, opts.Limit); err != nil { //nolint:govet
 				return nil, err
 			}
 			return file, nil
@@ -500,7 +511,20 @@ func (i *FileInfo) readListing(checker rules.Checker, readHeader bool) error {
 }
 
 // simpleReadListingFromS3 is used for S3-compatible filesystems that implement Readdir
-func (i *FileInfo) simpleReadListingFromS3(checker rules.Checker, readHeader bool, limit int) error {
+func (i *FileInfo) simpleReadListingFromS3(checker rules.Checker, lister Lister, readHeader bool, limit int) error {
+	if lister != nil {
+		dir, ok, err := lister.List(i.Fs, i.Path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if limit > 0 && len(dir) > limit {
+				dir = dir[:limit]
+			}
+			return i.processDirectoryListing(dir, checker, readHeader)
+		}
+	}
+
 	// Try to use the optimized S3 listing interface if available
 	type S3Lister interface {
 		ListDirectory(path string, limit int) ([]os.FileInfo, error)
@@ -1,7 +1,11 @@
 package files
 
 import (
+	"mime"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -57,3 +61,24 @@ func IsNamedPipe(mode os.FileMode) bool {
 func IsSymlink(mode os.FileMode) bool {
 	return mode&os.ModeSymlink != 0
 }
+
+// MimeTypeByName returns the MIME type implied by the extension of a
+// file name, without parameters, or an empty string if it's unknown.
+func MimeTypeByName(name string) string {
+	typ, _, _ := strings.Cut(mime.TypeByExtension(strings.ToLower(filepath.Ext(name))), ";")
+	return strings.TrimSpace(typ)
+}
+
+// SniffMimeType returns the MIME type, without parameters, of a file
+// from its first bytes. Plain text is refined with the extension, so
+// that e.g. CSV or JSON files get their own type, but unrecognized
+// binary content is never trusted to be what its name says.
+func SniffMimeType(name string, header []byte) string {
+	typ, _, _ := strings.Cut(http.DetectContentType(header), ";")
+	if typ == ContentTextHeaderValue {
+		if byName := MimeTypeByName(name); strings.HasPrefix(byName, "text/") || strings.HasPrefix(byName, "application/") {
+			return byName
+		}
+	}
+	return typ
+}
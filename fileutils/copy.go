@@ -1,6 +1,7 @@
 package fileutils
 
 import (
+	"context"
 	"io/fs"
 	"os"
 	"path"
@@ -8,8 +9,17 @@ import (
 	"github.com/spf13/afero"
 )
 
+// Progress is told of the objects and the bytes copied.
+type Progress func(objects, bytes int64)
+
 // Copy copies a file or folder from one place to another.
 func Copy(afs afero.Fs, src, dst string, fileMode, dirMode fs.FileMode) error {
+	return CopyContext(context.Background(), afs, src, dst, fileMode, dirMode, nil)
+}
+
+// CopyContext is Copy stopping once ctx is done. The progress, when not
+// nil, is called after each file copied.
+func CopyContext(ctx context.Context, afs afero.Fs, src, dst string, fileMode, dirMode fs.FileMode, progress Progress) error {
 	if src = path.Clean("/" + src); src == "" {
 		return os.ErrNotExist
 	}
@@ -33,8 +43,14 @@ func Copy(afs afero.Fs, src, dst string, fileMode, dirMode fs.FileMode) error {
 	}
 
 	if info.IsDir() {
-		return CopyDir(afs, src, dst, fileMode, dirMode)
+		return copyDir(ctx, afs, src, dst, fileMode, dirMode, progress)
 	}
 
-	return CopyFile(afs, src, dst, fileMode, dirMode)
+	if err = CopyFile(afs, src, dst, fileMode, dirMode); err != nil {
+		return err
+	}
+	if progress != nil {
+		progress(1, info.Size())
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package fileutils
 
 import (
+	"context"
 	"errors"
 	"io/fs"
 
@@ -11,6 +12,10 @@ import (
 // of its sub-directories. It doesn't stop if it finds an error
 // during the copy. Returns an error if any.
 func CopyDir(afs afero.Fs, source, dest string, fileMode, dirMode fs.FileMode) error {
+	return copyDir(context.Background(), afs, source, dest, fileMode, dirMode, nil)
+}
+
+func copyDir(ctx context.Context, afs afero.Fs, source, dest string, fileMode, dirMode fs.FileMode, progress Progress) error {
 	// Get properties of source.
 	srcinfo, err := afs.Stat(source)
 	if err != nil {
@@ -32,12 +37,19 @@ func CopyDir(afs afero.Fs, source, dest string, fileMode, dirMode fs.FileMode) e
 	var errs []error
 
 	for _, obj := range obs {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
 		fsource := source + "/" + obj.Name()
 		fdest := dest + "/" + obj.Name()
 
 		if obj.IsDir() {
 			// Create sub-directories, recursively.
-			err = CopyDir(afs, fsource, fdest, fileMode, dirMode)
+			err = copyDir(ctx, afs, fsource, fdest, fileMode, dirMode, progress)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			if err != nil {
 				errs = append(errs, err)
 			}
@@ -46,6 +58,8 @@ func CopyDir(afs afero.Fs, source, dest string, fileMode, dirMode fs.FileMode) e
 			err = CopyFile(afs, fsource, fdest, fileMode, dirMode)
 			if err != nil {
 				errs = append(errs, err)
+			} else if progress != nil {
+				progress(1, obj.Size())
 			}
 		}
 	}
@@ -61,3 +75,37 @@ func CopyDir(afs afero.Fs, source, dest string, fileMode, dirMode fs.FileMode) e
 
 	return nil
 }
+
+// RemoveAllContext removes a file or a directory with all its content,
+// one file after the other until ctx is done. The progress, when not nil,
+// is called after each file removed.
+func RemoveAllContext(ctx context.Context, afs afero.Fs, name string, progress Progress) error {
+	var files []fs.FileInfo
+	var paths []string
+	err := afero.Walk(afs, name, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files, paths = append(files, info), append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, p := range paths {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = afs.Remove(p); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(1, files[i].Size())
+		}
+	}
+
+	return afs.RemoveAll(name)
+}
@@ -0,0 +1,76 @@
+import { createURL, fetchJSON, fetchURL } from "./utils";
+
+export async function list(path: string, scope?: string) {
+  const urlParams = new URLSearchParams();
+  urlParams.set("path", path);
+
+  return fetchJSON<ArchiveEntry[]>(
+    `/api/archive/list?${urlParams.toString()}`,
+    {},
+    scope
+  );
+}
+
+export function getEntryURL(
+  path: string,
+  entry: string,
+  inline = false,
+  scope?: string
+) {
+  const params: Record<string, string> = {
+    path,
+    entry,
+    ...(inline && { inline: "true" }),
+    ...(scope && { scope }),
+  };
+
+  return createURL("api/archive/raw", params);
+}
+
+export async function extract(
+  path: string,
+  destination: string,
+  overwrite = false,
+  rename = false,
+  scope?: string
+) {
+  const urlParams = new URLSearchParams();
+  urlParams.set("path", path);
+  urlParams.set("action", "extract");
+  urlParams.set("destination", destination);
+  urlParams.set("override", overwrite.toString());
+  urlParams.set("rename", rename.toString());
+
+  return fetchURL(
+    `/api/resources?${urlParams.toString()}`,
+    { method: "PATCH" },
+    true,
+    scope
+  );
+}
+
+export async function compress(
+  path: string,
+  destination: string,
+  algo = "zip",
+  files: string[] = [],
+  overwrite = false,
+  scope?: string
+) {
+  const urlParams = new URLSearchParams();
+  urlParams.set("path", path);
+  urlParams.set("action", "compress");
+  urlParams.set("destination", destination);
+  urlParams.set("algo", algo);
+  urlParams.set("override", overwrite.toString());
+  if (files.length > 0) {
+    urlParams.set("files", files.map(encodeURIComponent).join(","));
+  }
+
+  return fetchURL(
+    `/api/resources?${urlParams.toString()}`,
+    { method: "PATCH" },
+    true,
+    scope
+  );
+}
@@ -0,0 +1,36 @@
+import { baseURL } from "@/utils/constants";
+import { removePrefix } from "./utils";
+
+const ssl = window.location.protocol === "https:";
+const protocol = ssl ? "wss:" : "ws:";
+
+export interface DirChange {
+  type: "create" | "modify" | "delete" | "rename";
+  path: string;
+  destination?: string;
+  size?: number;
+  etag?: string;
+  time: string;
+}
+
+export default function watch(
+  dirs: string[],
+  onchange: (change: DirChange) => void,
+  onclose: WebSocket["onclose"]
+) {
+  const url = `${protocol}//${window.location.host}${baseURL}/api/events`;
+
+  const conn = new window.WebSocket(url);
+  conn.onopen = () =>
+    conn.send(JSON.stringify({ watch: dirs.map((dir) => removePrefix(dir)) }));
+  conn.onmessage = (event) => onchange(JSON.parse(event.data));
+  conn.onclose = onclose;
+
+  return {
+    watch: (dirs: string[]) =>
+      conn.send(
+        JSON.stringify({ watch: dirs.map((dir) => removePrefix(dir)) })
+      ),
+    close: () => conn.close(),
+  };
+}
@@ -2,7 +2,13 @@ import { useAuthStore } from "@/stores/auth";
 import { useLayoutStore } from "@/stores/layout";
 import { baseURL } from "@/utils/constants";
 import { upload as postTus, useTus } from "./tus";
-import { createURL, fetchURL, removePrefix, StatusError } from "./utils";
+import {
+  createURL,
+  fetchJSON,
+  fetchURL,
+  removePrefix,
+  StatusError,
+} from "./utils";
 import { stripS3BucketPrefix } from "@/utils/path";
 
 export async function fetch(url: string, signal?: AbortSignal, scope?: string) {
@@ -402,6 +408,21 @@ export function copy(items: any[], overwrite = false, rename = false) {
   return moveCopy(items, true, overwrite, rename);
 }
 
+export async function batch(
+  operations: BatchOperation[],
+  atomic = false,
+  scope?: string
+) {
+  return fetchJSON<BatchResult[]>(
+    "/api/resources/batch",
+    {
+      method: "POST",
+      body: JSON.stringify({ operations, atomic }),
+    },
+    scope
+  );
+}
+
 export async function checksum(url: string, algo: ChecksumAlg, scope?: string) {
   // Extract path and query parameters
   const [path, query] = url.split("?");
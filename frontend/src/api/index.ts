@@ -1,4 +1,6 @@
 import * as files from "./files";
+import * as archive from "./archive";
+import * as jobs from "./jobs";
 import * as share from "./share";
 import * as users from "./users";
 import * as settings from "./settings";
@@ -7,6 +9,20 @@ import * as config from "./config";
 import * as pub from "./pub";
 import search from "./search";
 import commands from "./commands";
+import watch from "./events";
 
-export { files, share, users, settings, bucket, config, pub, commands, search };
+export {
+  files,
+  archive,
+  jobs,
+  share,
+  users,
+  settings,
+  bucket,
+  config,
+  pub,
+  commands,
+  search,
+  watch,
+};
 export type { Scope } from "./bucket";
@@ -0,0 +1,15 @@
+import { fetchURL, fetchJSON } from "./utils";
+
+export async function list() {
+  return fetchJSON<Job[]>("/api/jobs");
+}
+
+export async function get(id: number) {
+  return fetchJSON<Job>(`/api/jobs/${id}`);
+}
+
+export async function cancel(id: number) {
+  await fetchURL(`/api/jobs/${id}`, {
+    method: "DELETE",
+  });
+}
@@ -16,6 +16,8 @@ export async function fetch(url: string, password: string = "") {
   data.url = `/share${url}`;
 
   if (data.isDir) {
+    // The content of a file drop may be hidden.
+    data.items = data.items ?? [];
     if (!data.url.endsWith("/")) data.url += "/";
     data.items = data.items.map((item: any, index: any) => {
       item.index = index;
@@ -15,11 +15,19 @@ export async function remove(hash: string) {
   });
 }
 
+export async function update(hash: string, changes: ShareUpdate) {
+  return fetchJSON<Share>(`/api/share/${hash}`, {
+    method: "PUT",
+    body: JSON.stringify(changes),
+  });
+}
+
 export async function create(
   url: string,
   password = "",
   expires = "",
-  unit = "hours"
+  unit = "hours",
+  drop: ShareDropOptions = {}
 ) {
   url = removePrefix(url);
   url = `/api/share${url}`;
@@ -27,11 +35,18 @@ export async function create(
     url += `?expires=${expires}&unit=${unit}`;
   }
   let body = "{}";
-  if (password != "" || expires !== "" || unit !== "hours") {
+  if (
+    password != "" ||
+    expires !== "" ||
+    unit !== "hours" ||
+    drop.upload ||
+    drop.maxDownloads
+  ) {
     body = JSON.stringify({
       password: password,
       expires: expires.toString(), // backend expects string not number
       unit: unit,
+      ...drop,
     });
   }
   return fetchJSON(url, {
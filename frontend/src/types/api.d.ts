@@ -23,12 +23,91 @@ type ChecksumAlg = "md5" | "sha1" | "sha256" | "sha512";
 interface Share {
   hash: string;
   path: string;
+  bucket?: string;
+  rootPrefix?: string;
   expire?: any;
   userID?: number;
+  description?: string;
   token?: string;
   username?: string;
+  upload?: boolean;
+  hideListing?: boolean;
+  maxUploadSize?: number;
+  maxUploads?: number;
+  uploads?: number;
+  maxDownloads?: number;
+  downloads?: number;
+  revoked?: boolean;
+  accesses?: ShareAccess[];
+}
+
+interface ShareUpdate {
+  password?: string;
+  expires?: string;
+  unit?: string;
+  description?: string;
+}
+
+interface ShareAccess {
+  id: number;
+  hash: string;
+  time: string;
+  ip: string;
+  path: string;
+  size: number;
+}
+
+interface ShareDropOptions {
+  maxDownloads?: number;
+  upload?: boolean;
+  hideListing?: boolean;
+  maxUploadSize?: number;
+  maxUploads?: number;
 }
 
 interface SearchParams {
   [key: string]: string;
 }
+
+interface ArchiveEntry {
+  name: string;
+  size: number;
+  modified: string;
+  isDir: boolean;
+}
+
+interface BatchOperation {
+  action: "copy" | "rename" | "delete" | "extract";
+  path: string;
+  destination?: string;
+  override?: boolean;
+  rename?: boolean;
+}
+
+interface BatchResult {
+  action: string;
+  path: string;
+  destination?: string;
+  status: number;
+  error?: string;
+}
+
+type JobStatus = "queued" | "running" | "done" | "failed" | "canceled";
+
+interface Job {
+  id: number;
+  userID: number;
+  type: string;
+  scope: string;
+  path: string;
+  destination?: string;
+  status: JobStatus;
+  error?: string;
+  objectsDone: number;
+  objectsTotal: number;
+  bytesDone: number;
+  bytesTotal: number;
+  created: string;
+  started?: string;
+  finished?: string;
+}
@@ -1,6 +1,12 @@
 interface IScope {
   name: string;
   rootPrefix: string;
+  quota?: IQuota;
+}
+
+interface IQuota {
+  bytes: number;
+  objects: number;
 }
 
 interface IUser {
@@ -12,6 +18,7 @@ interface IUser {
   currentScope: IScope; // Current selected scope
   locale: string;
   perm: Permissions;
+  quota?: IQuota;
   commands: string[];
   rules: IRule[];
   lockPassword: boolean;
@@ -33,6 +40,7 @@ interface IUserForm {
   currentScope?: IScope;
   locale?: string;
   perm?: Permissions;
+  quota?: IQuota;
   commands?: string[];
   rules?: IRule[];
   lockPassword?: boolean;
@@ -65,9 +73,20 @@ interface IRule {
   allow: boolean;
   path: string;
   regex: boolean;
+  glob?: boolean;
   regexp: IRegexp;
+  ops?: RuleOperation[];
 }
 
+type RuleOperation =
+  | "read"
+  | "create"
+  | "modify"
+  | "rename"
+  | "delete"
+  | "share"
+  | "download";
+
 interface IRegexp {
   raw: string;
 }
@@ -0,0 +1,161 @@
+package fulltext
+
+import (
+	"html"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxDocumentSize is the size of the largest file whose content is
+// indexed. Larger files are only indexed by their first bytes.
+const MaxDocumentSize = 1 << 20
+
+// maxStoredSize is how much of a document is kept for snippets.
+const maxStoredSize = 256 << 10
+
+const (
+	minTermLen = 2
+	maxTermLen = 64
+	// snippetContext is the number of characters shown around a match.
+	snippetContext = 80
+)
+
+// Document is an indexed file. ID identifies the object the file is, the
+// same way whatever the scope it's seen from.
+type Document struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+}
+
+// Hit is a document matching a search.
+type Hit struct {
+	ID    string
+	Score int
+	// Snippet is an HTML excerpt of the document with the matched terms
+	// highlighted in <mark> elements.
+	Snippet string
+}
+
+var textExtensions = map[string]bool{
+	".txt": true, ".md": true, ".markdown": true, ".csv": true, ".tsv": true,
+	".json": true, ".yaml": true, ".yml": true, ".toml": true, ".ini": true,
+	".xml": true, ".html": true, ".htm": true, ".css": true, ".log": true,
+	".go": true, ".js": true, ".ts": true, ".py": true, ".rb": true, ".java": true,
+	".c": true, ".h": true, ".cpp": true, ".rs": true, ".sh": true, ".sql": true,
+	".srt": true, ".vtt": true, ".tex": true, ".rst": true,
+}
+
+// IsText tells if a file, given its name and its first bytes, has a
+// content worth indexing.
+func IsText(name string, head []byte) bool {
+	if len(head) == 0 {
+		return false
+	}
+
+	// Drop a rune that may have been cut at the end of the head.
+	for i := 0; i < utf8.UTFMax && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	if !utf8.Valid(head) {
+		return false
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	if textExtensions[ext] || strings.HasPrefix(mime.TypeByExtension(ext), "text/") {
+		return true
+	}
+	return strings.HasPrefix(http.DetectContentType(head), "text/")
+}
+
+// Terms splits a text into its lower-cased terms.
+func Terms(text string) []string {
+	var terms []string
+	for _, field := range strings.FieldsFunc(text, isSeparator) {
+		if n := utf8.RuneCountInString(field); n < minTermLen || n > maxTermLen {
+			continue
+		}
+		terms = append(terms, strings.ToLower(field))
+	}
+	return terms
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// frequencies counts the occurrences of every term of a text.
+func frequencies(text string) map[string]int {
+	freqs := map[string]int{}
+	for _, term := range Terms(text) {
+		freqs[term]++
+	}
+	return freqs
+}
+
+// snippet returns an HTML excerpt of content around the first match of
+// any of the terms, with every match in it highlighted.
+func snippet(content string, terms []string) string {
+	runes := []rune(content)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	type match struct{ start, end int }
+	var matches []match
+	for i := 0; i < len(lower); i++ {
+		if i > 0 && !isSeparator(lower[i-1]) {
+			continue
+		}
+		for _, term := range terms {
+			t := []rune(term)
+			end := i + len(t)
+			if end > len(lower) || string(lower[i:end]) != term {
+				continue
+			}
+			if end < len(lower) && !isSeparator(lower[end]) {
+				continue
+			}
+			matches = append(matches, match{i, end})
+			i = end - 1
+			break
+		}
+	}
+
+	if len(matches) == 0 {
+		end := min(len(runes), 2*snippetContext)
+		return html.EscapeString(strings.TrimSpace(string(runes[:end])))
+	}
+
+	from := max(0, matches[0].start-snippetContext)
+	to := min(len(runes), matches[0].end+snippetContext)
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, m := range matches {
+		if m.start < from {
+			continue
+		}
+		if m.end > to {
+			break
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:m.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[m.start:m.end])))
+		b.WriteString("</mark>")
+		pos = m.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
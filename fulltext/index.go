@@ -0,0 +1,147 @@
+package fulltext
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+)
+
+// IndexBackend is the interface to implement for a full-text index
+// storage.
+type IndexBackend interface {
+	Get(id string) (*Document, error)
+	// Put saves a document and replaces its postings with the given term
+	// frequencies.
+	Put(doc *Document, freqs map[string]int) error
+	Delete(id string) error
+	// IDs returns the IDs of the documents beginning with prefix.
+	IDs(prefix string) ([]string, error)
+	// Postings returns the frequency of a term in each document that
+	// contains it, by document ID.
+	Postings(term string) (map[string]int, error)
+}
+
+// Index is an inverted index of the contents of files.
+type Index struct {
+	back IndexBackend
+	mux  sync.Mutex
+}
+
+// NewIndex creates a full-text index from a backend.
+func NewIndex(back IndexBackend) *Index {
+	return &Index{back: back}
+}
+
+// Add indexes, or reindexes, the content of a document.
+func (i *Index) Add(id string, content []byte) error {
+	if len(content) > MaxDocumentSize {
+		content = content[:MaxDocumentSize]
+	}
+	text := string(content)
+
+	stored := text
+	if len(stored) > maxStoredSize {
+		stored = stored[:maxStoredSize]
+		for !utf8.ValidString(stored) {
+			stored = stored[:len(stored)-1]
+		}
+	}
+
+	i.mux.Lock()
+	defer i.mux.Unlock()
+	return i.back.Put(&Document{ID: id, Content: stored}, frequencies(text))
+}
+
+// Remove removes a document from the index. Missing documents are
+// ignored.
+func (i *Index) Remove(id string) error {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	err := i.back.Delete(id)
+	if errors.Is(err, fbErrors.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// RemoveAll removes a document and, as it may be a directory, all the
+// documents below it.
+func (i *Index) RemoveAll(id string) error {
+	ids, err := i.back.IDs(strings.TrimSuffix(id, "/") + "/")
+	if err != nil {
+		return err
+	}
+
+	for _, id := range append(ids, id) {
+		if err := i.Remove(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search returns the documents containing every term of the query and
+// accepted by the filter, best matches first. A limit of 0 returns all
+// of them.
+func (i *Index) Search(query string, accept func(id string) bool, limit int) ([]*Hit, error) {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return []*Hit{}, nil
+	}
+
+	var scores map[string]int
+	for _, term := range terms {
+		postings, err := i.back.Postings(term)
+		if err != nil {
+			return nil, err
+		}
+
+		if scores == nil {
+			scores = map[string]int{}
+			for id, freq := range postings {
+				if accept == nil || accept(id) {
+					scores[id] = freq
+				}
+			}
+			continue
+		}
+
+		for id := range scores {
+			freq, ok := postings[id]
+			if !ok {
+				delete(scores, id)
+				continue
+			}
+			scores[id] += freq
+		}
+	}
+
+	hits := make([]*Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, &Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].ID < hits[b].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	for _, hit := range hits {
+		doc, err := i.back.Get(hit.ID)
+		if err != nil {
+			return nil, err
+		}
+		hit.Snippet = snippet(doc.Content, terms)
+	}
+
+	return hits, nil
+}
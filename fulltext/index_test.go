@@ -0,0 +1,152 @@
+package fulltext
+
+import (
+	"strings"
+	"testing"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+)
+
+type memBackend struct {
+	docs     map[string]*Document
+	postings map[string]map[string]int
+}
+
+func newMemBackend() *memBackend {
+	return &memBackend{docs: map[string]*Document{}, postings: map[string]map[string]int{}}
+}
+
+func (m *memBackend) Get(id string) (*Document, error) {
+	doc, ok := m.docs[id]
+	if !ok {
+		return nil, fbErrors.ErrNotExist
+	}
+	return doc, nil
+}
+
+func (m *memBackend) Put(doc *Document, freqs map[string]int) error {
+	_ = m.Delete(doc.ID)
+	m.docs[doc.ID] = doc
+	for term, freq := range freqs {
+		if m.postings[term] == nil {
+			m.postings[term] = map[string]int{}
+		}
+		m.postings[term][doc.ID] = freq
+	}
+	return nil
+}
+
+func (m *memBackend) Delete(id string) error {
+	delete(m.docs, id)
+	for _, postings := range m.postings {
+		delete(postings, id)
+	}
+	return nil
+}
+
+func (m *memBackend) IDs(prefix string) ([]string, error) {
+	var ids []string
+	for id := range m.docs {
+		if strings.HasPrefix(id, prefix) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *memBackend) Postings(term string) (map[string]int, error) {
+	return m.postings[term], nil
+}
+
+func hitIDs(hits []*Hit) []string {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	index := NewIndex(newMemBackend())
+	for id, content := range map[string]string{
+		"/docs/a.txt":    "The quick brown fox jumps over the lazy dog.",
+		"/docs/b.txt":    "A fox, another fox and a third fox.",
+		"/notes/c.md":    "Nothing about animals here.",
+		"/docs/sub/d.md": "The brown bear.",
+	} {
+		if err := index.Add(id, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query  string
+		accept func(id string) bool
+		want   []string
+	}{
+		{query: "fox", want: []string{"/docs/b.txt", "/docs/a.txt"}},
+		{query: "FOX brown", want: []string{"/docs/a.txt"}},
+		{query: "brown", accept: func(id string) bool { return strings.HasPrefix(id, "/docs/sub/") }, want: []string{"/docs/sub/d.md"}},
+		{query: "elephant", want: []string{}},
+		{query: "", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		hits, err := index.Search(tt.query, tt.accept, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hitIDs(hits); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestIndexRemoveAll(t *testing.T) {
+	index := NewIndex(newMemBackend())
+	for _, id := range []string{"/docs/a.txt", "/docs/sub/b.txt", "/docs2/c.txt"} {
+		if err := index.Add(id, []byte("report")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := index.RemoveAll("/docs"); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := index.Search("report", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(hits); len(got) != 1 || got[0] != "/docs2/c.txt" {
+		t.Errorf("after RemoveAll, Search = %v, want [/docs2/c.txt]", got)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	got := snippet("Dear <team>, the Budget is ready.", []string{"budget"})
+	want := "Dear &lt;team&gt;, the <mark>Budget</mark> is ready."
+	if got != want {
+		t.Errorf("snippet = %q, want %q", got, want)
+	}
+}
+
+func TestIsText(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want bool
+	}{
+		{"notes.txt", []byte("hello"), true},
+		{"main.go", []byte("package main"), true},
+		{"README", []byte("plain text without an extension"), true},
+		{"image.png", []byte("\x89PNG\r\n\x1a\n\x00\x00"), false},
+		{"empty.txt", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsText(tt.name, tt.head); got != tt.want {
+			t.Errorf("IsText(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
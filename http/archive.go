@@ -0,0 +1,260 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mholt/archives"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/jobs"
+	"github.com/futureharmony/storagebrowser/v2/rules"
+	"github.com/futureharmony/storagebrowser/v2/upload"
+)
+
+// errArchiveEntryFound stops the walk of an archive once the entry looked
+// for was served.
+var errArchiveEntryFound = errors.New("archive entry found")
+
+// archiveEntry is an entry of an archive as listed to the clients.
+type archiveEntry struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modified"`
+	IsDir   bool      `json:"isDir"`
+}
+
+// archiveEntryName returns the name an entry of an archive is listed and
+// extracted with: relative, and never leading out of the destination.
+func archiveEntryName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// walkArchive calls fn for the directories and the regular files of the
+// archive at src, in the order they are stored. Links are left out, not to
+// be followed out of the destination of an extraction.
+func walkArchive(ctx context.Context, d *data, src string, fn func(name string, f archives.FileInfo) error) error {
+	if !d.Check(src) {
+		return fbErrors.ErrPermissionDenied
+	}
+
+	file, err := d.requestFs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Seekable files are given back by Identify, as the zip format needs.
+	format, stream, err := archives.Identify(ctx, src, file)
+	if errors.Is(err, archives.NoMatch) {
+		return fmt.Errorf("%w: %s is not an archive", fbErrors.ErrInvalidRequestParams, path.Base(src))
+	}
+	if err != nil {
+		return err
+	}
+	extractor, ok := format.(archives.Extractor)
+	if !ok {
+		return fmt.Errorf("%w: %s is not an archive", fbErrors.ErrInvalidRequestParams, path.Base(src))
+	}
+
+	return extractor.Extract(ctx, stream, func(ctx context.Context, f archives.FileInfo) error {
+		name := archiveEntryName(f.NameInArchive)
+		if name == "" || (!f.IsDir() && !f.Mode().IsRegular()) {
+			return nil
+		}
+		return fn(name, f)
+	})
+}
+
+var archiveListHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	src := decodePath(r.URL.Query().Get("path"))
+	if src == "" {
+		return http.StatusBadRequest, nil
+	}
+	if !d.user.Perm.Download || !d.CheckOp(rules.OpDownload, src) {
+		return http.StatusForbidden, nil
+	}
+
+	entries := []archiveEntry{}
+	err := walkArchive(r.Context(), d, src, func(name string, f archives.FileInfo) error {
+		entry := archiveEntry{Name: name, ModTime: f.ModTime(), IsDir: f.IsDir()}
+		if !entry.IsDir {
+			entry.Size = f.Size()
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, entries)
+})
+
+var archiveRawHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	src := decodePath(r.URL.Query().Get("path"))
+	entry := archiveEntryName(r.URL.Query().Get("entry"))
+	if src == "" || entry == "" {
+		return http.StatusBadRequest, nil
+	}
+	if !d.user.Perm.Download || !d.CheckOp(rules.OpDownload, src) {
+		return http.StatusForbidden, nil
+	}
+
+	found := false
+	err := walkArchive(r.Context(), d, src, func(name string, f archives.FileInfo) error {
+		if name != entry || f.IsDir() {
+			return nil
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		if r.URL.Query().Get("inline") == "true" {
+			w.Header().Set("Content-Disposition", "inline")
+		} else {
+			w.Header().Set("Content-Disposition", "attachment; filename*=utf-8''"+url.PathEscape(path.Base(name)))
+		}
+		w.Header().Add("Content-Security-Policy", `script-src 'none';`)
+		w.Header().Set("Cache-Control", "private")
+		w.Header().Set("Content-Type", getContentTypeForExtension(path.Ext(name)))
+		w.Header().Set("Content-Length", strconv.FormatInt(f.Size(), 10))
+
+		found = true
+		if _, err := io.Copy(w, rc); err != nil {
+			return err
+		}
+		return errArchiveEntryFound
+	})
+
+	switch {
+	case found && errors.Is(err, errArchiveEntryFound):
+		return 0, nil
+	case found:
+		// The headers are sent: the error can only be logged.
+		return 0, err
+	case err != nil:
+		return errToStatus(err), err
+	default:
+		return http.StatusNotFound, nil
+	}
+})
+
+// checkEntryContent checks the type of an archive entry, sniffed from its
+// first bytes, when the upload policy restricts types.
+func checkEntryContent(policy *upload.Policy, name string, f archives.FileInfo) error {
+	if !policy.SniffsContent() {
+		return nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	header := make([]byte, upload.SniffLen)
+	n, err := io.ReadFull(rc, header)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	return policy.CheckContent(name, header[:n])
+}
+
+// extractArchive unpacks the archive at src into the directory dst. All
+// the entries are checked against the rules, the upload policy and the quota
+// before anything is written, for an archive to be extracted whole or not
+// at all. The progress is reported to t.
+func extractArchive(ctx context.Context, d *data, src, dst string, t *jobs.Tracker) error {
+	// The entries are read out of the archive as they would be listed.
+	if !d.user.Perm.Download || !d.CheckOp(rules.OpDownload, src) {
+		return fbErrors.ErrPermissionDenied
+	}
+
+	policy := d.uploadPolicy()
+	// The quota is checked against what the archive adds: the files it
+	// overwrites are given back.
+	var bytes, objects, replacedBytes, replacedObjects int64
+	err := walkArchive(ctx, d, src, func(name string, f archives.FileInfo) error {
+		target := path.Join(dst, name)
+		if !d.CheckOp(rules.OpCreate, target) {
+			return fbErrors.ErrPermissionDenied
+		}
+		if !f.IsDir() {
+			if err := policy.CheckName(name); err != nil {
+				return err
+			}
+			if err := policy.CheckSize(f.Size()); err != nil {
+				return err
+			}
+			if err := checkEntryContent(policy, name, f); err != nil {
+				return err
+			}
+			bytes += f.Size()
+			objects++
+			if info, statErr := d.requestFs.Stat(target); statErr == nil && !info.IsDir() {
+				replacedBytes += info.Size()
+				replacedObjects++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The usage is then settled with what the extraction changed.
+	dstBytes, dstObjects, err := diskUsageOf(d.requestFs, dst)
+	if err != nil {
+		return err
+	}
+	res, err := d.reserveQuota(bytes-replacedBytes, objects-replacedObjects)
+	if err != nil {
+		return err
+	}
+
+	if err = d.requestFs.MkdirAll(dst, d.settings.DirMode); err != nil {
+		res.settle(0, 0)
+		return err
+	}
+	t.SetTotal(objects, bytes)
+	err = walkArchive(ctx, d, src, func(name string, f archives.FileInfo) error {
+		target := path.Join(dst, name)
+		if f.IsDir() {
+			return d.requestFs.MkdirAll(target, d.settings.DirMode)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		info, err := writeFile(d.requestFs, target, rc, d.settings.FileMode, d.settings.DirMode)
+		if err != nil {
+			return err
+		}
+		t.Add(1, info.Size())
+		return nil
+	})
+
+	// Whatever was written counts, even when the extraction failed midway.
+	newBytes, newObjects, usageErr := diskUsageOf(d.requestFs, dst)
+	if usageErr == nil {
+		res.settle(newBytes-dstBytes, newObjects-dstObjects)
+	}
+
+	return err
+}
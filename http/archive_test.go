@@ -0,0 +1,248 @@
+package http
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/asdine/storm/v3"
+	"github.com/mholt/archives"
+	"github.com/spf13/afero"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/quota"
+	"github.com/futureharmony/storagebrowser/v2/rules"
+	"github.com/futureharmony/storagebrowser/v2/settings"
+	"github.com/futureharmony/storagebrowser/v2/storage/bolt"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func newZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newArchiveData(t *testing.T) *data {
+	t.Helper()
+
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store, err := bolt.NewStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := afero.NewMemMapFs()
+	files := map[string]string{"a.txt": "alpha", "sub/b.txt": "bravo", "../evil.txt": "evil"}
+	for name, content := range map[string][]byte{
+		"/files.zip":    newZip(t, files),
+		"/files.tar.gz": newTarGz(t, files),
+		"/notes.txt":    []byte("not an archive"),
+	} {
+		if err := afero.WriteFile(fs, name, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &data{
+		store: store,
+		user: &users.User{
+			ID:           1,
+			Perm:         users.Permissions{Create: true, Download: true},
+			CurrentScope: users.Scope{Name: "current", RootPrefix: "/"},
+			Rules:        []rules.Rule{{Path: "/locked", Allow: false}},
+		},
+		settings:  &settings.Settings{FileMode: settings.DefaultFileMode, DirMode: settings.DefaultDirMode},
+		requestFs: fs,
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	t.Parallel()
+
+	for _, src := range []string{"/files.zip", "/files.tar.gz"} {
+		t.Run(src, func(t *testing.T) {
+			t.Parallel()
+
+			d := newArchiveData(t)
+			if err := extractArchive(context.Background(), d, src, "/out", nil); err != nil {
+				t.Fatal(err)
+			}
+
+			// Entries leading out of the destination are kept inside it.
+			for name, want := range map[string]string{"/out/a.txt": "alpha", "/out/sub/b.txt": "bravo", "/out/evil.txt": "evil"} {
+				got, err := afero.ReadFile(d.requestFs, name)
+				if err != nil || string(got) != want {
+					t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+				}
+			}
+			if _, err := d.requestFs.Stat("/evil.txt"); err == nil {
+				t.Error("an entry was extracted out of the destination")
+			}
+
+			usage, err := d.store.Usage.Get(quota.UserKey(1))
+			if err != nil || usage.Bytes != 14 || usage.Objects != 3 {
+				t.Errorf("usage = %+v, %v; want 14 bytes and 3 objects", usage, err)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveDenied(t *testing.T) {
+	t.Parallel()
+
+	d := newArchiveData(t)
+	err := extractArchive(context.Background(), d, "/files.zip", "/locked/out", nil)
+	if !errors.Is(err, fbErrors.ErrPermissionDenied) {
+		t.Fatalf("extracting under a denied path: %v", err)
+	}
+	if _, err := d.requestFs.Stat("/locked"); err == nil {
+		t.Error("files were written under a denied path")
+	}
+}
+
+func TestExtractArchiveDownloadDenied(t *testing.T) {
+	t.Parallel()
+
+	d := newArchiveData(t)
+	d.user.Rules = append(d.user.Rules, rules.Rule{Path: "/files.zip", Allow: false, Ops: []rules.Operation{rules.OpDownload}})
+	err := extractArchive(context.Background(), d, "/files.zip", "/out", nil)
+	if !errors.Is(err, fbErrors.ErrPermissionDenied) {
+		t.Fatalf("extracting an archive that can't be downloaded: %v", err)
+	}
+	if _, err := d.requestFs.Stat("/out"); err == nil {
+		t.Error("the entries of an archive that can't be downloaded were extracted")
+	}
+}
+
+func TestExtractArchivePolicy(t *testing.T) {
+	t.Parallel()
+
+	d := newArchiveData(t)
+	d.settings.Upload.BlockedExtensions = []string{".txt"}
+	err := extractArchive(context.Background(), d, "/files.zip", "/out", nil)
+	if !errors.Is(err, fbErrors.ErrUploadNotAllowed) {
+		t.Fatalf("extracting blocked files: %v", err)
+	}
+	if _, err := d.requestFs.Stat("/out"); err == nil {
+		t.Error("files were written despite the upload policy")
+	}
+}
+
+func TestExtractArchiveContentPolicy(t *testing.T) {
+	t.Parallel()
+
+	d := newArchiveData(t)
+	if err := afero.WriteFile(d.requestFs, "/page.zip", newZip(t, map[string]string{"page.txt": "<html><body>hi</body></html>"}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d.settings.Upload.BlockedTypes = []string{"text/html"}
+	err := extractArchive(context.Background(), d, "/page.zip", "/out", nil)
+	if !errors.Is(err, fbErrors.ErrUploadNotAllowed) {
+		t.Fatalf("extracting a file of a blocked type: %v", err)
+	}
+	if _, err := d.requestFs.Stat("/out"); err == nil {
+		t.Error("files were written despite the upload policy")
+	}
+
+	if err = extractArchive(context.Background(), d, "/files.zip", "/out", nil); err != nil {
+		t.Errorf("extracting files of other types: %v", err)
+	}
+}
+
+func TestExtractArchiveQuota(t *testing.T) {
+	t.Parallel()
+
+	// The archive holds 14 bytes, 5 of them replacing /out/a.txt.
+	for _, tt := range []struct {
+		limit uint64
+		want  error
+	}{
+		{110, fbErrors.ErrQuotaExceeded},
+		{114, nil},
+	} {
+		d := newArchiveData(t)
+		for name, size := range map[string]int{"/out/big.bin": 100, "/out/a.txt": 5} {
+			if err := afero.WriteFile(d.requestFs, name, make([]byte, size), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := d.store.Usage.Set(quota.UserKey(d.user.ID), 105, 2); err != nil {
+			t.Fatal(err)
+		}
+		d.user.Quota = quota.Limit{Bytes: tt.limit}
+
+		err := extractArchive(context.Background(), d, "/files.zip", "/out", nil)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("extracting with %d bytes of quota: %v, want %v", tt.limit, err, tt.want)
+		}
+	}
+}
+
+func TestWalkArchive(t *testing.T) {
+	t.Parallel()
+
+	d := newArchiveData(t)
+	var names []string
+	err := walkArchive(context.Background(), d, "/files.tar.gz", func(name string, _ archives.FileInfo) error {
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 {
+		t.Errorf("entries = %v", names)
+	}
+
+	err = walkArchive(context.Background(), d, "/notes.txt", func(string, archives.FileInfo) error { return nil })
+	if !errors.Is(err, fbErrors.ErrInvalidRequestParams) {
+		t.Errorf("walking a text file: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/futureharmony/storagebrowser/v2/audit"
+)
+
+// audit records an operation made by the user of the request.
+func (d *data) audit(action audit.Action, path, dst string, size int64, err error) {
+	e := &audit.Entry{
+		IP:          d.ip,
+		Action:      action,
+		Path:        path,
+		Destination: dst,
+		Size:        size,
+		Outcome:     audit.OutcomeSuccess,
+	}
+	if d.user != nil {
+		e.UserID = d.user.ID
+		e.Username = d.user.Username
+		e.Scope = d.scope().Name
+	}
+	if err != nil {
+		e.Outcome = audit.OutcomeFailure
+		e.Error = err.Error()
+	}
+
+	d.store.Audit.Record(e)
+}
+
+var auditGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	entries, err := d.store.Audit.Query(filter)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return renderJSON(w, r, entries)
+})
+
+func parseAuditFilter(r *http.Request) (audit.Filter, error) {
+	q := r.URL.Query()
+	filter := audit.Filter{
+		Username:   q.Get("user"),
+		Action:     audit.Action(q.Get("action")),
+		Outcome:    audit.Outcome(q.Get("outcome")),
+		PathPrefix: q.Get("path"),
+	}
+
+	var err error
+	if v := q.Get("since"); v != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, err
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, err
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return filter, err
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
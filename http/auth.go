@@ -1,25 +1,34 @@
 package http
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/golang-jwt/jwt/v4/request"
 	"github.com/spf13/afero"
+	"github.com/tomasen/realip"
 
+	"github.com/futureharmony/storagebrowser/v2/audit"
 	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/lockout"
 	"github.com/futureharmony/storagebrowser/v2/minio"
 	"github.com/futureharmony/storagebrowser/v2/users"
 )
 
 const (
 	DefaultTokenExpirationTime = time.Hour * 2
+
+	maxLoginBodySize = 1 << 20
 )
 
 type userInfo struct {
@@ -94,6 +103,10 @@ func withUser(fn handleFunc) handleFunc {
 			return http.StatusInternalServerError, err
 		}
 
+		if err := d.store.Groups.Apply(d.user); err != nil {
+			return http.StatusInternalServerError, err
+		}
+
 		// Parse scope parameter from query
 		scopeParam := r.URL.Query().Get("scope")
 		var targetScope *users.Scope
@@ -151,6 +164,9 @@ func withUser(fn handleFunc) handleFunc {
 		}
 
 		d.requestScope = targetScope
+		if targetScope != nil {
+			d.user.Perm = d.user.ScopePerm(targetScope.Name)
+		}
 
 		// Create filesystem instance for this request
 		if d.server.StorageType == "s3" {
@@ -179,6 +195,13 @@ func withAdmin(fn handleFunc) handleFunc {
 	})
 }
 
+// releaseLoginAttempt gives back a login attempt that didn't fail.
+func (d *data) releaseLoginAttempt(keys ...string) {
+	if err := d.store.Lockout.Release(d.settings.Lockout, keys...); err != nil {
+		log.Printf("[AUTH] Failed to release login attempt: %v", err)
+	}
+}
+
 func loginHandler(tokenExpireTime time.Duration) handleFunc {
 	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		auther, err := d.store.Auth.Get(d.settings.AuthMethod)
@@ -186,14 +209,60 @@ func loginHandler(tokenExpireTime time.Duration) handleFunc {
 			return http.StatusInternalServerError, err
 		}
 
+		ipKey := lockout.IPKey(realip.FromRequest(r))
+		keys := []string{ipKey}
+		// The body is read once: the authentication consumes it.
+		username := loginUsername(r)
+		if username != "" {
+			keys = append(keys, lockout.UserKey(username))
+		}
+
+		// The attempt is counted as failed until it succeeds, so that no
+		// other is made meanwhile.
+		wait, err := d.store.Lockout.Attempt(d.settings.Lockout, keys...)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			return http.StatusTooManyRequests, nil
+		}
+
 		user, err := auther.Auth(r, d.store.Users, d.settings, d.server)
 		switch {
 		case errors.Is(err, os.ErrPermission):
+			d.store.Audit.Record(&audit.Entry{
+				Username: username,
+				IP:       d.ip,
+				Action:   audit.ActionLogin,
+				Outcome:  audit.OutcomeFailure,
+				Error:    err.Error(),
+			})
 			return http.StatusForbidden, nil
 		case err != nil:
+			d.releaseLoginAttempt(keys...)
 			return http.StatusInternalServerError, err
 		}
 
+		d.releaseLoginAttempt(keys...)
+		if err := d.store.Groups.Apply(user); err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		d.store.Audit.Record(&audit.Entry{
+			UserID:   user.ID,
+			Username: user.Username,
+			IP:       d.ip,
+			Scope:    user.CurrentScope.Name,
+			Action:   audit.ActionLogin,
+		})
+
+		// A successful login clears the username backoff, but the client IP
+		// keeps its record so one valid account can't be used to reset it.
+		if err := d.store.Lockout.Reset(lockout.UserKey(user.Username)); err != nil {
+			log.Printf("[AUTH] Failed to reset login failures for %s: %v", user.Username, err)
+		}
+
 		// If using S3 storage, validate that the user's bucket is available and create user-specific filesystem
 		if d.server.StorageType == "s3" {
 			availableBuckets, err := minio.ListBuckets()
@@ -217,6 +286,28 @@ func loginHandler(tokenExpireTime time.Duration) handleFunc {
 	}
 }
 
+// loginUsername peeks at the login request body to find the username
+// being authenticated, leaving the body intact for the auther.
+func loginUsername(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLoginBodySize))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var cred struct {
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(body, &cred); err != nil {
+		return ""
+	}
+	return cred.Username
+}
+
 type signupBody struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
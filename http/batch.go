@@ -0,0 +1,246 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+)
+
+// maxBatchOperations is the most operations a batch may hold.
+const maxBatchOperations = 1000
+
+// batchSlots bounds how many operations of batches run at the same time,
+// all batches of the server together.
+var batchSlots = make(chan struct{}, 4)
+
+// batchOperation is an operation of a batch: a delete, or an action of a
+// PATCH request with its query parameters.
+type batchOperation struct {
+	Action      string `json:"action"`
+	Path        string `json:"path"`
+	Destination string `json:"destination,omitempty"`
+	Override    bool   `json:"override,omitempty"`
+	Rename      bool   `json:"rename,omitempty"`
+}
+
+type batchRequest struct {
+	Operations []batchOperation `json:"operations"`
+	// Atomic checks all the operations before running any, then runs them
+	// in order up to the first failing. What was done is not undone.
+	Atomic bool `json:"atomic"`
+}
+
+// batchResult is the outcome of an operation of a batch, with the status
+// its own request would have answered with. The operations skipped for
+// the failure of another have the 424 Failed Dependency status.
+type batchResult struct {
+	Action      string `json:"action"`
+	Path        string `json:"path"`
+	Destination string `json:"destination,omitempty"`
+	Status      int    `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// newBatchResults returns the results of operations yet to run.
+func newBatchResults(ops []batchOperation) []batchResult {
+	results := make([]batchResult, len(ops))
+	for i, op := range ops {
+		results[i] = batchResult{
+			Action:      op.Action,
+			Path:        op.Path,
+			Destination: op.Destination,
+			Status:      http.StatusFailedDependency,
+		}
+	}
+	return results
+}
+
+func (res *batchResult) set(err error) {
+	res.Status = errToStatus(err)
+	if err != nil {
+		res.Error = err.Error()
+	}
+}
+
+// prepareBatchOperation checks an operation, returning the function which
+// runs it with its hooks.
+func prepareBatchOperation(d *data, fileCache FileCache, op batchOperation) (func(context.Context) error, error) {
+	src := decodePath(op.Path)
+	switch op.Action {
+	case "delete":
+		file, err := checkDelete(d, src)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error {
+			return deleteResource(ctx, d, fileCache, file)
+		}, nil
+	case "compress":
+		return nil, fmt.Errorf("%w: archives can't be written in a batch", fbErrors.ErrInvalidRequestParams)
+	default:
+		dst, err := checkPatch(d, op.Action, src, decodePath(op.Destination), op.Override, op.Rename)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error {
+			return d.RunHook(func() error {
+				return patchAction(ctx, op.Action, src, dst, d, fileCache, nil)
+			}, op.Action, src, dst, d.user)
+		}, nil
+	}
+}
+
+// runBatchOperation checks an operation and runs it once a slot is free.
+// It is checked right before running, against what the operations run
+// before it did.
+func runBatchOperation(ctx context.Context, d *data, fileCache FileCache, op batchOperation) error {
+	select {
+	case batchSlots <- struct{}{}:
+		defer func() { <-batchSlots }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	run, err := prepareBatchOperation(d, fileCache, op)
+	if err != nil {
+		return err
+	}
+	return run(ctx)
+}
+
+// batchPaths returns the paths an operation reads or writes.
+func batchPaths(op batchOperation) []string {
+	paths := []string{path.Clean("/" + decodePath(op.Path))}
+	if op.Action != "delete" {
+		paths = append(paths, path.Clean("/" + decodePath(op.Destination)))
+	}
+	return paths
+}
+
+// overlaps tells if two operations touch the same files: one of the paths
+// of an operation is one of the other's, or holds it.
+func overlaps(a, b []string) bool {
+	for _, p := range a {
+		for _, q := range b {
+			if within(p, q) || within(q, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// within tells if the cleaned path p is dir or below it.
+func within(p, dir string) bool {
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+func resourceBatchHandler(fileCache FileCache) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		var req batchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return http.StatusBadRequest, fmt.Errorf("failed to decode body: %w", err)
+		}
+		defer r.Body.Close()
+
+		if len(req.Operations) == 0 || len(req.Operations) > maxBatchOperations {
+			return http.StatusBadRequest, fmt.Errorf("a batch holds from 1 to %d operations", maxBatchOperations)
+		}
+
+		results := newBatchResults(req.Operations)
+		if req.Atomic {
+			runAtomicBatch(r.Context(), d, fileCache, req.Operations, results)
+		} else {
+			runBatch(r.Context(), d, fileCache, req.Operations, results)
+		}
+
+		return renderJSON(w, r, results)
+	})
+}
+
+// runAtomicBatch runs the operations in order once all of them were
+// checked, stopping at the first failing.
+func runAtomicBatch(ctx context.Context, d *data, fileCache FileCache, ops []batchOperation, results []batchResult) {
+	for i, op := range ops {
+		if _, err := prepareBatchOperation(d, fileCache, op); err != nil {
+			results[i].set(err)
+			return
+		}
+	}
+
+	for i, op := range ops {
+		err := runBatchOperation(ctx, d, fileCache, op)
+		results[i].set(err)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// runBatch runs the operations independently of each other, several at a
+// time and in no given order. Those touching the same files, through the
+// same paths or ones below the other's, run one after the other in order,
+// for their results not to depend on which runs first.
+func runBatch(ctx context.Context, d *data, fileCache FileCache, ops []batchOperation, results []batchResult) {
+	var wg sync.WaitGroup
+	for _, group := range overlappingBatchGroups(ops) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, i := range group {
+				results[i].set(runBatchOperation(ctx, d, fileCache, ops[i]))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// overlappingBatchGroups splits the operations into groups that touch
+// different files, each listing its operations in order.
+func overlappingBatchGroups(ops []batchOperation) [][]int {
+	paths := make([][]string, len(ops))
+	for i, op := range ops {
+		paths[i] = batchPaths(op)
+	}
+
+	// The groups are merged as overlapping operations are found, each
+	// being led by its first operation.
+	leader := make([]int, len(ops))
+	var find func(i int) int
+	find = func(i int) int {
+		if leader[i] != i {
+			leader[i] = find(leader[i])
+		}
+		return leader[i]
+	}
+	for i := range ops {
+		leader[i] = i
+		for j := 0; j < i; j++ {
+			if a, b := find(i), find(j); a != b && overlaps(paths[i], paths[j]) {
+				leader[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	byGroup := map[int][]int{}
+	var groups []int
+	for i := range ops {
+		g := find(i)
+		if _, ok := byGroup[g]; !ok {
+			groups = append(groups, g)
+		}
+		byGroup[g] = append(byGroup[g], i)
+	}
+
+	ordered := make([][]int, len(groups))
+	for i, g := range groups {
+		ordered[i] = byGroup[g]
+	}
+	return ordered
+}
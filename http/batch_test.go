@@ -0,0 +1,178 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/futureharmony/storagebrowser/v2/diskcache"
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/settings"
+	"github.com/futureharmony/storagebrowser/v2/upload"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func newBatchData(t *testing.T) *data {
+	t.Helper()
+
+	d := newArchiveData(t)
+	d.bus = events.NewBus()
+	d.server = &settings.Server{}
+	d.user.Perm = users.Permissions{Create: true, Delete: true, Rename: true}
+	for name, content := range map[string]string{"/a.txt": "alpha", "/b.txt": "bravo", "/locked/c.txt": "charlie"} {
+		if err := afero.WriteFile(d.requestFs, name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return d
+}
+
+func batchStatuses(results []batchResult) []int {
+	statuses := make([]int, len(results))
+	for i, res := range results {
+		statuses[i] = res.Status
+	}
+	return statuses
+}
+
+func TestRunBatch(t *testing.T) {
+	t.Parallel()
+
+	d := newBatchData(t)
+	ops := []batchOperation{
+		{Action: "copy", Path: "/a.txt", Destination: "/a2.txt"},
+		{Action: "delete", Path: "/b.txt"},
+		{Action: "delete", Path: "/locked/c.txt"},
+		{Action: "rename", Path: "/missing.txt", Destination: "/x.txt"},
+		{Action: "compress", Path: "/a.txt", Destination: "/a.zip"},
+	}
+	results := newBatchResults(ops)
+	runBatch(context.Background(), d, diskcache.NewNoOp(), ops, results)
+
+	want := []int{http.StatusOK, http.StatusOK, http.StatusForbidden, http.StatusNotFound, http.StatusBadRequest}
+	for i, got := range batchStatuses(results) {
+		if got != want[i] {
+			t.Errorf("operation %d: got status %d (%s), want %d", i, got, results[i].Error, want[i])
+		}
+	}
+	if _, err := d.requestFs.Stat("/a2.txt"); err != nil {
+		t.Errorf("the copy wasn't made: %v", err)
+	}
+	if _, err := d.requestFs.Stat("/b.txt"); err == nil {
+		t.Error("the file wasn't deleted")
+	}
+}
+
+func TestRunBatchUploadPolicy(t *testing.T) {
+	t.Parallel()
+
+	d := newBatchData(t)
+	d.settings.Upload = upload.Policy{MaxSize: 4, BlockedExtensions: []string{".exe"}}
+	ops := []batchOperation{
+		{Action: "rename", Path: "/a.txt", Destination: "/a.exe"},
+		{Action: "copy", Path: "/b.txt", Destination: "/b2.txt"},
+	}
+	results := newBatchResults(ops)
+	runBatch(context.Background(), d, diskcache.NewNoOp(), ops, results)
+
+	want := []int{http.StatusUnsupportedMediaType, http.StatusRequestEntityTooLarge}
+	for i, got := range batchStatuses(results) {
+		if got != want[i] {
+			t.Errorf("operation %d: got status %d (%s), want %d", i, got, results[i].Error, want[i])
+		}
+	}
+}
+
+func TestRunBatchSameTarget(t *testing.T) {
+	t.Parallel()
+
+	d := newBatchData(t)
+	ops := []batchOperation{
+		{Action: "copy", Path: "/a.txt", Destination: "/c.txt"},
+		{Action: "copy", Path: "/b.txt", Destination: "/c.txt"},
+		{Action: "copy", Path: "/a.txt", Destination: "/b.txt", Rename: true},
+		{Action: "copy", Path: "/a.txt", Destination: "/b.txt", Rename: true},
+	}
+	results := newBatchResults(ops)
+	runBatch(context.Background(), d, diskcache.NewNoOp(), ops, results)
+
+	want := []int{http.StatusOK, http.StatusConflict, http.StatusOK, http.StatusOK}
+	for i, got := range batchStatuses(results) {
+		if got != want[i] {
+			t.Errorf("operation %d: got status %d (%s), want %d", i, got, results[i].Error, want[i])
+		}
+	}
+	if got, _ := afero.ReadFile(d.requestFs, "/c.txt"); string(got) != "alpha" {
+		t.Errorf("the first copy was overwritten with %q", got)
+	}
+	for _, name := range []string{"/b(1).txt", "/b(2).txt"} {
+		if _, err := d.requestFs.Stat(name); err != nil {
+			t.Errorf("renamed copy %s: %v", name, err)
+		}
+	}
+}
+
+func TestOverlappingBatchGroups(t *testing.T) {
+	t.Parallel()
+
+	ops := []batchOperation{
+		{Action: "delete", Path: "/a"},
+		{Action: "rename", Path: "/a/x", Destination: "/b"},
+		{Action: "copy", Path: "/c", Destination: "/d"},
+		{Action: "delete", Path: "/c/y"},
+		{Action: "copy", Path: "/b/z", Destination: "/e"},
+		{Action: "delete", Path: "/ab"},
+	}
+	got := fmt.Sprint(overlappingBatchGroups(ops))
+	if want := "[[0 1 4] [2 3] [5]]"; got != want {
+		t.Errorf("groups = %s, want %s", got, want)
+	}
+}
+
+func TestRunAtomicBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("checked first", func(t *testing.T) {
+		t.Parallel()
+
+		d := newBatchData(t)
+		ops := []batchOperation{
+			{Action: "copy", Path: "/a.txt", Destination: "/a2.txt"},
+			{Action: "delete", Path: "/locked/c.txt"},
+		}
+		results := newBatchResults(ops)
+		runAtomicBatch(context.Background(), d, diskcache.NewNoOp(), ops, results)
+
+		got := batchStatuses(results)
+		if got[0] != http.StatusFailedDependency || got[1] != http.StatusForbidden {
+			t.Errorf("got statuses %v, want the copy skipped for the forbidden delete", got)
+		}
+		if _, err := d.requestFs.Stat("/a2.txt"); err == nil {
+			t.Error("an operation ran though another was forbidden")
+		}
+	})
+
+	t.Run("stopped at the first failure", func(t *testing.T) {
+		t.Parallel()
+
+		d := newBatchData(t)
+		ops := []batchOperation{
+			{Action: "rename", Path: "/a.txt", Destination: "/moved.txt"},
+			{Action: "copy", Path: "/a.txt", Destination: "/a2.txt"},
+			{Action: "delete", Path: "/b.txt"},
+		}
+		results := newBatchResults(ops)
+		runAtomicBatch(context.Background(), d, diskcache.NewNoOp(), ops, results)
+
+		got := batchStatuses(results)
+		if got[0] != http.StatusOK || got[1] != http.StatusNotFound || got[2] != http.StatusFailedDependency {
+			t.Errorf("got statuses %v, want the delete skipped after the failed copy", got)
+		}
+		if _, err := d.requestFs.Stat("/b.txt"); err != nil {
+			t.Errorf("an operation ran after one failed: %v", err)
+		}
+	})
+}
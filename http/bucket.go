@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
 	"github.com/futureharmony/storagebrowser/v2/minio"
 	"github.com/futureharmony/storagebrowser/v2/users"
 	"github.com/gorilla/mux"
@@ -46,6 +47,7 @@ type createBucketRequest struct {
 	ObjectLock     bool   `json:"objectLock"`
 	ObjectLockDays int    `json:"objectLockDays"`
 	RetentionMode  string `json:"retentionMode"`
+	Groups         []uint `json:"groups"`
 }
 
 func createBucketHandler() handleFunc {
@@ -86,6 +88,26 @@ func createBucketHandler() handleFunc {
 
 		log.Printf("[BUCKET] createBucketHandler: bucket %s created successfully", req.Name)
 
+		// When groups are given, grant the new bucket through them instead
+		// of touching every single user.
+		if len(req.Groups) > 0 {
+			for _, id := range req.Groups {
+				group, err := d.store.Groups.Get(id)
+				if err != nil {
+					log.Printf("[BUCKET] createBucketHandler: failed to get group %d: %v", id, err)
+					continue
+				}
+				group.AvailableScopes = addScope(group.AvailableScopes, req.Name)
+				if err := d.store.Groups.Save(group); err != nil {
+					log.Printf("[BUCKET] createBucketHandler: failed to update group %s: %v", group.Name, err)
+				} else {
+					log.Printf("[BUCKET] createBucketHandler: updated group %s, added bucket %s", group.Name, req.Name)
+				}
+			}
+
+			return renderJSON(w, r, map[string]string{"name": req.Name})
+		}
+
 		// Update all users to add the new bucket to their AvailableScopes
 		log.Printf("[BUCKET] createBucketHandler: updating users to add bucket %s to scopes", req.Name)
 		allUsers, err := d.store.Users.Gets(d.server.Root)
@@ -125,6 +147,27 @@ func createBucketHandler() handleFunc {
 	})
 }
 
+// addScope appends a scope for the bucket unless one already exists.
+func addScope(scopes []users.Scope, bucket string) []users.Scope {
+	for _, scope := range scopes {
+		if scope.Name == bucket {
+			return scopes
+		}
+	}
+	return append(scopes, users.Scope{Name: bucket})
+}
+
+// removeScope returns the scopes without the ones for the bucket.
+func removeScope(scopes []users.Scope, bucket string) []users.Scope {
+	newScopes := make([]users.Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		if scope.Name != bucket {
+			newScopes = append(newScopes, scope)
+		}
+	}
+	return newScopes
+}
+
 func deleteBucketHandler() handleFunc {
 	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		log.Printf("[BUCKET] deleteBucketHandler: request received")
@@ -188,6 +231,21 @@ func deleteBucketHandler() handleFunc {
 			}
 		}
 
+		allGroups, err := d.store.Groups.Gets()
+		if err != nil && !errors.Is(err, fbErrors.ErrNotExist) {
+			log.Printf("[BUCKET] deleteBucketHandler: failed to get groups: %v", err)
+		}
+		for _, group := range allGroups {
+			scopes := removeScope(group.AvailableScopes, name)
+			if len(scopes) == len(group.AvailableScopes) {
+				continue
+			}
+			group.AvailableScopes = scopes
+			if err := d.store.Groups.Save(group); err != nil {
+				log.Printf("[BUCKET] deleteBucketHandler: failed to update group %s: %v", group.Name, err)
+			}
+		}
+
 		log.Printf("[BUCKET] deleteBucketHandler: bucket %s deleted successfully", name)
 		return http.StatusNoContent, nil
 	})
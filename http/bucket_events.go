@@ -0,0 +1,108 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tomasen/realip"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+// bucketNotification is an S3 event notification, as sent by MinIO
+// webhook targets and AWS S3.
+type bucketNotification struct {
+	Records []struct {
+		EventName string    `json:"eventName"`
+		EventTime time.Time `json:"eventTime"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key  string `json:"key"`
+				Size int64  `json:"size"`
+				ETag string `json:"eTag"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+// bucketEventType maps the name of an S3 event to the type of event of
+// the bus. Events that aren't about objects changing are ignored.
+func bucketEventType(name string) (events.Type, bool) {
+	name = strings.TrimPrefix(name, "s3:")
+	switch {
+	case strings.HasPrefix(name, "ObjectCreated:"):
+		return events.Upload, true
+	case strings.HasPrefix(name, "ObjectRemoved:"):
+		return events.Delete, true
+	default:
+		return "", false
+	}
+}
+
+// bucketEventsHandler receives the notifications of changes made to the
+// buckets by other tools, and publishes them on the event bus. It is
+// disabled unless a token is configured, which the notifications must
+// carry in the Authorization header.
+func bucketEventsHandler(token string, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		notification := &bucketNotification{}
+		if err := json.NewDecoder(r.Body).Decode(notification); err != nil {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		ip := realip.FromRequest(r)
+		for _, record := range notification.Records {
+			typ, ok := bucketEventType(record.EventName)
+			if !ok {
+				continue
+			}
+
+			// Keys are URL encoded in notifications.
+			key, err := url.QueryUnescape(record.S3.Object.Key)
+			if err != nil {
+				key = record.S3.Object.Key
+			}
+
+			e := &events.Event{
+				Type:  typ,
+				Phase: events.After,
+				Time:  record.EventTime,
+				IP:    ip,
+				Scope: users.Scope{Name: record.S3.Bucket.Name},
+				Path:  "/" + key,
+				Size:  record.S3.Object.Size,
+				ETag:  record.S3.Object.ETag,
+			}
+			if e.Time.IsZero() {
+				e.Time = time.Now()
+			}
+
+			if err := bus.Publish(e); err != nil {
+				log.Printf("WARNING: couldn't handle bucket event %s on %s: %v", record.EventName, e.Path, err)
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
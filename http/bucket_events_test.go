@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+type memFileCache struct {
+	mux  sync.Mutex
+	data map[string][]byte
+}
+
+func (c *memFileCache) Store(_ context.Context, key string, value []byte) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.data[key] = value
+	return nil
+}
+
+func (c *memFileCache) Load(_ context.Context, key string) ([]byte, bool, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	v, ok := c.data[key]
+	return v, ok, nil
+}
+
+func (c *memFileCache) Delete(_ context.Context, key string) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	delete(c.data, key)
+	return nil
+}
+
+const minioNotification = `{
+  "EventName": "s3:ObjectRemoved:Delete",
+  "Key": "photos/docs/a b.jpg",
+  "Records": [{
+    "eventName": "s3:ObjectRemoved:Delete",
+    "eventTime": "2024-05-01T10:00:00.000Z",
+    "s3": {
+      "bucket": {"name": "photos"},
+      "object": {"key": "docs/a+b.jpg", "size": 0, "eTag": ""}
+    }
+  }, {
+    "eventName": "s3:ObjectAccessed:Get",
+    "s3": {"bucket": {"name": "photos"}, "object": {"key": "docs/c.jpg"}}
+  }]
+}`
+
+func TestBucketEventsHandler(t *testing.T) {
+	ctx := context.Background()
+	cache := &memFileCache{data: map[string][]byte{}}
+
+	// A preview cached while browsing a scope rooted in the "docs" prefix.
+	id := scopeObjectID(users.Scope{Name: "photos", RootPrefix: "/docs"}, "/a b.jpg")
+	if err := cache.Store(ctx, "thumb", []byte("jpeg")); err != nil {
+		t.Fatal(err)
+	}
+	if err := indexPreview(ctx, cache, id, "thumb"); err != nil {
+		t.Fatal(err)
+	}
+
+	bus := events.NewBus()
+	subscribePreviewInvalidation(bus, cache)
+
+	var published []*events.Event
+	bus.Subscribe(func(e *events.Event) error {
+		published = append(published, e)
+		return nil
+	})
+
+	handler := bucketEventsHandler("s3cret", bus)
+
+	tests := map[string]struct {
+		token  string
+		status int
+	}{
+		"missing token": {"", http.StatusUnauthorized},
+		"wrong token":   {"Bearer nope", http.StatusUnauthorized},
+		"bearer token":  {"Bearer s3cret", http.StatusNoContent},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/bucket-events", strings.NewReader(minioNotification))
+			req.Header.Set("Authorization", tt.token)
+			rec := httptest.NewRecorder()
+
+			handler(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+
+	if len(published) != 1 {
+		t.Fatalf("got %d events, want 1", len(published))
+	}
+	if e := published[0]; e.Type != events.Delete || e.Scope.Name != "photos" || e.Path != "/docs/a b.jpg" || e.User != nil {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if _, ok, _ := cache.Load(ctx, "thumb"); ok {
+		t.Fatal("preview wasn't invalidated")
+	}
+	if _, ok, _ := cache.Load(ctx, previewIndexKey(id)); ok {
+		t.Fatal("preview index wasn't removed")
+	}
+}
+
+func TestBucketEventsHandlerDisabled(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/bucket-events", strings.NewReader(minioNotification))
+	rec := httptest.NewRecorder()
+
+	bucketEventsHandler("", events.NewBus())(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/mholt/archives"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/files"
+	"github.com/futureharmony/storagebrowser/v2/fileutils"
+	"github.com/futureharmony/storagebrowser/v2/jobs"
+	"github.com/futureharmony/storagebrowser/v2/rules"
+)
+
+// submitCompress checks a compress action and runs it in a job: the files
+// selected by the "files" query parameter, src itself when there is none,
+// are put in an archive written to dst in the format of the "algo" query
+// parameter.
+func submitCompress(w http.ResponseWriter, r *http.Request, d *data, src, dst string) (int, error) {
+	// The files are read into the archive as they would be downloaded.
+	if !d.user.Perm.Create || !d.user.Perm.Download || !d.CheckOp(rules.OpCreate, dst) {
+		return http.StatusForbidden, nil
+	}
+
+	_, archiver, err := parseQueryAlgorithm(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	filenames, err := parseQueryFiles(r, &files.FileInfo{Path: src}, d.user)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	for _, fname := range filenames {
+		if !d.Check(fname) || !d.CheckOp(rules.OpDownload, fname) {
+			return http.StatusForbidden, nil
+		}
+		if _, err = d.requestFs.Stat(fname); err != nil {
+			return errToStatus(err), err
+		}
+	}
+
+	job := &jobs.Job{Type: string(events.Compress), Path: src, Destination: dst}
+	return submitJob(w, d, job, func(ctx context.Context, t *jobs.Tracker) error {
+		return d.RunHook(func() error {
+			return compressFiles(ctx, d, filenames, dst, archiver, t)
+		}, string(events.Compress), src, dst, d.user)
+	})
+}
+
+// compressFiles writes an archive of the files to dst, which is removed
+// when it can't be written whole. The files put in it are reported to t.
+func compressFiles(ctx context.Context, d *data, filenames []string, dst string, archiver archives.Archiver, t *jobs.Tracker) error {
+	commonDir := fileutils.CommonPrefix(filepath.Separator, filenames...)
+	if isS3Fs(d.requestFs) {
+		commonDir = fileutils.CommonPrefix('/', filenames...)
+	}
+
+	var allFiles []archives.FileInfo
+	for _, fname := range filenames {
+		archiveFiles, err := getFiles(d, fname, commonDir)
+		if err != nil {
+			return err
+		}
+		allFiles = append(allFiles, archiveFiles...)
+	}
+
+	var objects, bytes int64
+	for i, f := range allFiles {
+		if f.IsDir() {
+			continue
+		}
+		objects++
+		bytes += f.Size()
+		allFiles[i].Open = trackedOpen(f, t)
+	}
+	t.SetTotal(objects, bytes)
+
+	// The size of the archive is only known once written: the room left
+	// by the quota is reserved, and the archive stopped when it goes over.
+	oldBytes, oldObjects, err := diskUsageOf(d.requestFs, dst)
+	if err != nil {
+		return err
+	}
+	limit, used, limited, err := d.byteQuota()
+	if err != nil {
+		return err
+	}
+	reserved := -oldBytes
+	if limited {
+		reserved = int64(limit - min(used, limit)) //nolint:gosec
+	}
+	res, err := d.reserveQuota(reserved, 1-oldObjects)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(archiver.Archive(ctx, pw, allFiles))
+	}()
+
+	var body io.Reader = pr
+	if limited {
+		body = &quotaReader{r: pr, left: reserved + oldBytes}
+	}
+	info, err := writeFile(d.requestFs, dst, body, d.settings.FileMode, d.settings.DirMode)
+	// Unblocks the archiver when the write failed.
+	pr.CloseWithError(err)
+	if err != nil {
+		if removeErr := d.requestFs.Remove(dst); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("removing the incomplete archive %s: %v", dst, removeErr)
+		}
+		res.settle(-oldBytes, -oldObjects)
+		return err
+	}
+
+	res.settle(info.Size()-oldBytes, 1-oldObjects)
+	return nil
+}
+
+// trackedOpen opens a file put in an archive, counting it once read.
+func trackedOpen(f archives.FileInfo, t *jobs.Tracker) func() (fs.File, error) {
+	return func() (fs.File, error) {
+		file, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		return &trackedFile{File: file, t: t, size: f.Size()}, nil
+	}
+}
+
+// trackedFile reports a file to a tracker when closed.
+type trackedFile struct {
+	fs.File
+	t    *jobs.Tracker
+	size int64
+}
+
+func (f *trackedFile) Close() error {
+	f.t.Add(1, f.size)
+	return f.File.Close()
+}
+
+// quotaReader reads up to a number of bytes, failing with
+// fbErrors.ErrQuotaExceeded past them.
+type quotaReader struct {
+	r    io.Reader
+	left int64
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	if q.left < 0 {
+		return 0, fbErrors.ErrQuotaExceeded
+	}
+	if int64(len(p)) > q.left+1 {
+		p = p[:q.left+1]
+	}
+
+	n, err := q.r.Read(p)
+	q.left -= int64(n)
+	if q.left < 0 {
+		return n, fbErrors.ErrQuotaExceeded
+	}
+	return n, err
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/mholt/archives"
+	"github.com/spf13/afero"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/quota"
+	"github.com/futureharmony/storagebrowser/v2/rules"
+)
+
+func TestCompressFiles(t *testing.T) {
+	t.Parallel()
+
+	d := newArchiveData(t)
+	for name, content := range map[string]string{"/dir/a.txt": "alpha", "/dir/sub/b.txt": "bravo", "/dir/c.txt": "charlie"} {
+		if err := afero.WriteFile(d.requestFs, name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := compressFiles(context.Background(), d, []string{"/dir/a.txt", "/dir/sub"}, "/out/dir.zip", archives.Zip{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := afero.ReadFile(d.requestFs, "/out/dir.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, f.Name+"="+string(data))
+	}
+	sort.Strings(got)
+	if want := "[a.txt=alpha sub/= sub/b.txt=bravo]"; fmt.Sprint(got) != want {
+		t.Errorf("archive files = %v, want %v", got, want)
+	}
+
+	usage, err := d.store.Usage.Get(quota.UserKey(1))
+	if err != nil || usage.Bytes != int64(len(content)) || usage.Objects != 1 {
+		t.Errorf("usage = %+v, %v; want %d bytes and 1 object", usage, err, len(content))
+	}
+}
+
+func TestSubmitCompressDownloadDenied(t *testing.T) {
+	t.Parallel()
+
+	for name, deny := range map[string]func(d *data){
+		"permission": func(d *data) { d.user.Perm.Download = false },
+		"rule": func(d *data) {
+			d.user.Rules = append(d.user.Rules, rules.Rule{Path: "/notes.txt", Allow: false, Ops: []rules.Operation{rules.OpDownload}})
+		},
+	} {
+		d := newArchiveData(t)
+		deny(d)
+		r := httptest.NewRequest(http.MethodPatch, "/api/resources?action=compress&algo=zip", http.NoBody)
+		status, _ := submitCompress(httptest.NewRecorder(), r, d, "/notes.txt", "/notes.zip")
+		if status != http.StatusForbidden {
+			t.Errorf("compressing without the download %s answered %d", name, status)
+		}
+	}
+}
+
+func TestCompressFilesQuota(t *testing.T) {
+	t.Parallel()
+
+	d := newArchiveData(t)
+	d.user.Quota = quota.Limit{Bytes: 100}
+	if err := afero.WriteFile(d.requestFs, "/dir/big.txt", bytes.Repeat([]byte("x"), 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := compressFiles(context.Background(), d, []string{"/dir"}, "/dir.tar", archives.Tar{}, nil)
+	if !errors.Is(err, fbErrors.ErrQuotaExceeded) {
+		t.Fatalf("compressing over the quota: %v", err)
+	}
+	if _, err := d.requestFs.Stat("/dir.tar"); err == nil {
+		t.Error("the incomplete archive was kept")
+	}
+}
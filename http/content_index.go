@@ -0,0 +1,149 @@
+package http
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/spf13/afero"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/fulltext"
+	"github.com/futureharmony/storagebrowser/v2/minio"
+)
+
+// contentQueueSize is how many events may wait to be indexed. Files
+// changed past that are left out of the index until it's rebuilt.
+const contentQueueSize = 256
+
+// contentHeadSize is how much of a file tells if it's a text file.
+const contentHeadSize = 512
+
+// subscribeContentIndex keeps the full-text index up to date with the
+// files changed by the events of the bus. Files are read and indexed
+// in the background, in the order of the events.
+func subscribeContentIndex(bus *events.Bus, index *fulltext.Index) {
+	if index == nil {
+		return
+	}
+
+	queue := make(chan *events.Event, contentQueueSize)
+	go func() {
+		for e := range queue {
+			if err := indexEvent(index, e); err != nil {
+				log.Printf("content index: %s %s: %v", e.Type, e.Path, err)
+			}
+		}
+	}()
+
+	bus.Subscribe(func(e *events.Event) error {
+		if e.Phase != events.After || e.Err != nil {
+			return nil
+		}
+
+		select {
+		case queue <- e:
+		default:
+			log.Printf("content index: queue full, %s of %s not indexed", e.Type, e.Path)
+		}
+		return nil
+	}, events.Upload, events.Save, events.Delete, events.Rename, events.Copy, events.Compress)
+}
+
+func indexEvent(index *fulltext.Index, e *events.Event) error {
+	fs := e.Fs
+	if fs == nil {
+		// Changes notified by the bucket have no file system.
+		fs = minio.CreateUserFs(e.Scope.Name, e.Scope.RootPrefix)
+	}
+
+	switch e.Type {
+	case events.Delete:
+		return index.RemoveAll(fsObjectID(fs, e.Path))
+	case events.Rename:
+		if err := index.RemoveAll(fsObjectID(fs, e.Path)); err != nil {
+			return err
+		}
+		return indexTree(index, fs, e.Destination)
+	case events.Copy, events.Compress:
+		return indexTree(index, fs, e.Destination)
+	default:
+		return indexTree(index, fs, e.Path)
+	}
+}
+
+// indexTree indexes the file at root or, for a directory, all the files
+// below it.
+func indexTree(index *fulltext.Index, fs afero.Fs, root string) error {
+	return afero.Walk(fs, root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return index.RemoveAll(fsObjectID(fs, p))
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		if err := indexFile(index, fs, p); err != nil {
+			log.Printf("content index: %s: %v", p, err)
+		}
+		return nil
+	})
+}
+
+func indexFile(index *fulltext.Index, fs afero.Fs, p string) error {
+	id := fsObjectID(fs, p)
+
+	f, err := fs.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, fulltext.MaxDocumentSize))
+	if err != nil {
+		return err
+	}
+
+	head := content
+	if len(head) > contentHeadSize {
+		head = head[:contentHeadSize]
+	}
+	if !fulltext.IsText(path.Base(p), head) {
+		return index.Remove(id)
+	}
+
+	return index.Add(id, content)
+}
+
+// contentIndexPostHandler rebuilds the part of the full-text index of the
+// files of the current scope below the requested path.
+var contentIndexPostHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	index := d.store.Content
+	if index == nil {
+		return http.StatusNotImplemented, nil
+	}
+
+	root := r.URL.Path
+	if root == "" {
+		root = "/"
+	}
+
+	fs := d.requestFs
+	go func() {
+		if err := index.RemoveAll(fsObjectID(fs, root)); err != nil {
+			log.Printf("content index: rebuilding %s: %v", root, err)
+			return
+		}
+		if err := indexTree(index, fs, root); err != nil {
+			log.Printf("content index: rebuilding %s: %v", root, err)
+		}
+	}()
+
+	w.WriteHeader(http.StatusAccepted)
+	return 0, nil
+})
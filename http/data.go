@@ -1,17 +1,20 @@
 package http
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
-	"strings"
 
 	"github.com/spf13/afero"
 	"github.com/tomasen/realip"
 
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/events"
 	"github.com/futureharmony/storagebrowser/v2/rules"
-	"github.com/futureharmony/storagebrowser/v2/runner"
 	"github.com/futureharmony/storagebrowser/v2/settings"
+	"github.com/futureharmony/storagebrowser/v2/share"
 	"github.com/futureharmony/storagebrowser/v2/storage"
 	"github.com/futureharmony/storagebrowser/v2/users"
 )
@@ -19,40 +22,50 @@ import (
 type handleFunc func(w http.ResponseWriter, r *http.Request, d *data) (int, error)
 
 type data struct {
-	*runner.Runner
+	bus          *events.Bus
 	settings     *settings.Settings
 	server       *settings.Server
 	store        *storage.Storage
 	user         *users.User
+	ip           string
 	raw          interface{}
 	requestFs    afero.Fs     // Filesystem instance for this specific request (created based on scope parameter)
 	requestScope *users.Scope // Scope used for this request (from scope parameter or user.CurrentScope)
+	share        *share.Link  // Share link the request was made through, if any
 }
 
 // Check implements rules.Checker.
 func (d *data) Check(path string) bool {
+	return d.CheckOp(rules.OpRead, path)
+}
+
+// CheckOp implements rules.Checker.
+func (d *data) CheckOp(op rules.Operation, path string) bool {
 	if d.user.HideDotfiles && rules.MatchHidden(path) {
 		return false
 	}
 
-	// TODO
-	// Check bucket and scope permissions for S3 storage
-	// if d.server.StorageType == "s3" {
+	// Paths are relative to the scope root, so they must never resolve
+	// outside of its prefix, whatever the storage type.
+	if !d.scope().Contains(path) {
+		return false
+	}
 
-	// 	if !d.checkS3Permissions(path) {
-	// 		return false
-	// 	}
-	// }
+	return d.rulesAllow(op, path)
+}
 
+// rulesAllow reports whether the global and the user rules allow the
+// operation on the path, the last matching rule winning.
+func (d *data) rulesAllow(op rules.Operation, path string) bool {
 	allow := true
 	for _, rule := range d.settings.Rules {
-		if rule.Matches(path) {
+		if rule.AppliesTo(op) && rule.Matches(path) {
 			allow = rule.Allow
 		}
 	}
 
 	for _, rule := range d.user.Rules {
-		if rule.Matches(path) {
+		if rule.AppliesTo(op) && rule.Matches(path) {
 			allow = rule.Allow
 		}
 	}
@@ -60,44 +73,50 @@ func (d *data) Check(path string) bool {
 	return allow
 }
 
-// checkS3Permissions checks if the user has permission to access the given path based on bucket and scope
-func (d *data) checkS3Permissions(path string) bool {
-	// Use the request scope for path checking
-	currentScope := d.requestScope
-	if currentScope == nil {
-		currentScope = &d.user.CurrentScope
-	}
-	if currentScope.RootPrefix == "" && len(d.user.AvailableScopes) > 0 {
-		currentScope = &d.user.AvailableScopes[0] // Use first available scope as fallback
+// checkTree checks an operation acting on a whole directory, like a delete
+// or a rename: it must be allowed on the path and on everything below it,
+// for rules protecting the content not to be bypassed through its parent.
+func (d *data) checkTree(op rules.Operation, path string) error {
+	if !d.CheckOp(op, path) {
+		return fbErrors.ErrPermissionDenied
 	}
 
-	path = currentScope.RootPrefix + path
-
-	// If user has no available scopes, allow access
-	if len(d.user.AvailableScopes) == 0 {
-		return true
+	// Without a deny rule, nothing below can be protected.
+	denies := false
+	for _, list := range [][]rules.Rule{d.settings.Rules, d.user.Rules} {
+		for _, rule := range list {
+			denies = denies || (rule.AppliesTo(op) && !rule.Allow)
+		}
+	}
+	if !denies {
+		return nil
 	}
 
-	// Check scope permission - if user has a scope set, path must be within that scope
-	if currentScope.RootPrefix != "" {
-		normalizedPath := strings.TrimPrefix(path, "/")
-		normalizedScope := strings.TrimPrefix(currentScope.RootPrefix, "/")
-
-		// Ensure scope ends with a slash for proper prefix matching, except for root
-		if normalizedScope != "" && !strings.HasSuffix(normalizedScope, "/") {
-			normalizedScope += "/"
+	err := afero.Walk(d.requestFs, path, func(p string, _ os.FileInfo, err error) error {
+		if err != nil {
+			return err
 		}
-
-		// If scope is set, path must start with the scope prefix
-		if normalizedScope != "/" && !strings.HasPrefix(normalizedPath, normalizedScope) {
-			return false
+		if !d.rulesAllow(op, p) {
+			return fbErrors.ErrPermissionDenied
 		}
+		return nil
+	})
+	if os.IsNotExist(err) || errors.Is(err, afero.ErrFileNotFound) {
+		return nil
 	}
+	return err
+}
 
-	return true
+// scope returns the scope the request operates in, falling back to the
+// user's current scope when none was resolved for the request.
+func (d *data) scope() users.Scope {
+	if d.requestScope != nil {
+		return *d.requestScope
+	}
+	return d.user.CurrentScope
 }
 
-func handle(fn handleFunc, prefix string, store *storage.Storage, server *settings.Server) http.Handler {
+func handle(fn handleFunc, prefix string, store *storage.Storage, server *settings.Server, bus *events.Bus) http.Handler {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		for k, v := range globalHeaders {
 			w.Header().Set(k, v)
@@ -109,15 +128,16 @@ func handle(fn handleFunc, prefix string, store *storage.Storage, server *settin
 			return
 		}
 
+		clientIP := realip.FromRequest(r)
 		status, err := fn(w, r, &data{
-			Runner:   &runner.Runner{Enabled: server.EnableExec, Settings: settings},
+			bus:      bus,
 			store:    store,
 			settings: settings,
 			server:   server,
+			ip:       clientIP,
 		})
 
 		if status >= 400 || err != nil {
-			clientIP := realip.FromRequest(r)
 			log.Printf("%s: %v %s %v", r.URL.Path, status, clientIP, err)
 		}
 
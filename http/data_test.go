@@ -0,0 +1,141 @@
+package http
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/rules"
+	"github.com/futureharmony/storagebrowser/v2/settings"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func TestDataCheck(t *testing.T) {
+	t.Parallel()
+
+	user := &users.User{
+		CurrentScope: users.Scope{Name: "current", RootPrefix: "/home/alice"},
+		Rules:        []rules.Rule{{Path: "/private", Allow: false}},
+	}
+
+	testCases := map[string]struct {
+		requestScope *users.Scope
+		path         string
+		want         bool
+	}{
+		"path inside request scope":          {&users.Scope{Name: "b", RootPrefix: "/team"}, "/docs/a.txt", true},
+		"root of request scope":              {&users.Scope{Name: "b", RootPrefix: "/team"}, "/", true},
+		"traversal out of request scope":     {&users.Scope{Name: "b", RootPrefix: "/team"}, "/../other/a.txt", false},
+		"nested traversal out of scope":      {&users.Scope{Name: "b", RootPrefix: "/team"}, "/docs/../../other", false},
+		"traversal that stays inside scope":  {&users.Scope{Name: "b", RootPrefix: "/team"}, "/docs/../a.txt", true},
+		"traversal out of bucket root":       {&users.Scope{Name: "b", RootPrefix: "/"}, "/../../etc/passwd", false},
+		"falls back to current scope":        {nil, "/docs/a.txt", true},
+		"traversal out of current scope":     {nil, "/../bob/a.txt", false},
+		"user rule still applies in scope":   {&users.Scope{Name: "b", RootPrefix: "/team"}, "/private/a.txt", false},
+		"search result with relative path":   {&users.Scope{Name: "b", RootPrefix: "/team"}, "docs/a.txt", true},
+		"relative search result traversal":   {&users.Scope{Name: "b", RootPrefix: "/team"}, "../x", false},
+		"archive entry traversal via prefix": {&users.Scope{Name: "b", RootPrefix: "/team"}, "/docs/../../team2/a", false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			d := &data{
+				user:         user,
+				settings:     &settings.Settings{},
+				requestScope: tc.requestScope,
+			}
+			if got := d.Check(tc.path); got != tc.want {
+				t.Errorf("Check(%q)=%v; want %v", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDataCheckOp(t *testing.T) {
+	t.Parallel()
+
+	user := &users.User{
+		CurrentScope: users.Scope{Name: "current", RootPrefix: "/"},
+		Rules: []rules.Rule{
+			{Glob: true, Path: "/archive/**", Allow: false, Ops: []rules.Operation{rules.OpDelete, rules.OpModify}},
+			{Glob: true, Path: "*.exe", Allow: false, Ops: []rules.Operation{rules.OpCreate}},
+		},
+	}
+	set := &settings.Settings{
+		Rules: []rules.Rule{{Path: "/archive/", Allow: false, Ops: []rules.Operation{rules.OpShare}}},
+	}
+
+	testCases := map[string]struct {
+		op   rules.Operation
+		path string
+		want bool
+	}{
+		"read inside archive":           {rules.OpRead, "/archive/2020/a.txt", true},
+		"delete inside archive":         {rules.OpDelete, "/archive/2020/a.txt", false},
+		"modify inside archive":         {rules.OpModify, "/archive/a.txt", false},
+		"delete outside archive":        {rules.OpDelete, "/docs/a.txt", true},
+		"upload executable":             {rules.OpCreate, "/docs/setup.exe", false},
+		"download executable":           {rules.OpDownload, "/docs/setup.exe", true},
+		"share inside archive (global)": {rules.OpShare, "/archive/a.txt", false},
+		"share outside archive":         {rules.OpShare, "/docs/a.txt", true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			d := &data{user: user, settings: set}
+			if got := d.CheckOp(tc.op, tc.path); got != tc.want {
+				t.Errorf("CheckOp(%q, %q)=%v; want %v", tc.op, tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDataCheckTree(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	for _, name := range []string{"/archive/2020/a.txt", "/docs/a.txt"} {
+		if err := afero.WriteFile(fs, name, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := &data{
+		user: &users.User{
+			CurrentScope: users.Scope{Name: "current", RootPrefix: "/"},
+			Rules: []rules.Rule{
+				{Glob: true, Path: "/archive/**", Allow: false, Ops: []rules.Operation{rules.OpDelete}},
+			},
+		},
+		settings:  &settings.Settings{},
+		requestFs: fs,
+	}
+
+	testCases := map[string]struct {
+		op   rules.Operation
+		path string
+		want error
+	}{
+		"parent of a protected tree": {rules.OpDelete, "/archive", fbErrors.ErrPermissionDenied},
+		"root of the scope":          {rules.OpDelete, "/", fbErrors.ErrPermissionDenied},
+		"inside the protected tree":  {rules.OpDelete, "/archive/2020", fbErrors.ErrPermissionDenied},
+		"unprotected tree":           {rules.OpDelete, "/docs", nil},
+		"operation the rule skips":   {rules.OpRename, "/archive", nil},
+		"path that doesn't exist":    {rules.OpDelete, "/missing", nil},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := d.checkTree(tc.op, tc.path); !errors.Is(err, tc.want) {
+				t.Errorf("checkTree(%q, %q)=%v; want %v", tc.op, tc.path, err, tc.want)
+			}
+		})
+	}
+}
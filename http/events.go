@@ -0,0 +1,79 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+// RunHook runs fn as the operation evt on path, and dst when it has a
+// destination, publishing it on the event bus before and after. The
+// operation doesn't run if a subscriber of the before phase fails, the
+// after phase being published with the veto as its error. Failures of the
+// after phase are logged: they don't fail an operation that happened.
+func (d *data) RunHook(fn func() error, evt, path, dst string, user *users.User) error {
+	e := &events.Event{
+		Type:        events.Type(evt),
+		Phase:       events.Before,
+		Time:        time.Now(),
+		User:        user,
+		IP:          d.ip,
+		Scope:       d.scope(),
+		Fs:          d.requestFs,
+		Path:        path,
+		Destination: dst,
+	}
+
+	// Removed and moved files are described before they are gone.
+	if e.Type == events.Delete || e.Type == events.Rename {
+		d.describe(e, path)
+	}
+
+	err := d.bus.Publish(e)
+	if err == nil {
+		err = fn()
+		if err == nil && e.Type != events.Delete && e.Type != events.Rename {
+			if dst != "" {
+				d.describe(e, dst)
+			} else {
+				d.describe(e, path)
+			}
+		}
+	}
+
+	e.Phase = events.After
+	e.Time = time.Now()
+	e.Err = err
+
+	if pubErr := d.bus.Publish(e); pubErr != nil {
+		log.Printf("WARNING: couldn't handle %s on %s: %v", e.Name(), path, pubErr)
+	}
+	return err
+}
+
+// describe sets the size and the ETag of the event from the file at path
+// in the request's filesystem, when it can be read.
+func (d *data) describe(e *events.Event, path string) {
+	if d.requestFs == nil {
+		return
+	}
+
+	info, err := d.requestFs.Stat(path)
+	if err != nil {
+		return
+	}
+
+	e.Size = info.Size()
+	if !info.IsDir() {
+		e.ETag = fileETag(info)
+	}
+}
+
+// fileETag returns the ETag of a file as served by the API.
+func fileETag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x%x"`, info.ModTime().UnixNano(), info.Size())
+}
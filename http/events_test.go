@@ -0,0 +1,53 @@
+package http
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func TestRunHook(t *testing.T) {
+	t.Parallel()
+
+	errVeto := errors.New("veto")
+	d := &data{bus: events.NewBus(), user: &users.User{}}
+
+	var got []*events.Event
+	d.bus.Subscribe(func(e *events.Event) error {
+		if e.Phase == events.Before && e.Path == "/vetoed" {
+			return errVeto
+		}
+		if e.Phase == events.After {
+			return errors.New("observer failed")
+		}
+		return nil
+	})
+	d.bus.Subscribe(func(e *events.Event) error {
+		if e.Phase == events.After {
+			ev := *e
+			got = append(got, &ev)
+		}
+		return nil
+	})
+
+	ran := false
+	err := d.RunHook(func() error {
+		ran = true
+		return nil
+	}, string(events.Upload), "/vetoed", "", d.user)
+	if !errors.Is(err, errVeto) || ran {
+		t.Fatalf("got error %v and ran %v, want the operation vetoed", err, ran)
+	}
+
+	// A failing observer doesn't fail an operation that happened.
+	err = d.RunHook(func() error { return nil }, string(events.Upload), "/ok", "", d.user)
+	if err != nil {
+		t.Fatalf("got error %v, want none", err)
+	}
+
+	if len(got) != 2 || !errors.Is(got[0].Err, errVeto) || got[1].Err != nil {
+		t.Fatalf("got after events %+v, want the vetoed one with its error", got)
+	}
+}
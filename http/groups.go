@@ -0,0 +1,128 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+type modifyGroupRequest struct {
+	modifyRequest
+	Data *users.Group `json:"data"`
+}
+
+func getGroup(r *http.Request) (*modifyGroupRequest, error) {
+	if r.Body == nil {
+		return nil, fbErrors.ErrEmptyRequest
+	}
+
+	req := &modifyGroupRequest{}
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.What != "group" || req.Data == nil {
+		return nil, fbErrors.ErrInvalidDataType
+	}
+
+	return req, nil
+}
+
+var groupsGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	groups, err := d.store.Groups.Gets()
+	if errors.Is(err, fbErrors.ErrNotExist) {
+		return renderJSON(w, r, []*users.Group{})
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].ID < groups[j].ID
+	})
+
+	return renderJSON(w, r, groups)
+})
+
+var groupGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	id, err := getUserID(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	g, err := d.store.Groups.Get(id)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, g)
+})
+
+var groupPostHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	req, err := getGroup(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	req.Data.ID = 0
+	err = d.store.Groups.Save(req.Data)
+	switch {
+	case errors.Is(err, fbErrors.ErrEmptyGroupName):
+		return http.StatusBadRequest, err
+	case err != nil:
+		return errToStatus(err), err
+	}
+
+	w.Header().Set("Location", "/settings/groups/"+strconv.FormatUint(uint64(req.Data.ID), 10))
+	return http.StatusCreated, nil
+})
+
+var groupPutHandler = withAdmin(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	id, err := getUserID(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	req, err := getGroup(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if req.Data.ID != id {
+		return http.StatusBadRequest, nil
+	}
+
+	if _, err = d.store.Groups.Get(id); err != nil {
+		return errToStatus(err), err
+	}
+
+	err = d.store.Groups.Save(req.Data)
+	switch {
+	case errors.Is(err, fbErrors.ErrEmptyGroupName):
+		return http.StatusBadRequest, err
+	case err != nil:
+		return errToStatus(err), err
+	}
+
+	return http.StatusOK, nil
+})
+
+var groupDeleteHandler = withAdmin(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	id, err := getUserID(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	err = d.store.Groups.Delete(id)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return http.StatusOK, nil
+})
@@ -6,8 +6,11 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/runner"
 	"github.com/futureharmony/storagebrowser/v2/settings"
 	"github.com/futureharmony/storagebrowser/v2/storage"
+	"github.com/futureharmony/storagebrowser/v2/webhook"
 )
 
 type modifyRequest struct {
@@ -33,13 +36,21 @@ func NewHandler(
 	})
 	index, static := getStaticHandlers(store, server, assetsFs)
 
+	bus := events.NewBus()
+	runner.Subscribe(bus, server.EnableExec, store.Settings)
+	webhook.NewDispatcher(store.Webhooks).Subscribe(bus, store.Settings)
+	store.Audit.Subscribe(bus)
+	subscribePreviewInvalidation(bus, fileCache)
+	subscribeContentIndex(bus, store.Content)
+	store.Metadata.Subscribe(bus)
+
 	// NOTE: This fixes the issue where it would redirect if people did not put a
 	// trailing slash in the end. I hate this decision since this allows some awful
 	// URLs https://www.gorillatoolkit.org/pkg/mux#Router.SkipClean
 	r = r.SkipClean(true)
 
 	monkey := func(fn handleFunc, prefix string) http.Handler {
-		return handle(fn, prefix, store, server)
+		return handle(fn, prefix, store, server, bus)
 	}
 
 	r.HandleFunc("/health", healthHandler)
@@ -59,13 +70,28 @@ func NewHandler(
 	users.Handle("/{id:[0-9]+}", monkey(userPutHandler, "")).Methods("PUT")
 	users.Handle("/{id:[0-9]+}", monkey(userGetHandler, "")).Methods("GET")
 	users.Handle("/{id:[0-9]+}", monkey(userDeleteHandler, "")).Methods("DELETE")
+	users.Handle("/{id:[0-9]+}/lockout", monkey(userLockoutGetHandler, "")).Methods("GET")
+	users.Handle("/{id:[0-9]+}/lockout", monkey(userLockoutDeleteHandler, "")).Methods("DELETE")
+
+	groups := api.PathPrefix("/groups").Subrouter()
+	groups.Handle("", monkey(groupsGetHandler, "")).Methods("GET")
+	groups.Handle("", monkey(groupPostHandler, "")).Methods("POST")
+	groups.Handle("/{id:[0-9]+}", monkey(groupPutHandler, "")).Methods("PUT")
+	groups.Handle("/{id:[0-9]+}", monkey(groupGetHandler, "")).Methods("GET")
+	groups.Handle("/{id:[0-9]+}", monkey(groupDeleteHandler, "")).Methods("DELETE")
 
+	api.Path("/resources/batch").Handler(monkey(resourceBatchHandler(fileCache), "")).Methods("POST")
 	api.PathPrefix("/resources").Handler(monkey(resourceGetHandler, "/api/resources")).Methods("GET")
 	api.PathPrefix("/resources").Handler(monkey(resourceDeleteHandler(fileCache), "/api/resources")).Methods("DELETE")
 	api.PathPrefix("/resources").Handler(monkey(resourcePostHandler(fileCache), "/api/resources")).Methods("POST")
 	api.PathPrefix("/resources").Handler(monkey(resourcePutHandler, "/api/resources")).Methods("PUT")
 	api.PathPrefix("/resources").Handler(monkey(resourcePatchHandler(fileCache), "/api/resources")).Methods("PATCH")
 
+	jobs := api.PathPrefix("/jobs").Subrouter()
+	jobs.Handle("", monkey(jobsGetHandler, "")).Methods("GET")
+	jobs.Handle("/{id:[0-9]+}", monkey(jobGetHandler, "")).Methods("GET")
+	jobs.Handle("/{id:[0-9]+}", monkey(jobDeleteHandler, "")).Methods("DELETE")
+
 	api.PathPrefix("/tus").Handler(monkey(tusPostHandler(), "/api/tus")).Methods("POST")
 	api.PathPrefix("/tus").Handler(monkey(tusHeadHandler(), "/api/tus")).Methods("HEAD", "GET")
 	api.PathPrefix("/tus").Handler(monkey(tusPatchHandler(), "/api/tus")).Methods("PATCH")
@@ -76,8 +102,14 @@ func NewHandler(
 	api.Path("/shares").Handler(monkey(shareListHandler, "/api/shares")).Methods("GET")
 	api.PathPrefix("/share").Handler(monkey(shareGetsHandler, "/api/share")).Methods("GET")
 	api.PathPrefix("/share").Handler(monkey(sharePostHandler, "/api/share")).Methods("POST")
+	api.PathPrefix("/share").Handler(monkey(sharePutHandler, "/api/share")).Methods("PUT")
 	api.PathPrefix("/share").Handler(monkey(shareDeleteHandler, "/api/share")).Methods("DELETE")
 
+	api.Handle("/audit", monkey(auditGetHandler, "")).Methods("GET")
+	api.Handle("/bucket-events", bucketEventsHandler(server.BucketEventsToken, bus)).Methods("POST")
+	api.Handle("/webhooks/deliveries", monkey(webhookDeliveriesGetHandler, "")).Methods("GET")
+	api.Handle("/webhooks/test", monkey(webhookTestHandler, "")).Methods("POST")
+
 	api.Handle("/settings", monkey(settingsGetHandler, "")).Methods("GET")
 	api.Handle("/settings", monkey(settingsPutHandler, "")).Methods("PUT")
 
@@ -96,17 +128,23 @@ func NewHandler(
 	api.PathPrefix("/preview/{size}/{path:.*}").
 		Handler(monkey(previewHandler(imgSvc, fileCache, server.EnableThumbnails, server.ResizePreview), "/api/preview")).Methods("GET")
 	api.PathPrefix("/command").Handler(monkey(commandsHandler, "/api/command")).Methods("GET")
+	api.Handle("/events", monkey(eventsHandler, "")).Methods("GET")
 	api.PathPrefix("/search").Handler(monkey(searchHandler, "/api/search")).Methods("GET")
+	api.PathPrefix("/content-index").Handler(monkey(contentIndexPostHandler, "/api/content-index")).Methods("POST")
 	api.Handle("/buckets", monkey(listBucketsHandler(), "")).Methods("GET")
 	api.Handle("/buckets", monkey(createBucketHandler(), "")).Methods("POST")
 	api.Handle("/buckets/{name}", monkey(deleteBucketHandler(), "")).Methods("DELETE")
 	api.Handle("/buckets/{name}", monkey(getBucketSettingsHandler(), "")).Methods("GET")
 	api.Handle("/buckets/{name}", monkey(updateBucketSettingsHandler(), "")).Methods("PUT")
+	api.Handle("/archive/list", monkey(archiveListHandler, "")).Methods("GET")
+	api.Handle("/archive/raw", monkey(archiveRawHandler, "")).Methods("GET")
 	api.PathPrefix("/subtitle").Handler(monkey(subtitleHandler, "/api/subtitle")).Methods("GET")
 
 	public := api.PathPrefix("/public").Subrouter()
 	public.PathPrefix("/dl").Handler(monkey(publicDlHandler, "/api/public/dl/")).Methods("GET")
 	public.PathPrefix("/share").Handler(monkey(publicShareHandler, "/api/public/share/")).Methods("GET")
+	public.PathPrefix("/upload").Handler(monkey(publicUploadHandler(fileCache), "/api/public/upload/")).
+		Methods("POST", "HEAD", "GET", "PATCH", "DELETE")
 
 	return stripPrefix(server.BaseURL, r), nil
 }
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/jobs"
+)
+
+// submitJob hands an operation off to the job manager and answers with
+// the job once recorded. The data of the request, which fn may use,
+// outlives it.
+func submitJob(w http.ResponseWriter, d *data, j *jobs.Job, fn jobs.Func) (int, error) {
+	j.UserID = d.user.ID
+	j.Scope = d.scope().Name
+	if err := d.store.Jobs.Submit(j, fn); err != nil {
+		return errToStatus(err), err
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(j); err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+// withJob runs fn for the job of the id route variable, set in d.raw.
+// Users only see their own jobs, and admins every job.
+func withJob(fn handleFunc) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			return http.StatusNotFound, nil
+		}
+
+		job, err := d.store.Jobs.Get(id)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		if job.UserID != d.user.ID && !d.user.Perm.Admin {
+			return http.StatusNotFound, nil
+		}
+
+		d.raw = job
+		return fn(w, r, d)
+	})
+}
+
+var jobsGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	var (
+		list []*jobs.Job
+		err  error
+	)
+	if d.user.Perm.Admin {
+		list, err = d.store.Jobs.All()
+	} else {
+		list, err = d.store.Jobs.FindByUserID(d.user.ID)
+	}
+	if errors.Is(err, fbErrors.ErrNotExist) {
+		return renderJSON(w, r, []*jobs.Job{})
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return renderJSON(w, r, list)
+})
+
+var jobGetHandler = withJob(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	return renderJSON(w, r, d.raw)
+})
+
+var jobDeleteHandler = withJob(func(_ http.ResponseWriter, _ *http.Request, d *data) (int, error) {
+	if err := d.store.Jobs.Cancel(d.raw.(*jobs.Job).ID); err != nil {
+		return errToStatus(err), err
+	}
+
+	return http.StatusNoContent, nil
+})
@@ -146,6 +146,10 @@ func createPreview(imgSvc ImgService, fileCache FileCache,
 		cacheKey := previewCacheKey(file, previewSize)
 		if err := fileCache.Store(context.Background(), cacheKey, buf.Bytes()); err != nil {
 			fmt.Printf("failed to cache resized image: %v", err)
+			return
+		}
+		if err := indexPreview(context.Background(), fileCache, objectID(file), cacheKey); err != nil {
+			fmt.Printf("failed to index resized image: %v", err)
 		}
 	}()
 
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"context"
+	"log"
+	"path"
+	"strings"
+	"sync"
+
+	aferos3 "github.com/futureharmony/afero-aws-s3"
+	"github.com/spf13/afero"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/files"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+// Preview cache keys depend on the modification time of their file, which
+// is unknown once the file changed outside of the browser. So the keys of
+// every cached preview of a file are indexed by the object they belong to,
+// for them to be removed knowing the object only.
+
+var previewIndexMux sync.Mutex
+
+// objectID identifies an object whatever the scope it's seen from: the
+// bucket and the full object key on S3, the real path otherwise.
+func objectID(file *files.FileInfo) string {
+	return fsObjectID(file.Fs, file.Path)
+}
+
+// fsObjectID returns the ID of the object at a path of a file system.
+func fsObjectID(fs afero.Fs, p string) string {
+	if wrapper, ok := fs.(*aferos3.FsWrapper); ok {
+		return scopeObjectID(users.Scope{Name: wrapper.Bucket, RootPrefix: wrapper.RootPrefix}, p)
+	}
+
+	if realPathFs, ok := fs.(interface {
+		RealPath(name string) (fPath string, err error)
+	}); ok {
+		if realPath, err := realPathFs.RealPath(p); err == nil {
+			return realPath
+		}
+	}
+
+	return p
+}
+
+// scopeObjectID returns the ID of the object at a path of an S3 scope.
+func scopeObjectID(scope users.Scope, p string) string {
+	key := strings.TrimPrefix(path.Join("/", scope.RootPrefix, p), "/")
+	return scope.Name + "/" + key
+}
+
+func previewIndexKey(id string) string {
+	return "preview-index:" + id
+}
+
+// indexPreview records that a preview was cached under key for an object.
+func indexPreview(ctx context.Context, fileCache FileCache, id, key string) error {
+	previewIndexMux.Lock()
+	defer previewIndexMux.Unlock()
+
+	index, _, err := fileCache.Load(ctx, previewIndexKey(id))
+	if err != nil {
+		return err
+	}
+	for _, k := range strings.Split(string(index), "\n") {
+		if k == key {
+			return nil
+		}
+	}
+
+	index = append(index, []byte(key+"\n")...)
+	return fileCache.Store(ctx, previewIndexKey(id), index)
+}
+
+// invalidatePreviews removes every cached preview of an object.
+func invalidatePreviews(ctx context.Context, fileCache FileCache, id string) error {
+	previewIndexMux.Lock()
+	defer previewIndexMux.Unlock()
+
+	index, ok, err := fileCache.Load(ctx, previewIndexKey(id))
+	if err != nil || !ok {
+		return err
+	}
+
+	for _, key := range strings.Split(string(index), "\n") {
+		if key == "" {
+			continue
+		}
+		if err := fileCache.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return fileCache.Delete(ctx, previewIndexKey(id))
+}
+
+// subscribePreviewInvalidation removes the cached previews of the S3
+// objects changed by the events published on the bus, including the ones
+// that were made outside of the browser.
+func subscribePreviewInvalidation(bus *events.Bus, fileCache FileCache) {
+	bus.Subscribe(func(e *events.Event) error {
+		if e.Phase != events.After || e.Err != nil || e.Scope.Name == "" {
+			return nil
+		}
+
+		paths := []string{e.Path}
+		if e.Destination != "" {
+			paths = append(paths, e.Destination)
+		}
+
+		for _, p := range paths {
+			err := invalidatePreviews(context.Background(), fileCache, scopeObjectID(e.Scope, p))
+			if err != nil {
+				log.Printf("WARNING: couldn't invalidate previews of %s: %v", p, err)
+			}
+		}
+		return nil
+	})
+}
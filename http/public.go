@@ -2,23 +2,27 @@ package http
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"net/url"
 	"path"
-	"path/filepath"
 	"strings"
 
 	"github.com/spf13/afero"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/futureharmony/storagebrowser/v2/audit"
 	"github.com/futureharmony/storagebrowser/v2/files"
 	"github.com/futureharmony/storagebrowser/v2/minio"
 	"github.com/futureharmony/storagebrowser/v2/share"
 )
 
-var withHashFile = func(fn handleFunc) handleFunc {
+// withShareLink resolves the link of a public request, whose file system
+// is its owner's, rooted at the shared directory. The shared file is
+// left in d.raw.
+var withShareLink = func(fn handleFunc) handleFunc {
 	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-		id, ifPath := ifPathWithName(r)
+		id, _ := ifPathWithName(r)
 		link, err := d.store.Share.GetByHash(id)
 		if err != nil {
 			return errToStatus(err), err
@@ -34,23 +38,44 @@ var withHashFile = func(fn handleFunc) handleFunc {
 			return errToStatus(err), err
 		}
 
+		if err := d.store.Groups.Apply(user); err != nil {
+			return http.StatusInternalServerError, err
+		}
+
 		d.user = user
+		d.share = link
+
+		// Links are resolved against the scope they were created in, or
+		// the owner's current one for those that didn't record it.
+		scope := linkScope(link)
+		if scope.Name == "" && scope.RootPrefix == "" {
+			scope = d.user.CurrentScope
+		}
+		// Links die with the owner's access to their scope.
+		if d.server.StorageType == "s3" && !d.user.HasScope(scope) {
+			return http.StatusNotFound, nil
+		}
+		d.requestScope = &scope
+		d.user.Perm = d.user.ScopePerm(scope.Name)
+
+		// shareFs returns the file system of the scope rooted at root.
+		shareFs := func(root string) afero.Fs {
+			if d.server.StorageType != "s3" {
+				if root == "" || root == "/" {
+					return d.user.Fs
+				}
+				return afero.NewBasePathFs(d.user.Fs, root)
+			}
 
-		// For public shares, we need to create a filesystem instance
-		var fsInstance afero.Fs
-		if d.server.StorageType == "s3" {
-			// Calculate the full scope path for the shared resource
 			scopePath := d.server.Root
-			if d.user.CurrentScope.RootPrefix != "" {
-				scopePath = d.user.CurrentScope.RootPrefix
+			if scope.RootPrefix != "" {
+				scopePath = scope.RootPrefix
 			}
-			fsInstance = minio.CreateUserFs(d.user.CurrentScope.Name, scopePath)
-		} else {
-			fsInstance = d.user.Fs
+			return minio.CreateUserFs(scope.Name, path.Join(scopePath, root))
 		}
 
 		file, err := files.NewFileInfo(&files.FileOptions{
-			Fs:         fsInstance,
+			Fs:         shareFs(""),
 			Path:       link.Path,
 			Modify:     d.user.Perm.Modify,
 			Expand:     false,
@@ -62,30 +87,38 @@ var withHashFile = func(fn handleFunc) handleFunc {
 			return errToStatus(err), err
 		}
 
-		// share base path
-		basePath := link.Path
+		// A shared directory becomes the root of the file system, paths
+		// being relative to it, while a shared file keeps its path.
+		d.user.Fs = shareFs("")
+		if file.IsDir {
+			d.user.Fs = shareFs(link.Path)
+		}
+		d.requestFs = d.user.Fs
 
-		// file relative path
-		filePath := ""
+		d.raw = file
+		return fn(w, r, d)
+	}
+}
 
-		if file.IsDir {
-			basePath = filepath.Dir(basePath)
+var withHashFile = func(fn handleFunc) handleFunc {
+	return withShareLink(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		_, ifPath := ifPathWithName(r)
+		filePath := d.share.Path
+		if d.raw.(*files.FileInfo).IsDir {
 			filePath = ifPath
-		} else {
-			// For files, we need to set filePath to link.Path to access the actual file
-			filePath = link.Path
+			// The content of a hidden file drop is never looked up.
+			if d.share.Upload && d.share.HideListing {
+				filePath = "/"
+			}
 		}
 
-		// set fs root to the shared file/folder
-		d.user.Fs = minio.NewBasePathFs()
-
-		file, err = files.NewFileInfo(&files.FileOptions{
+		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:      d.user.Fs,
 			Path:    filePath,
 			Modify:  d.user.Perm.Modify,
-			Expand:  true,
+			Expand:  !d.share.HideListing,
 			Checker: d,
-			Token:   link.Token,
+			Token:   d.share.Token,
 		})
 		if err != nil {
 			return errToStatus(err), err
@@ -93,7 +126,7 @@ var withHashFile = func(fn handleFunc) handleFunc {
 
 		d.raw = file
 		return fn(w, r, d)
-	}
+	})
 }
 
 // ref to https://github.com/futureharmony/storagebrowser/pull/727
@@ -114,7 +147,7 @@ func ifPathWithName(r *http.Request) (id, filePath string) {
 var publicShareHandler = withHashFile(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	file := d.raw.(*files.FileInfo)
 
-	if file.IsDir {
+	if file.IsDir && file.Listing != nil {
 		file.Listing.Sorting = files.Sorting{By: "name", Asc: false}
 		file.Listing.ApplySort()
 		return renderJSON(w, r, file)
@@ -124,14 +157,58 @@ var publicShareHandler = withHashFile(func(w http.ResponseWriter, r *http.Reques
 })
 
 var publicDlHandler = withHashFile(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	// Nothing is downloaded from a file drop.
+	if d.share.Upload {
+		return http.StatusForbidden, nil
+	}
+
 	file := d.raw.(*files.FileInfo)
+
+	// The download is counted before being served, so that the link
+	// can't be downloaded more than allowed at the same time. A ranged
+	// request only continues the download of its client counted last.
+	client := d.ip + " " + file.Path
+	if r.Header.Get("Range") == "" || !d.store.Share.Resumes(d.share.Hash, client) {
+		link, err := d.store.Share.AddDownload(d.share.Hash, client)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		if link.Revoked {
+			d.notifyShareRevoked(link)
+		}
+	}
+
+	d.audit(audit.ActionDownload, file.Path, "", file.Size, nil)
+
+	cw := &countingWriter{ResponseWriter: w}
+	var status int
+	var err error
 	if !file.IsDir {
-		return rawFileHandler(w, r, file)
+		status, err = rawFileHandler(cw, r, file)
+	} else {
+		status, err = rawDirHandler(cw, r, d, file)
+	}
+
+	access := &share.Access{Hash: d.share.Hash, IP: d.ip, Path: file.Path, Size: cw.n}
+	if logErr := d.store.Share.LogAccess(access); logErr != nil {
+		log.Printf("WARNING: couldn't log access to share %s: %v", d.share.Hash, logErr)
 	}
 
-	return rawDirHandler(w, r, d, file)
+	return status, err
 })
 
+// countingWriter counts the bytes of a response body.
+type countingWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
 func authenticateShareRequest(r *http.Request, l *share.Link) (int, error) {
 	if l.PasswordHash == "" {
 		return 0, nil
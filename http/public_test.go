@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/asdine/storm/v3"
@@ -95,7 +96,7 @@ func TestPublicShareHandlerAuthentication(t *testing.T) {
 				}
 
 				recorder := httptest.NewRecorder()
-				handler := handle(handler, "", storage, &settings.Server{})
+				handler := handle(handler, "", storage, &settings.Server{}, nil)
 
 				handler.ServeHTTP(recorder, tc.req)
 				result := recorder.Result()
@@ -134,3 +135,232 @@ func (cu *customFSUser) Get(baseScope string, id interface{}) (*users.User, erro
 
 	return user, nil
 }
+
+func TestPublicUploadHandler(t *testing.T) {
+	t.Parallel()
+
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	store, err := bolt.NewStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Users.Save(&users.User{Username: "username", Password: "pw", Perm: users.Permissions{Create: true, Modify: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Settings.Save(&settings.Settings{Key: []byte("key"), FileMode: 0o640, DirMode: 0o750}); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range []*share.Link{
+		{Hash: "drop", Path: "/inbox", UserID: 1, Upload: true, HideListing: true, MaxUploadSize: 8, MaxUploads: 2},
+		{Hash: "read", Path: "/inbox", UserID: 1},
+	} {
+		if err := store.Share.Save(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/inbox/existing.txt", []byte("secret"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	store.Users = &customFSUser{Store: store.Users, fs: fs}
+
+	request := func(handler handleFunc, method, target, body string) int {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		handle(handler, "/api/public/", store, &settings.Server{}, nil).ServeHTTP(recorder, r)
+		return recorder.Code
+	}
+	upload := publicUploadHandler(nil)
+
+	tests := []struct {
+		handler handleFunc
+		method  string
+		target  string
+		body    string
+		want    int
+	}{
+		{upload, http.MethodPost, "/api/public/drop?path=/a.txt", "hello", http.StatusOK},
+		{upload, http.MethodPost, "/api/public/drop?path=/existing.txt&override=true", "hello", http.StatusConflict},
+		{upload, http.MethodPost, "/api/public/drop?path=/big.txt", "far too large", http.StatusRequestEntityTooLarge},
+		{upload, http.MethodPost, "/api/public/drop?path=/dir/", "", http.StatusForbidden},
+		{upload, http.MethodPost, "/api/public/drop?path=/b.txt", "world", http.StatusOK},
+		{upload, http.MethodPost, "/api/public/drop?path=/c.txt", "again", http.StatusForbidden},
+		{upload, http.MethodPost, "/api/public/read?path=/d.txt", "hello", http.StatusForbidden},
+		{publicDlHandler, http.MethodGet, "/api/public/drop/existing.txt", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := request(tt.handler, tt.method, tt.target, tt.body); got != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.target, got, tt.want)
+		}
+	}
+
+	if content, _ := afero.ReadFile(fs, "/inbox/a.txt"); string(content) != "hello" {
+		t.Errorf("dropped file content = %q", content)
+	}
+	if content, _ := afero.ReadFile(fs, "/inbox/existing.txt"); string(content) != "secret" {
+		t.Errorf("existing file replaced by %q", content)
+	}
+	if link, _ := store.Share.GetByHash("drop"); link.Uploads != 2 {
+		t.Errorf("drop counted %d uploads, want 2", link.Uploads)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/public/drop", http.NoBody)
+	recorder := httptest.NewRecorder()
+	handle(publicShareHandler, "/api/public/", store, &settings.Server{}, nil).ServeHTTP(recorder, r)
+	if strings.Contains(recorder.Body.String(), "existing.txt") {
+		t.Errorf("hidden file drop listed its content: %s", recorder.Body.String())
+	}
+}
+
+func TestPublicUploadTus(t *testing.T) {
+	t.Parallel()
+
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	store, err := bolt.NewStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Users.Save(&users.User{Username: "username", Password: "pw", Perm: users.Permissions{Create: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Settings.Save(&settings.Settings{Key: []byte("key"), FileMode: 0o640, DirMode: 0o750}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Share.Save(&share.Link{Hash: "tusdrop", Path: "/tusdrop", UserID: 1, Upload: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := afero.NewMemMapFs()
+	if err := fs.MkdirAll("/tusdrop", 0o750); err != nil {
+		t.Fatal(err)
+	}
+	store.Users = &customFSUser{Store: store.Users, fs: fs}
+
+	request := func(method, target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Tus-Resumable", "1.0.0")
+		r.Header.Set("Upload-Length", "5")
+		r.Header.Set("Upload-Offset", "0")
+		r.Header.Set("Content-Type", "application/offset+octet-stream")
+		recorder := httptest.NewRecorder()
+		handle(publicUploadHandler(nil), "/api/public/upload/", store, &settings.Server{}, nil).ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	created := request(http.MethodPost, "/api/public/upload/tusdrop?path=/a.txt", "")
+	if created.Code != http.StatusCreated {
+		t.Fatalf("creating the upload answered %d", created.Code)
+	}
+	location := created.Header().Get("Location")
+
+	// Other visitors of the link reach neither the upload nor the file.
+	for _, method := range []string{http.MethodHead, http.MethodPatch, http.MethodDelete} {
+		for _, target := range []string{"/api/public/upload/tusdrop?path=/a.txt", "/api/public/upload/tusdrop?path=/a.txt&upload=guess"} {
+			if got := request(method, target, "evil!").Code; got != http.StatusNotFound {
+				t.Errorf("%s %s = %d, want %d", method, target, got, http.StatusNotFound)
+			}
+		}
+	}
+
+	// Nor do the users of the scope.
+	d := newArchiveData(t)
+	d.requestFs = fs
+	d.server = &settings.Server{}
+	r := httptest.NewRequest(http.MethodPatch, "/api/tus?path=/tusdrop/a.txt", strings.NewReader("evil!"))
+	r.Header.Set("Upload-Offset", "0")
+	r.Header.Set("Content-Type", "application/offset+octet-stream")
+	if got, _ := tusPatch()(httptest.NewRecorder(), r, d); got != http.StatusNotFound {
+		t.Errorf("a user continued the upload of a visitor: %d", got)
+	}
+
+	if got := request(http.MethodPatch, location, "hello").Code; got != http.StatusNoContent {
+		t.Errorf("continuing the upload answered %d", got)
+	}
+	if content, _ := afero.ReadFile(fs, "/tusdrop/a.txt"); string(content) != "hello" {
+		t.Errorf("uploaded file content = %q", content)
+	}
+}
+
+func TestPublicDlHandlerDownloadLimit(t *testing.T) {
+	t.Parallel()
+
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	store, err := bolt.NewStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Users.Save(&users.User{Username: "username", Password: "pw"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Settings.Save(&settings.Settings{Key: []byte("key")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Share.Save(&share.Link{Hash: "h", Path: "/a.txt", UserID: 1, MaxDownloads: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/a.txt", []byte("hello world"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	store.Users = &customFSUser{Store: store.Users, fs: fs}
+
+	// The second and third requests resume the first download, whatever
+	// they get. The fourth asks for a range from another client, and the
+	// fifth for the whole file again: both are counted.
+	for i, tt := range []struct {
+		ip      string
+		rng     string
+		ifRange string
+		want    int
+	}{
+		{"192.0.2.1", "", "", http.StatusOK},
+		{"192.0.2.1", "bytes=6-", "", http.StatusPartialContent},
+		{"192.0.2.1", "bytes=1-", `"stale"`, http.StatusOK},
+		{"192.0.2.2", "bytes=6-", "", http.StatusPartialContent},
+		{"192.0.2.2", "", "", http.StatusOK},
+		{"192.0.2.1", "bytes=6-", "", http.StatusNotFound},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/public/dl/h", http.NoBody)
+		r.RemoteAddr = tt.ip + ":1234"
+		if tt.rng != "" {
+			r.Header.Set("Range", tt.rng)
+		}
+		if tt.ifRange != "" {
+			r.Header.Set("If-Range", tt.ifRange)
+		}
+		recorder := httptest.NewRecorder()
+		handle(publicDlHandler, "/api/public/dl/", store, &settings.Server{}, nil).ServeHTTP(recorder, r)
+		if recorder.Code != tt.want {
+			t.Errorf("request %d answered %d, want %d", i+1, recorder.Code, tt.want)
+		}
+	}
+
+	accesses, err := store.Share.Accesses("h", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int64
+	for _, a := range accesses {
+		sizes = append(sizes, a.Size)
+	}
+	if fmt.Sprint(sizes) != "[11 5 11 5 11]" {
+		t.Errorf("logged sizes %v, want [11 5 11 5 11]", sizes)
+	}
+}
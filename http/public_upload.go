@@ -0,0 +1,84 @@
+package http
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/futureharmony/storagebrowser/v2/files"
+)
+
+// publicUploadHandler receives the files dropped through a file drop
+// link, in a single POST like /api/resources or through the tus protocol
+// like /api/tus, the path of the file being given by the path parameter.
+func publicUploadHandler(fileCache FileCache) handleFunc {
+	post := resourcePost(fileCache)
+	tusCreate := tusPost(func(d *data) string { return path.Join("/api/public/upload", d.share.Hash) })
+	tusOffset, tusWrite, tusCancel := tusHead(), tusPatch(), tusDelete()
+
+	return withShareLink(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if !d.share.Upload || !d.raw.(*files.FileInfo).IsDir {
+			return http.StatusForbidden, nil
+		}
+
+		// Visitors only add files: they neither create directories nor
+		// replace the files that already exist.
+		query := r.URL.Query()
+		if strings.HasSuffix(query.Get("path"), "/") {
+			return http.StatusForbidden, nil
+		}
+		query.Del("override")
+		r.URL.RawQuery = query.Encode()
+		d.user.Perm.Modify = false
+
+		maxSize := d.share.MaxUploadSize
+		if maxSize != 0 && r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+		}
+
+		switch {
+		case r.Method == http.MethodPost && r.Header.Get("Tus-Resumable") == "":
+			if maxSize != 0 && r.ContentLength < 0 {
+				return http.StatusLengthRequired, nil
+			}
+			if maxSize != 0 && r.ContentLength > maxSize {
+				return http.StatusRequestEntityTooLarge, nil
+			}
+			return countUpload(w, r, d, post, http.StatusOK)
+		case r.Method == http.MethodPost:
+			length, err := getUploadLength(r)
+			if err != nil {
+				return http.StatusBadRequest, err
+			}
+			if maxSize != 0 && length > maxSize {
+				return http.StatusRequestEntityTooLarge, nil
+			}
+			return countUpload(w, r, d, tusCreate, http.StatusCreated)
+		case r.Method == http.MethodHead, r.Method == http.MethodGet:
+			return tusOffset(w, r, d)
+		case r.Method == http.MethodPatch:
+			return tusWrite(w, r, d)
+		case r.Method == http.MethodDelete:
+			return tusCancel(w, r, d)
+		default:
+			return http.StatusMethodNotAllowed, nil
+		}
+	})
+}
+
+// countUpload counts the upload made by fn against the limit of the file
+// drop, giving it back unless fn answers with the success status.
+func countUpload(w http.ResponseWriter, r *http.Request, d *data, fn handleFunc, success int) (int, error) {
+	if err := d.store.Share.AddUploads(d.share.Hash, 1); err != nil {
+		return errToStatus(err), err
+	}
+
+	status, err := fn(w, r, d)
+	if err != nil || status != success {
+		if releaseErr := d.store.Share.AddUploads(d.share.Hash, -1); releaseErr != nil && err == nil {
+			err = releaseErr
+		}
+	}
+
+	return status, err
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/spf13/afero"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/quota"
+)
+
+// quotaTargets returns the usage keys, and their limits, that writes made
+// by the user of the request in the request's scope are accounted to.
+// The scope is tracked from when a user has a quota of it, and from then
+// on accounted to by every user writing to it, for its usage to stay its
+// real size.
+func (d *data) quotaTargets() []quota.Target {
+	targets := []quota.Target{{Key: quota.UserKey(d.user.ID), Limit: d.user.Quota}}
+
+	scope := d.scope()
+	key := quota.ScopeKey(scope.Name, scope.RootPrefix)
+	if limit := d.user.ScopeQuota(scope.Name); limit != nil {
+		targets = append(targets, quota.Target{Key: key, Limit: *limit})
+	} else if _, err := d.store.Usage.Get(key); err == nil {
+		targets = append(targets, quota.Target{Key: key})
+	}
+
+	return targets
+}
+
+// limitsBytes tells if a byte quota applies to the request.
+func (d *data) limitsBytes() bool {
+	for _, t := range d.quotaTargets() {
+		if t.Limit.Bytes != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// byteQuota returns the byte quota, and its usage, with the least room
+// left among the ones that apply to the request.
+func (d *data) byteQuota() (limit, used uint64, ok bool, err error) {
+	targets := d.quotaTargets()
+	if err = d.seedScopeUsage(targets); err != nil {
+		return 0, 0, false, err
+	}
+
+	for _, t := range targets {
+		if t.Limit.Bytes == 0 {
+			continue
+		}
+
+		u, getErr := d.store.Usage.Get(t.Key)
+		switch {
+		case errors.Is(getErr, fbErrors.ErrNotExist):
+			u = &quota.Usage{Key: t.Key}
+		case getErr != nil:
+			return 0, 0, false, getErr
+		}
+
+		tUsed := uint64(u.Bytes) //nolint:gosec
+		if !ok || t.Limit.Bytes-min(tUsed, t.Limit.Bytes) < limit-min(used, limit) {
+			limit, used, ok = t.Limit.Bytes, tUsed, true
+		}
+	}
+
+	return limit, used, ok, nil
+}
+
+// quotaReservation is the usage reserved by a write before it's made,
+// until it's settled with what the write changed.
+type quotaReservation struct {
+	d       *data
+	bytes   int64
+	objects int64
+}
+
+// reserveQuota adds the bytes and objects a write is about to make to the
+// usage of the user and of the scope, returning fbErrors.ErrQuotaExceeded
+// if they would go over their quota. The reservation must be settled once
+// the write is over, whether it succeeded or not.
+func (d *data) reserveQuota(bytes, objects int64) (*quotaReservation, error) {
+	targets := d.quotaTargets()
+	if err := d.seedScopeUsage(targets); err != nil {
+		return nil, err
+	}
+
+	if err := d.store.Usage.Reserve(targets, bytes, objects); err != nil {
+		return nil, err
+	}
+	return &quotaReservation{d: d, bytes: bytes, objects: objects}, nil
+}
+
+// settle replaces the reserved usage with the bytes and objects the write
+// actually changed.
+func (q *quotaReservation) settle(bytes, objects int64) {
+	q.d.addUsage(bytes-q.bytes, objects-q.objects)
+}
+
+// addUsage records written, or removed when negative, bytes and objects.
+// Failures are logged since the operation itself already succeeded.
+func (d *data) addUsage(bytes, objects int64) {
+	targets := d.quotaTargets()
+	if err := d.seedScopeUsage(targets); err != nil {
+		log.Printf("WARNING: couldn't compute scope usage: %v", err)
+		return
+	}
+
+	keys := make([]string, len(targets))
+	for i, t := range targets {
+		keys[i] = t.Key
+	}
+
+	if err := d.store.Usage.Add(keys, bytes, objects); err != nil {
+		log.Printf("WARNING: couldn't update storage usage: %v", err)
+	}
+}
+
+// seedScopeUsage computes, once, the usage of scopes that were never
+// tracked before so that incremental updates start from the real size.
+func (d *data) seedScopeUsage(targets []quota.Target) error {
+	// The first target always is the user.
+	for _, t := range targets[1:] {
+		_, err := d.store.Usage.Get(t.Key)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, fbErrors.ErrNotExist) {
+			return err
+		}
+
+		bytes, objects, err := diskUsageOf(d.requestFs, "/")
+		if err != nil {
+			return err
+		}
+		if err := d.store.Usage.Set(t.Key, bytes, objects); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diskUsageOf returns the size and the number of files of a path.
+// Missing paths have no usage.
+func diskUsageOf(fs afero.Fs, path string) (bytes, objects int64, err error) {
+	err = afero.Walk(fs, path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			bytes += info.Size()
+			objects++
+		}
+		return nil
+	})
+	if os.IsNotExist(err) || errors.Is(err, afero.ErrFileNotFound) {
+		return 0, 0, nil
+	}
+
+	return bytes, objects, err
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/futureharmony/storagebrowser/v2/quota"
+)
+
+func TestAddUsageScope(t *testing.T) {
+	t.Parallel()
+
+	d := newArchiveData(t)
+	key := quota.ScopeKey(d.user.CurrentScope.Name, d.user.CurrentScope.RootPrefix)
+
+	// An untracked scope stays untracked.
+	d.addUsage(10, 1)
+	if _, err := d.store.Usage.Get(key); err == nil {
+		t.Fatal("a write tracked a scope nobody has a quota of")
+	}
+
+	// A tracked one is accounted to by users without a quota of it.
+	if err := d.store.Usage.Set(key, 100, 4); err != nil {
+		t.Fatal(err)
+	}
+	d.addUsage(10, 1)
+	d.addUsage(-5, -1)
+	u, err := d.store.Usage.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Bytes != 105 || u.Objects != 4 {
+		t.Errorf("scope usage = %d bytes, %d objects, want 105 and 4", u.Bytes, u.Objects)
+	}
+}
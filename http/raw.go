@@ -2,7 +2,6 @@ package http
 
 import (
 	"errors"
-	"io"
 	"io/fs"
 	"log"
 	"mime"
@@ -12,15 +11,18 @@ import (
 	gopath "path"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 
+	aferos3 "github.com/futureharmony/afero-aws-s3"
 	"github.com/mholt/archives"
 	"github.com/spf13/afero"
 
+	"github.com/futureharmony/storagebrowser/v2/audit"
 	"github.com/futureharmony/storagebrowser/v2/files"
 	"github.com/futureharmony/storagebrowser/v2/fileutils"
+	"github.com/futureharmony/storagebrowser/v2/metaindex"
 	"github.com/futureharmony/storagebrowser/v2/minio"
+	"github.com/futureharmony/storagebrowser/v2/rules"
 	"github.com/futureharmony/storagebrowser/v2/users"
 )
 
@@ -191,6 +193,12 @@ var rawHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data)
 		return errToStatus(err), err
 	}
 
+	if !d.CheckOp(rules.OpDownload, path) {
+		return http.StatusForbidden, nil
+	}
+
+	d.audit(audit.ActionDownload, path, "", file.Size, nil)
+
 	if files.IsNamedPipe(file.Mode) {
 		setContentDisposition(w, r, file)
 		return 0, nil
@@ -204,7 +212,7 @@ var rawHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data)
 })
 
 func getFiles(d *data, path, commonPath string) ([]archives.FileInfo, error) {
-	if !d.Check(path) {
+	if !d.CheckOp(rules.OpDownload, path) {
 		return nil, nil
 	}
 
@@ -282,6 +290,18 @@ func rawDirHandler(w http.ResponseWriter, r *http.Request, d *data, file *files.
 		return http.StatusInternalServerError, err
 	}
 
+	if isS3Fs(d.requestFs) {
+		for i, fname := range filenames {
+			// Convert OS-specific paths to S3-compatible paths with forward slashes
+			fname = gopath.Clean(strings.ReplaceAll(fname, string(filepath.Separator), "/"))
+			// Ensure it starts with a forward slash for S3
+			if fname != "" && fname[0] != '/' {
+				fname = "/" + fname
+			}
+			filenames[i] = fname
+		}
+	}
+
 	var commonDir string
 	if isS3Fs(d.requestFs) {
 		// For S3 filesystems, use forward slash as separator for CommonPrefix
@@ -291,27 +311,6 @@ func rawDirHandler(w http.ResponseWriter, r *http.Request, d *data, file *files.
 		commonDir = fileutils.CommonPrefix(filepath.Separator, filenames...)
 	}
 
-	var allFiles []archives.FileInfo
-	for _, fname := range filenames {
-		// For S3 filesystems, we need to normalize the path format
-		normalizedFname := fname
-		if isS3Fs(d.requestFs) {
-			// Convert OS-specific paths to S3-compatible paths with forward slashes
-			normalizedFname = gopath.Clean(strings.ReplaceAll(fname, string(filepath.Separator), "/"))
-			// Ensure it starts with a forward slash for S3
-			if normalizedFname != "" && normalizedFname[0] != '/' {
-				normalizedFname = "/" + normalizedFname
-			}
-		}
-
-		archiveFiles, err := getFiles(d, normalizedFname, commonDir)
-		if err != nil {
-			log.Printf("Failed to get files from %s: %v", normalizedFname, err)
-			continue
-		}
-		allFiles = append(allFiles, archiveFiles...)
-	}
-
 	name := filepath.Base(commonDir)
 	if name == "." || name == "" || name == string(filepath.Separator) {
 		if file.Name != "" {
@@ -340,6 +339,21 @@ func rawDirHandler(w http.ResponseWriter, r *http.Request, d *data, file *files.
 		name = "_" + name
 	}
 	name += extension
+
+	if extension == ".zip" {
+		return serveZip(w, r, d, filenames, commonDir, name)
+	}
+
+	var allFiles []archives.FileInfo
+	for _, fname := range filenames {
+		archiveFiles, err := getFiles(d, fname, commonDir)
+		if err != nil {
+			log.Printf("Failed to get files from %s: %v", fname, err)
+			continue
+		}
+		allFiles = append(allFiles, archiveFiles...)
+	}
+
 	w.Header().Set("Content-Disposition", "attachment; filename*=utf-8''"+url.PathEscape(name))
 
 	if err := archiver.Archive(r.Context(), w, allFiles); err != nil {
@@ -350,12 +364,6 @@ func rawDirHandler(w http.ResponseWriter, r *http.Request, d *data, file *files.
 }
 
 func rawFileHandler(w http.ResponseWriter, r *http.Request, file *files.FileInfo) (int, error) {
-	fd, err := file.Fs.Open(file.Path)
-	if err != nil {
-		return http.StatusInternalServerError, err
-	}
-	defer fd.Close()
-
 	setContentDisposition(w, r, file)
 	w.Header().Add("Content-Security-Policy", `script-src 'none';`)
 	w.Header().Set("Cache-Control", "private")
@@ -366,26 +374,17 @@ func rawFileHandler(w http.ResponseWriter, r *http.Request, file *files.FileInfo
 		w.Header().Set("Content-Type", mimeType)
 	}
 
-	// Check if filesystem is S3 to handle differently since S3 files are not seekable
-	// We need to get the actual filesystem from the data object in the rawHandler function
-	// Since we cannot import aferos3 in files package, we check by name or type
-	// We'll pass filesystem type information through the request context or check differently
-	if minio.IsS3FileSystem(file.Fs) {
-		// For S3 files, we can't use http.ServeContent because it requires seeking
-		// Instead, we'll set headers manually and copy the content
-
-		// Set content length if available
-		if file.Size > 0 {
-			w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
-		}
+	// S3 objects are fetched with ranged and conditional GETs rather than
+	// by seeking through them like http.ServeContent does.
+	if wrapper, ok := file.Fs.(*aferos3.FsWrapper); ok {
+		return serveS3Object(w, r, minio.GetS3Client(), wrapper.Bucket, metaindex.Key(wrapper.RootPrefix, file.Path))
+	}
 
-		// Copy the file content to response
-		_, err := io.Copy(w, fd)
-		if err != nil {
-			return http.StatusInternalServerError, err
-		}
-		return 0, nil
+	fd, err := file.Fs.Open(file.Path)
+	if err != nil {
+		return http.StatusInternalServerError, err
 	}
+	defer fd.Close()
 
 	http.ServeContent(w, r, file.Name, file.ModTime, fd)
 	return 0, nil
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3ObjectGetter is the part of the S3 client serving objects needs.
+type s3ObjectGetter interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// serveS3Object serves an object of a bucket. The range and the conditions
+// of the request are passed on to S3, for only the bytes asked for to be
+// fetched and for S3 to tell when the client's copy is up to date.
+func serveS3Object(w http.ResponseWriter, r *http.Request, client s3ObjectGetter, bucket, key string) (int, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if v := r.Header.Get("If-Match"); v != "" {
+		input.IfMatch = aws.String(v)
+	} else if t, err := http.ParseTime(r.Header.Get("If-Unmodified-Since")); err == nil {
+		input.IfUnmodifiedSince = &t
+	}
+	if v := r.Header.Get("If-None-Match"); v != "" {
+		input.IfNoneMatch = aws.String(v)
+	} else if t, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		input.IfModifiedSince = &t
+	}
+	// S3 serves a single range: the whole object is sent for the others.
+	if v := r.Header.Get("Range"); strings.HasPrefix(v, "bytes=") && !strings.Contains(v, ",") {
+		input.Range = aws.String(v)
+	}
+
+	out, err := client.GetObject(r.Context(), input)
+	if err == nil && input.Range != nil && !s3IfRange(r.Header.Get("If-Range"), out) {
+		// The object changed since the client got its first bytes.
+		out.Body.Close()
+		input.Range = nil
+		out, err = client.GetObject(r.Context(), input)
+	}
+	if err != nil {
+		var respErr interface{ HTTPStatusCode() int }
+		if !errors.As(err, &respErr) {
+			return errToStatus(err), err
+		}
+
+		switch status := respErr.HTTPStatusCode(); status {
+		case http.StatusNotModified:
+			w.WriteHeader(status)
+			return 0, nil
+		case http.StatusPreconditionFailed, http.StatusRequestedRangeNotSatisfiable, http.StatusNotFound:
+			return status, nil
+		default:
+			return errToStatus(err), err
+		}
+	}
+	defer out.Body.Close()
+
+	header := w.Header()
+	header.Set("Accept-Ranges", "bytes")
+	if out.ETag != nil {
+		header.Set("ETag", *out.ETag)
+	}
+	if out.LastModified != nil {
+		header.Set("Last-Modified", out.LastModified.UTC().Format(http.TimeFormat))
+	}
+	if out.ContentLength != nil {
+		header.Set("Content-Length", strconv.FormatInt(*out.ContentLength, 10))
+	}
+
+	status := http.StatusOK
+	if out.ContentRange != nil {
+		header.Set("Content-Range", *out.ContentRange)
+		status = http.StatusPartialContent
+	}
+	w.WriteHeader(status)
+
+	if r.Method == http.MethodHead {
+		return 0, nil
+	}
+	// The headers are sent: an error can only be logged from now on.
+	_, err = io.Copy(w, out.Body)
+	return 0, err
+}
+
+// s3IfRange tells if the object is the one the If-Range header of a
+// request stands for, given by its strong ETag or its modification time.
+func s3IfRange(ifRange string, out *s3.GetObjectOutput) bool {
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) {
+		return out.ETag != nil && ifRange == *out.ETag
+	}
+
+	t, err := http.ParseTime(ifRange)
+	return err == nil && out.LastModified != nil && out.LastModified.Unix() == t.Unix()
+}
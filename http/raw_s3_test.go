@@ -0,0 +1,179 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type s3StatusError int
+
+func (e s3StatusError) Error() string       { return http.StatusText(int(e)) }
+func (e s3StatusError) HTTPStatusCode() int { return int(e) }
+
+// fakeS3Object answers GetObject like S3 for a single object.
+type fakeS3Object struct {
+	content string
+	etag    string
+	modTime time.Time
+	gets    int
+}
+
+func (o *fakeS3Object) GetObject(_ context.Context, in *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	o.gets++
+	if in.IfMatch != nil && *in.IfMatch != o.etag {
+		return nil, s3StatusError(http.StatusPreconditionFailed)
+	}
+	if in.IfNoneMatch != nil && *in.IfNoneMatch == o.etag {
+		return nil, s3StatusError(http.StatusNotModified)
+	}
+	if in.IfModifiedSince != nil && !o.modTime.After(*in.IfModifiedSince) {
+		return nil, s3StatusError(http.StatusNotModified)
+	}
+
+	out := &s3.GetObjectOutput{ETag: aws.String(o.etag), LastModified: aws.Time(o.modTime)}
+	start, end := 0, len(o.content)-1
+	if in.Range != nil {
+		bounds := strings.SplitN(strings.TrimPrefix(*in.Range, "bytes="), "-", 2)
+		start, _ = strconv.Atoi(bounds[0])
+		if bounds[1] != "" {
+			end, _ = strconv.Atoi(bounds[1])
+		}
+		if start >= len(o.content) {
+			return nil, s3StatusError(http.StatusRequestedRangeNotSatisfiable)
+		}
+		out.ContentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, len(o.content)))
+	}
+	out.ContentLength = aws.Int64(int64(end - start + 1))
+	out.Body = io.NopCloser(strings.NewReader(o.content[start : end+1]))
+	return out, nil
+}
+
+func TestServeS3Object(t *testing.T) {
+	t.Parallel()
+
+	modTime := time.Date(2026, 5, 4, 3, 2, 1, 0, time.UTC)
+	lastModified := modTime.Format(http.TimeFormat)
+
+	testCases := map[string]struct {
+		header      http.Header
+		wantStatus  int
+		wantBody    string
+		wantHeaders map[string]string
+		wantGets    int
+	}{
+		"whole object": {
+			wantStatus:  http.StatusOK,
+			wantBody:    "0123456789",
+			wantHeaders: map[string]string{"ETag": `"abc"`, "Content-Length": "10", "Last-Modified": lastModified, "Accept-Ranges": "bytes"},
+			wantGets:    1,
+		},
+		"range": {
+			header:      http.Header{"Range": {"bytes=2-4"}},
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    "234",
+			wantHeaders: map[string]string{"Content-Range": "bytes 2-4/10", "Content-Length": "3"},
+			wantGets:    1,
+		},
+		"open-ended range": {
+			header:     http.Header{"Range": {"bytes=7-"}},
+			wantStatus: http.StatusPartialContent,
+			wantBody:   "789",
+			wantGets:   1,
+		},
+		"several ranges send the whole object": {
+			header:     http.Header{"Range": {"bytes=0-1,4-5"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "0123456789",
+			wantGets:   1,
+		},
+		"unsatisfiable range": {
+			header:     http.Header{"Range": {"bytes=20-"}},
+			wantStatus: http.StatusRequestedRangeNotSatisfiable,
+			wantGets:   1,
+		},
+		"range of the same object": {
+			header:     http.Header{"Range": {"bytes=2-4"}, "If-Range": {`"abc"`}},
+			wantStatus: http.StatusPartialContent,
+			wantBody:   "234",
+			wantGets:   1,
+		},
+		"range of a changed object": {
+			header:     http.Header{"Range": {"bytes=2-4"}, "If-Range": {`"old"`}},
+			wantStatus: http.StatusOK,
+			wantBody:   "0123456789",
+			wantGets:   2,
+		},
+		"range of an object unmodified since": {
+			header:     http.Header{"Range": {"bytes=2-4"}, "If-Range": {lastModified}},
+			wantStatus: http.StatusPartialContent,
+			wantBody:   "234",
+			wantGets:   1,
+		},
+		"matching If-None-Match": {
+			header:     http.Header{"If-None-Match": {`"abc"`}},
+			wantStatus: http.StatusNotModified,
+			wantGets:   1,
+		},
+		"not modified since": {
+			header:     http.Header{"If-Modified-Since": {lastModified}},
+			wantStatus: http.StatusNotModified,
+			wantGets:   1,
+		},
+		"modified since": {
+			header:     http.Header{"If-Modified-Since": {modTime.Add(-time.Hour).Format(http.TimeFormat)}},
+			wantStatus: http.StatusOK,
+			wantBody:   "0123456789",
+			wantGets:   1,
+		},
+		"failed If-Match": {
+			header:     http.Header{"If-Match": {`"old"`}},
+			wantStatus: http.StatusPreconditionFailed,
+			wantGets:   1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			obj := &fakeS3Object{content: "0123456789", etag: `"abc"`, modTime: modTime}
+			r := httptest.NewRequest(http.MethodGet, "/api/raw/a.txt", http.NoBody)
+			for k, v := range tc.header {
+				r.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+
+			status, err := serveS3Object(w, r, obj, "bucket", "a.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status == 0 {
+				status = w.Code
+			}
+			if status != tc.wantStatus {
+				t.Errorf("status = %d, want %d", status, tc.wantStatus)
+			}
+			if tc.wantBody != "" && w.Body.String() != tc.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tc.wantBody)
+			}
+			for k, v := range tc.wantHeaders {
+				if got := w.Header().Get(k); got != v {
+					t.Errorf("header %s = %q, want %q", k, got, v)
+				}
+			}
+			if obj.gets != tc.wantGets {
+				t.Errorf("%d GetObject calls, want %d", obj.gets, tc.wantGets)
+			}
+		})
+	}
+}
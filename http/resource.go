@@ -20,7 +20,9 @@ import (
 	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
 	"github.com/futureharmony/storagebrowser/v2/files"
 	"github.com/futureharmony/storagebrowser/v2/fileutils"
+	"github.com/futureharmony/storagebrowser/v2/jobs"
 	"github.com/futureharmony/storagebrowser/v2/minio"
+	"github.com/futureharmony/storagebrowser/v2/rules"
 
 	aferos3 "github.com/futureharmony/afero-aws-s3"
 )
@@ -55,6 +57,7 @@ var resourceGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d
 		Checker:    d,
 		Content:    true,
 		Limit:      limit,
+		Lister:     d.store.Metadata,
 	})
 	if err != nil {
 		return errToStatus(err), err
@@ -98,41 +101,42 @@ var resourceGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d
 })
 
 func resourceDeleteHandler(fileCache FileCache) handleFunc {
-	return withUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		// Get path from query parameter and decode any URL-encoded characters
 		path := decodePath(r.URL.Query().Get("path"))
-		if path == "" || path == "/" || !d.user.Perm.Delete {
-			return http.StatusForbidden, nil
-		}
-
-		file, err := files.NewFileInfo(&files.FileOptions{
-			Fs:         d.requestFs,
-			Path:       path,
-			Modify:     d.user.Perm.Modify,
-			Expand:     false,
-			ReadHeader: d.server.TypeDetectionByHeader,
-			Checker:    d,
-		})
+		file, err := checkDelete(d, path)
 		if err != nil {
 			return errToStatus(err), err
 		}
 
-		err = d.store.Share.DeleteWithPathPrefix(file.Path)
-		if err != nil {
-			log.Printf("WARNING: Error(s) occurred while deleting associated shares with file: %s", err)
-		}
+		if r.URL.Query().Get("background") == "true" {
+			if err = forgetResource(r.Context(), d, fileCache, file); err != nil {
+				return errToStatus(err), err
+			}
 
-		// delete thumbnails
-		err = delThumbs(r.Context(), fileCache, file)
-		if err != nil {
-			return errToStatus(err), err
+			// Walking a large tree is work for the job too.
+			return submitJob(w, d, &jobs.Job{Type: "delete", Path: path},
+				func(ctx context.Context, t *jobs.Tracker) error {
+					bytes, objects, err := diskUsageOf(d.requestFs, path)
+					if err != nil {
+						return err
+					}
+					t.SetTotal(objects, bytes)
+
+					err = d.RunHook(func() error {
+						return fileutils.RemoveAllContext(ctx, d.requestFs, path, t.Add)
+					}, "delete", path, "", d.user)
+
+					// A delete stopped midway leaves what wasn't removed.
+					left, leftObjects, usageErr := diskUsageOf(d.requestFs, path)
+					if usageErr == nil {
+						d.addUsage(left-bytes, leftObjects-objects)
+					}
+					return err
+				})
 		}
 
-		err = d.RunHook(func() error {
-			return d.requestFs.RemoveAll(path)
-		}, "delete", path, "", d.user)
-
-		if err != nil {
+		if err = deleteResource(r.Context(), d, fileCache, file); err != nil {
 			return errToStatus(err), err
 		}
 
@@ -140,15 +144,84 @@ func resourceDeleteHandler(fileCache FileCache) handleFunc {
 	})
 }
 
+// checkDelete checks that the user may delete path, returning its file.
+func checkDelete(d *data, path string) (*files.FileInfo, error) {
+	if path == "" || path == "/" || !d.user.Perm.Delete || !d.CheckOp(rules.OpDelete, path) {
+		return nil, fbErrors.ErrPermissionDenied
+	}
+
+	file, err := files.NewFileInfo(&files.FileOptions{
+		Fs:         d.requestFs,
+		Path:       path,
+		Modify:     d.user.Perm.Modify,
+		Expand:     false,
+		ReadHeader: d.server.TypeDetectionByHeader,
+		Checker:    d,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = d.checkTree(rules.OpDelete, path); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// forgetResource deletes the shares and the thumbnails of a file about to
+// be deleted.
+func forgetResource(ctx context.Context, d *data, fileCache FileCache, file *files.FileInfo) error {
+	err := d.store.Share.DeleteWithPathPrefix(d.scope().Name, bucketPath(d.scope(), file.Path))
+	if err != nil {
+		log.Printf("WARNING: Error(s) occurred while deleting associated shares with file: %s", err)
+	}
+
+	// delete thumbnails
+	return delThumbs(ctx, fileCache, file)
+}
+
+// deleteResource deletes a file or a directory checked by checkDelete,
+// running the hooks.
+func deleteResource(ctx context.Context, d *data, fileCache FileCache, file *files.FileInfo) error {
+	if err := forgetResource(ctx, d, fileCache, file); err != nil {
+		return err
+	}
+
+	bytes, objects, err := diskUsageOf(d.requestFs, file.Path)
+	if err != nil {
+		return err
+	}
+
+	err = d.RunHook(func() error {
+		return d.requestFs.RemoveAll(file.Path)
+	}, "delete", file.Path, "", d.user)
+	if err != nil {
+		return err
+	}
+
+	d.addUsage(-bytes, -objects)
+	return nil
+}
+
 func resourcePostHandler(fileCache FileCache) handleFunc {
-	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	return withUser(resourcePost(fileCache))
+}
+
+func resourcePost(fileCache FileCache) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		// Get path from query parameter and decode any URL-encoded characters
 		path := decodePath(r.URL.Query().Get("path"))
 		if path == "" {
 			return http.StatusBadRequest, nil
 		}
 
-		if !d.user.Perm.Create || !d.Check(path) {
+		policy := d.uploadPolicy()
+		path, err := uploadPath(policy, path)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		if !d.user.Perm.Create || !d.Check(path) || !d.CheckOp(rules.OpCreate, path) {
 			return http.StatusForbidden, nil
 		}
 
@@ -169,6 +242,11 @@ func resourcePostHandler(fileCache FileCache) handleFunc {
 			}
 		}
 
+		body, err := checkUploadBody(w, r, policy, path)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.requestFs,
 			Path:       path,
@@ -177,40 +255,59 @@ func resourcePostHandler(fileCache FileCache) handleFunc {
 			ReadHeader: d.server.TypeDetectionByHeader,
 			Checker:    d,
 		})
+		var oldSize, oldObjects int64
 		if err == nil {
 			if r.URL.Query().Get("override") != "true" {
 				return http.StatusConflict, nil
 			}
 
 			// Permission for overwriting the file
-			if !d.user.Perm.Modify {
+			if !d.user.Perm.Modify || !d.CheckOp(rules.OpModify, path) {
 				return http.StatusForbidden, nil
 			}
 
+			oldSize, oldObjects = file.Size, 1
+		}
+
+		if r.ContentLength < 0 && d.limitsBytes() {
+			return http.StatusLengthRequired, nil
+		}
+		res, err := d.reserveQuota(r.ContentLength-oldSize, 1-oldObjects)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		if oldObjects != 0 {
 			err = delThumbs(r.Context(), fileCache, file)
 			if err != nil {
+				res.settle(0, 0)
 				return errToStatus(err), err
 			}
 		}
 
 		err = d.RunHook(func() error {
-			info, writeErr := writeFile(d.requestFs, path, r.Body, d.settings.FileMode, d.settings.DirMode)
+			info, writeErr := writeFile(d.requestFs, path, body, d.settings.FileMode, d.settings.DirMode)
 			if writeErr != nil {
 				return writeErr
 
 			}
 
-			etag := fmt.Sprintf(`"%x%x"`, info.ModTime().UnixNano(), info.Size())
+			res.settle(info.Size()-oldSize, 1-oldObjects)
+			etag := fileETag(info)
 			w.Header().Set("ETag", etag)
 			return nil
 		}, "upload", path, "", d.user)
 
 		if err != nil {
-			_ = d.requestFs.RemoveAll(path)
+			if removeErr := d.requestFs.RemoveAll(path); removeErr == nil {
+				res.settle(-oldSize, -oldObjects)
+			} else {
+				res.settle(0, 0)
+			}
 		}
 
 		return errToStatus(err), err
-	})
+	}
 }
 
 var resourcePutHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
@@ -220,7 +317,7 @@ var resourcePutHandler = withUser(func(w http.ResponseWriter, r *http.Request, d
 		return http.StatusBadRequest, nil
 	}
 
-	if !d.user.Perm.Modify || !d.Check(path) {
+	if !d.user.Perm.Modify || !d.Check(path) || !d.CheckOp(rules.OpModify, path) {
 		return http.StatusForbidden, nil
 	}
 
@@ -229,75 +326,124 @@ var resourcePutHandler = withUser(func(w http.ResponseWriter, r *http.Request, d
 		return http.StatusMethodNotAllowed, nil
 	}
 
-	exists, err := afero.Exists(d.requestFs, path)
+	old, err := d.requestFs.Stat(path)
+	if os.IsNotExist(err) || errors.Is(err, afero.ErrFileNotFound) {
+		return http.StatusNotFound, nil
+	}
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
-	if !exists {
-		return http.StatusNotFound, nil
+
+	body, err := checkUploadBody(w, r, d.uploadPolicy(), path)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	if r.ContentLength < 0 && d.limitsBytes() {
+		return http.StatusLengthRequired, nil
+	}
+	res, err := d.reserveQuota(r.ContentLength-old.Size(), 0)
+	if err != nil {
+		return errToStatus(err), err
 	}
 
 	err = d.RunHook(func() error {
-		info, writeErr := writeFile(d.requestFs, path, r.Body, d.settings.FileMode, d.settings.DirMode)
+		info, writeErr := writeFile(d.requestFs, path, body, d.settings.FileMode, d.settings.DirMode)
 		if writeErr != nil {
 			return writeErr
 		}
 
-		etag := fmt.Sprintf(`"%x%x"`, info.ModTime().UnixNano(), info.Size())
+		res.settle(info.Size()-old.Size(), 0)
+
+		etag := fileETag(info)
 		w.Header().Set("ETag", etag)
 		return nil
 	}, "save", path, "", d.user)
+	if err != nil {
+		res.settle(0, 0)
+	}
 
 	return errToStatus(err), err
 })
 
 func resourcePatchHandler(fileCache FileCache) handleFunc {
-	return withUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		// Get path from query parameter and decode any URL-encoded characters
 		src := decodePath(r.URL.Query().Get("path"))
 		dst := decodePath(r.URL.Query().Get("destination"))
 		action := r.URL.Query().Get("action")
+		override := r.URL.Query().Get("override") == "true"
+		rename := r.URL.Query().Get("rename") == "true"
 
-		if src == "" {
-			return http.StatusBadRequest, nil
-		}
-
-		if !d.Check(src) || !d.Check(dst) {
-			return http.StatusForbidden, nil
-		}
-		if dst == "/" || src == "/" {
-			return http.StatusForbidden, nil
-		}
-
-		err := checkParent(src, dst)
+		dst, err := checkPatch(d, action, src, dst, override, rename)
 		if err != nil {
-			return http.StatusBadRequest, err
+			return errToStatus(err), err
 		}
 
-		override := r.URL.Query().Get("override") == "true"
-		rename := r.URL.Query().Get("rename") == "true"
-		if !override && !rename {
-			if _, err = d.requestFs.Stat(dst); err == nil {
-				return http.StatusConflict, nil
-			}
+		// Archives are always written in a job, the other actions when asked
+		// to, running the hooks once they are over.
+		if action == "compress" {
+			return submitCompress(w, r, d, src, dst)
 		}
-		if rename {
-			dst = addVersionSuffix(dst, d.requestFs)
-		}
-
-		// Permission for overwriting the file
-		if override && !d.user.Perm.Modify {
-			return http.StatusForbidden, nil
+		if r.URL.Query().Get("background") == "true" {
+			return submitJob(w, d, &jobs.Job{Type: action, Path: src, Destination: dst},
+				func(ctx context.Context, t *jobs.Tracker) error {
+					return d.RunHook(func() error {
+						return patchAction(ctx, action, src, dst, d, fileCache, t)
+					}, action, src, dst, d.user)
+				})
 		}
 
 		err = d.RunHook(func() error {
-			return patchAction(r.Context(), action, src, dst, d, fileCache)
+			return patchAction(r.Context(), action, src, dst, d, fileCache, nil)
 		}, action, src, dst, d.user)
 
 		return errToStatus(err), err
 	})
 }
 
+// checkPatch checks that the user may run a PATCH action from src to dst,
+// returning the destination to write to: a free one when renaming.
+func checkPatch(d *data, action, src, dst string, override, rename bool) (string, error) {
+	if src == "" {
+		return "", fbErrors.ErrInvalidRequestParams
+	}
+
+	if !d.Check(src) || !d.Check(dst) {
+		return "", fbErrors.ErrPermissionDenied
+	}
+	if dst == "/" || src == "/" {
+		return "", fbErrors.ErrPermissionDenied
+	}
+
+	// An archive of a directory may be written inside of it.
+	if action != "compress" {
+		if err := checkParent(src, dst); err != nil {
+			return "", fmt.Errorf("%w: %w", fbErrors.ErrInvalidRequestParams, err)
+		}
+	}
+
+	if !override && !rename {
+		if _, err := d.requestFs.Stat(dst); err == nil {
+			return "", fbErrors.ErrExist
+		}
+	}
+	if rename {
+		dst = addVersionSuffix(dst, d.requestFs)
+	}
+
+	// Permission for overwriting the file
+	if override && (!d.user.Perm.Modify || !d.CheckOp(rules.OpModify, dst)) {
+		return "", fbErrors.ErrPermissionDenied
+	}
+
+	if err := d.checkPatchPolicy(action, src, dst); err != nil {
+		return "", err
+	}
+
+	return dst, nil
+}
+
 func checkParent(src, dst string) error {
 	rel, err := filepath.Rel(src, dst)
 	if err != nil {
@@ -386,21 +532,49 @@ func delThumbs(ctx context.Context, fileCache FileCache, file *files.FileInfo) e
 		}
 	}
 
-	return nil
+	return invalidatePreviews(ctx, fileCache, objectID(file))
 }
 
-func patchAction(ctx context.Context, action, src, dst string, d *data, fileCache FileCache) error {
+// patchAction runs an action of a PATCH request, reporting its progress
+// to t when it runs in a job.
+func patchAction(ctx context.Context, action, src, dst string, d *data, fileCache FileCache, t *jobs.Tracker) error {
 	switch action {
 	case "copy":
-		if !d.user.Perm.Create {
+		if !d.user.Perm.Create || !d.CheckOp(rules.OpCreate, dst) {
 			return fbErrors.ErrPermissionDenied
 		}
 
-		return fileutils.Copy(d.requestFs, src, dst, d.settings.FileMode, d.settings.DirMode)
+		srcBytes, srcObjects, err := diskUsageOf(d.requestFs, src)
+		if err != nil {
+			return err
+		}
+		dstBytes, dstObjects, err := diskUsageOf(d.requestFs, dst)
+		if err != nil {
+			return err
+		}
+		res, err := d.reserveQuota(srcBytes-dstBytes, srcObjects-dstObjects)
+		if err != nil {
+			return err
+		}
+
+		t.SetTotal(srcObjects, srcBytes)
+		err = fileutils.CopyContext(ctx, d.requestFs, src, dst, d.settings.FileMode, d.settings.DirMode, t.Add)
+		if err != nil {
+			// A copy stopped midway leaves what was copied, which counts.
+			if newBytes, newObjects, usageErr := diskUsageOf(d.requestFs, dst); usageErr == nil {
+				res.settle(newBytes-dstBytes, newObjects-dstObjects)
+			}
+			return err
+		}
+
+		return nil
 	case "rename":
-		if !d.user.Perm.Rename {
+		if !d.user.Perm.Rename || !d.CheckOp(rules.OpCreate, dst) {
 			return fbErrors.ErrPermissionDenied
 		}
+		if err := d.checkTree(rules.OpRename, src); err != nil {
+			return err
+		}
 		src = path.Clean("/" + src)
 		dst = path.Clean("/" + dst)
 
@@ -422,7 +596,27 @@ func patchAction(ctx context.Context, action, src, dst string, d *data, fileCach
 			return err
 		}
 
-		return fileutils.MoveFile(d.requestFs, src, dst, d.settings.FileMode, d.settings.DirMode)
+		// An overridden destination is replaced by the source.
+		dstBytes, dstObjects, err := diskUsageOf(d.requestFs, dst)
+		if err != nil {
+			return err
+		}
+
+		err = fileutils.MoveFile(d.requestFs, src, dst, d.settings.FileMode, d.settings.DirMode)
+		if err != nil {
+			return err
+		}
+
+		t.SetTotal(1, 0)
+		t.Add(1, 0)
+		d.addUsage(-dstBytes, -dstObjects)
+		return nil
+	case "extract":
+		if !d.user.Perm.Create || !d.CheckOp(rules.OpCreate, dst) {
+			return fbErrors.ErrPermissionDenied
+		}
+
+		return extractArchive(ctx, d, src, dst, t)
 	default:
 		return fmt.Errorf("unsupported action %s: %w", action, fbErrors.ErrInvalidRequestParams)
 	}
@@ -452,6 +646,19 @@ var diskUsage = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (
 	if err != nil {
 		return errToStatus(err), err
 	}
+
+	// When a quota applies, report it instead of the storage capacity.
+	limit, used, ok, err := d.byteQuota()
+	if err != nil {
+		return errToStatus(err), err
+	}
+	if ok {
+		return renderJSON(w, r, &DiskUsageResponse{
+			Total: limit,
+			Used:  used,
+		})
+	}
+
 	if minio.IsS3FileSystem(d.requestFs) {
 		// For S3, calculate actual disk usage by listing all objects
 		s3Fs := d.requestFs.(*aferos3.FsWrapper)
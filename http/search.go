@@ -3,10 +3,16 @@ package http
 import (
 	"net/http"
 	"os"
+	gopath "path"
+	"strings"
 
 	"github.com/futureharmony/storagebrowser/v2/search"
 )
 
+// contentSearchLimit is the maximum number of results of a search in the
+// contents of the files.
+const contentSearchLimit = 100
+
 var searchHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	response := []map[string]interface{}{}
 	query := r.URL.Query().Get("query")
@@ -17,7 +23,15 @@ var searchHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *dat
 		path = "/"
 	}
 
-	err := search.Search(d.requestFs, path, query, d, func(path string, f os.FileInfo) error {
+	q, err := search.Parse(query)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	if q.ContentQuery() != "" {
+		return contentSearch(w, r, d, path, q)
+	}
+
+	err = search.Search(d.requestFs, d.store.Metadata, path, query, d, func(path string, f os.FileInfo) error {
 		if f == nil {
 			return nil
 		}
@@ -35,3 +49,48 @@ var searchHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *dat
 
 	return renderJSON(w, r, response)
 })
+
+// contentSearch searches the full-text index for the files below path
+// whose content has the words of the content: operators of query, and
+// whose name matches the rest of it.
+func contentSearch(w http.ResponseWriter, r *http.Request, d *data, path string, q *search.Query) (int, error) {
+	if d.store.Content == nil {
+		return http.StatusNotImplemented, nil
+	}
+
+	// The index holds the files of every user: the path must not lead
+	// out of the scope, widening the prefix the hits are filtered with.
+	path = gopath.Clean("/" + path)
+	if !d.Check(path) {
+		return http.StatusForbidden, nil
+	}
+
+	base := strings.TrimSuffix(fsObjectID(d.requestFs, path), "/") + "/"
+	relative := func(id string) string {
+		return strings.TrimPrefix(id, base)
+	}
+
+	hits, err := d.store.Content.Search(q.ContentQuery(), func(id string) bool {
+		if !strings.HasPrefix(id, base) {
+			return false
+		}
+		fPath := gopath.Join(path, relative(id))
+		return d.Check(fPath) && q.Match(fPath, func() (os.FileInfo, error) {
+			return d.requestFs.Stat(fPath)
+		})
+	}, contentSearchLimit)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	response := []map[string]interface{}{}
+	for _, hit := range hits {
+		response = append(response, map[string]interface{}{
+			"dir":     false,
+			"path":    relative(hit.ID),
+			"snippet": hit.Snippet,
+		})
+	}
+
+	return renderJSON(w, r, response)
+}
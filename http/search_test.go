@@ -1,10 +1,18 @@
 package http
 
 import (
+	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/asdine/storm/v3"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/futureharmony/storagebrowser/v2/search"
+	"github.com/futureharmony/storagebrowser/v2/storage/bolt"
 )
 
 func TestSearchHandlerWithScopeAndPath(t *testing.T) {
@@ -51,3 +59,44 @@ func TestSearchHandlerWithScopeAndPath(t *testing.T) {
 		// This matches the pattern in resource.go lines 30-33
 	})
 }
+
+func TestContentSearchTraversal(t *testing.T) {
+	t.Parallel()
+
+	db, err := storm.Open(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	index, err := bolt.NewContentIndex(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, content := range map[string]string{
+		"/home/alice/notes.txt":     "the password is in the safe",
+		"/home/alicebob/secret.txt": "the password is hunter2",
+	} {
+		if err = index.Add(id, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := newArchiveData(t)
+	d.store.Content = index
+	d.requestFs = afero.NewBasePathFs(afero.NewMemMapFs(), "/home/alice")
+	q, err := search.Parse("content:password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/", "/../alicebob", "/.."} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+		if _, err = contentSearch(w, r, d, path, q); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(w.Body.String(), "secret.txt") {
+			t.Errorf("searching %q found the file of another user: %s", path, w.Body.String())
+		}
+	}
+}
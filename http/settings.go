@@ -6,6 +6,7 @@ import (
 
 	"github.com/futureharmony/storagebrowser/v2/rules"
 	"github.com/futureharmony/storagebrowser/v2/settings"
+	"github.com/futureharmony/storagebrowser/v2/upload"
 )
 
 type settingsData struct {
@@ -17,8 +18,11 @@ type settingsData struct {
 	Rules                 []rules.Rule          `json:"rules"`
 	Branding              settings.Branding     `json:"branding"`
 	Tus                   settings.Tus          `json:"tus"`
+	Lockout               settings.Lockout      `json:"lockout"`
+	Upload                upload.Policy         `json:"upload"`
 	Shell                 []string              `json:"shell"`
 	Commands              map[string][]string   `json:"commands"`
+	Webhooks              settings.Webhooks     `json:"webhooks"`
 }
 
 var settingsGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
@@ -31,8 +35,11 @@ var settingsGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request,
 		Rules:                 d.settings.Rules,
 		Branding:              d.settings.Branding,
 		Tus:                   d.settings.Tus,
+		Lockout:               d.settings.Lockout,
+		Upload:                d.settings.Upload,
 		Shell:                 d.settings.Shell,
 		Commands:              d.settings.Commands,
+		Webhooks:              d.settings.Webhooks,
 	}
 
 	return renderJSON(w, r, data)
@@ -45,6 +52,12 @@ var settingsPutHandler = withAdmin(func(_ http.ResponseWriter, r *http.Request,
 		return http.StatusBadRequest, err
 	}
 
+	for i := range req.Rules {
+		if err = req.Rules[i].Validate(); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+
 	d.settings.Signup = req.Signup
 	d.settings.CreateUserDir = req.CreateUserDir
 	d.settings.MinimumPasswordLength = req.MinimumPasswordLength
@@ -53,8 +66,12 @@ var settingsPutHandler = withAdmin(func(_ http.ResponseWriter, r *http.Request,
 	d.settings.Rules = req.Rules
 	d.settings.Branding = req.Branding
 	d.settings.Tus = req.Tus
+	d.settings.Lockout = req.Lockout
+	d.settings.Upload = req.Upload
+	d.settings.Upload.Clean()
 	d.settings.Shell = req.Shell
 	d.settings.Commands = req.Commands
+	d.settings.Webhooks = req.Webhooks
 
 	err = d.store.Settings.Save(d.settings)
 	return errToStatus(err), err
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,8 +15,12 @@ import (
 
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/futureharmony/storagebrowser/v2/audit"
 	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/rules"
 	"github.com/futureharmony/storagebrowser/v2/share"
+	"github.com/futureharmony/storagebrowser/v2/users"
+	"github.com/futureharmony/storagebrowser/v2/webhook"
 )
 
 func withPermShare(fn handleFunc) handleFunc {
@@ -28,6 +33,16 @@ func withPermShare(fn handleFunc) handleFunc {
 	})
 }
 
+// shareAccessLogLimit is the number of the latest accesses of each link
+// listed by /api/shares.
+const shareAccessLogLimit = 100
+
+// shareListItem is a link along with its latest accesses.
+type shareListItem struct {
+	*share.Link
+	Accesses []*share.Access `json:"accesses"`
+}
+
 var shareListHandler = withPermShare(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	var (
 		s   []*share.Link
@@ -39,7 +54,7 @@ var shareListHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 		s, err = d.store.Share.FindByUserID(d.user.ID)
 	}
 	if errors.Is(err, fbErrors.ErrNotExist) {
-		return renderJSON(w, r, []*share.Link{})
+		return renderJSON(w, r, []*shareListItem{})
 	}
 
 	if err != nil {
@@ -53,7 +68,16 @@ var shareListHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 		return s[i].Expire < s[j].Expire
 	})
 
-	return renderJSON(w, r, s)
+	items := make([]*shareListItem, 0, len(s))
+	for _, l := range s {
+		accesses, err := d.store.Share.Accesses(l.Hash, shareAccessLogLimit)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		items = append(items, &shareListItem{Link: l, Accesses: accesses})
+	}
+
+	return renderJSON(w, r, items)
 })
 
 var shareGetsHandler = withPermShare(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
@@ -66,7 +90,16 @@ var shareGetsHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 		return http.StatusInternalServerError, err
 	}
 
-	return renderJSON(w, r, s)
+	// The same path may be shared in several scopes.
+	scope := d.scope()
+	links := []*share.Link{}
+	for _, l := range s {
+		if l.Bucket == scope.Name && l.RootPrefix == scope.RootPrefix {
+			links = append(links, l)
+		}
+	}
+
+	return renderJSON(w, r, links)
 })
 
 var shareDeleteHandler = withPermShare(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
@@ -82,6 +115,10 @@ var shareDeleteHandler = withPermShare(func(_ http.ResponseWriter, r *http.Reque
 })
 
 var sharePostHandler = withPermShare(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.CheckOp(rules.OpShare, r.URL.Path) {
+		return http.StatusForbidden, nil
+	}
+
 	var s *share.Link
 	var body share.CreateBody
 	if r.Body != nil {
@@ -99,28 +136,19 @@ var sharePostHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 
 	str := base64.URLEncoding.EncodeToString(bytes)
 
-	var expire int64 = 0
-
-	if body.Expires != "" {
-		//nolint:govet
-		num, err := strconv.Atoi(body.Expires)
-		if err != nil {
-			return http.StatusInternalServerError, err
-		}
+	expire, err := shareExpire(body.Expires, body.Unit)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
 
-		var add time.Duration
-		switch body.Unit {
-		case "seconds":
-			add = time.Second * time.Duration(num)
-		case "minutes":
-			add = time.Minute * time.Duration(num)
-		case "days":
-			add = time.Hour * 24 * time.Duration(num)
-		default:
-			add = time.Hour * time.Duration(num)
+	if body.MaxDownloads < 0 {
+		return http.StatusBadRequest, fmt.Errorf("%w: negative download limit", fbErrors.ErrInvalidRequestParams)
+	}
+	if body.Upload {
+		status, err := checkFileDrop(d, r.URL.Path, body)
+		if status != 0 || err != nil {
+			return status, err
 		}
-
-		expire = time.Now().Add(add).Unix()
 	}
 
 	hash, status, err := getSharePasswordHash(body)
@@ -130,11 +158,9 @@ var sharePostHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 
 	var token string
 	if len(hash) > 0 {
-		tokenBuffer := make([]byte, 96)
-		if _, err := rand.Read(tokenBuffer); err != nil {
+		if token, err = newShareToken(); err != nil {
 			return http.StatusInternalServerError, err
 		}
-		token = base64.URLEncoding.EncodeToString(tokenBuffer)
 	}
 
 	s = &share.Link{
@@ -142,17 +168,137 @@ var sharePostHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 		Hash:         str,
 		Expire:       expire,
 		UserID:       d.user.ID,
+		Description:  body.Description,
+		Bucket:       d.scope().Name,
+		RootPrefix:   d.scope().RootPrefix,
 		PasswordHash: string(hash),
 		Token:        token,
+		MaxDownloads: body.MaxDownloads,
+	}
+	if body.Upload {
+		s.Upload = true
+		s.HideListing = body.HideListing
+		s.MaxUploadSize = body.MaxUploadSize
+		s.MaxUploads = body.MaxUploads
 	}
 
-	if err := d.store.Share.Save(s); err != nil {
+	err = d.store.Share.Save(s)
+	d.audit(audit.ActionShare, s.Path, "", 0, err)
+	if err != nil {
 		return http.StatusInternalServerError, err
 	}
 
 	return renderJSON(w, r, s)
 })
 
+// sharePutHandler edits the expiry, password and description of a link
+// of the user, or of anyone's for admins.
+var sharePutHandler = withPermShare(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	hash := strings.Trim(r.URL.Path, "/")
+	if hash == "" {
+		return http.StatusBadRequest, nil
+	}
+	if r.Body == nil {
+		return http.StatusBadRequest, fbErrors.ErrEmptyRequest
+	}
+
+	var body share.UpdateBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return http.StatusBadRequest, fmt.Errorf("failed to decode body: %w", err)
+	}
+	defer r.Body.Close()
+
+	// The changes are worked out first, the link being edited under the
+	// lock of its counters.
+	var (
+		expire       int64
+		passwordHash []byte
+		token        string
+		err          error
+	)
+	if body.Expires != nil {
+		if expire, err = shareExpire(*body.Expires, body.Unit); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	if body.Password != nil {
+		var status int
+		passwordHash, status, err = getSharePasswordHash(share.CreateBody{Password: *body.Password})
+		if err != nil {
+			return status, err
+		}
+		if len(passwordHash) > 0 {
+			if token, err = newShareToken(); err != nil {
+				return http.StatusInternalServerError, err
+			}
+		}
+	}
+
+	link, err := d.store.Share.Update(hash, func(l *share.Link) error {
+		if l.UserID != d.user.ID && !d.user.Perm.Admin {
+			return fbErrors.ErrPermissionDenied
+		}
+
+		if body.Expires != nil {
+			l.Expire = expire
+		}
+		if body.Password != nil {
+			l.PasswordHash, l.Token = string(passwordHash), token
+		}
+		if body.Description != nil {
+			l.Description = *body.Description
+		}
+		return nil
+	})
+	// The link is only returned along an error when it couldn't be saved.
+	if link == nil {
+		return errToStatus(err), err
+	}
+	d.audit(audit.ActionShare, link.Path, "", 0, err)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return renderJSON(w, r, link)
+})
+
+// shareExpire returns the expiry of a link lasting expires units from
+// now, or zero for a permanent link when expires is empty.
+func shareExpire(expires, unit string) (int64, error) {
+	if expires == "" {
+		return 0, nil
+	}
+
+	num, err := strconv.Atoi(expires)
+	if err != nil {
+		return 0, err
+	}
+
+	var add time.Duration
+	switch unit {
+	case "seconds":
+		add = time.Second * time.Duration(num)
+	case "minutes":
+		add = time.Minute * time.Duration(num)
+	case "days":
+		add = time.Hour * 24 * time.Duration(num)
+	default:
+		add = time.Hour * time.Duration(num)
+	}
+
+	return time.Now().Add(add).Unix(), nil
+}
+
+// newShareToken returns a token to download from a password-protected
+// link.
+func newShareToken() (string, error) {
+	tokenBuffer := make([]byte, 96)
+	if _, err := rand.Read(tokenBuffer); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(tokenBuffer), nil
+}
+
 func getSharePasswordHash(body share.CreateBody) (data []byte, statuscode int, err error) {
 	if body.Password == "" {
 		return nil, 0, nil
@@ -165,3 +311,45 @@ func getSharePasswordHash(body share.CreateBody) (data []byte, statuscode int, e
 
 	return hash, 0, nil
 }
+
+// checkFileDrop checks a file drop can be created on a path.
+func checkFileDrop(d *data, p string, body share.CreateBody) (int, error) {
+	if body.MaxUploadSize < 0 || body.MaxUploads < 0 {
+		return http.StatusBadRequest, fmt.Errorf("%w: negative upload limit", fbErrors.ErrInvalidRequestParams)
+	}
+
+	// Files are dropped on behalf of the owner of the link.
+	if !d.user.Perm.Create || !d.CheckOp(rules.OpCreate, p) {
+		return http.StatusForbidden, nil
+	}
+
+	info, err := d.requestFs.Stat(p)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	if !info.IsDir() {
+		return http.StatusBadRequest, fmt.Errorf("%w: files are only dropped into directories", fbErrors.ErrInvalidRequestParams)
+	}
+
+	return 0, nil
+}
+
+// notifyShareRevoked records that a link reached its download limit and
+// notifies the webhooks of the share_revoked event.
+func (d *data) notifyShareRevoked(link *share.Link) {
+	d.audit(audit.ActionRevoke, link.Path, "", 0, nil)
+
+	dispatcher := webhook.NewDispatcher(d.store.Webhooks)
+	dispatcher.Notify(d.settings.Webhooks, dispatcher.NewPayload("share_revoked", d.user.Username, link.Bucket, link.Path, ""))
+}
+
+// bucketPath returns the path of a file of a scope from the root of its
+// bucket.
+func bucketPath(scope users.Scope, p string) string {
+	return path.Join("/", scope.RootPrefix, p)
+}
+
+// linkScope returns the scope a link was created in.
+func linkScope(l *share.Link) users.Scope {
+	return users.Scope{Name: l.Bucket, RootPrefix: l.RootPrefix}
+}
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"bufio"
+	"crypto/subtle"
 	"context"
 	"errors"
 	"fmt"
@@ -19,6 +21,8 @@ import (
 
 	"github.com/futureharmony/storagebrowser/v2/files"
 	"github.com/futureharmony/storagebrowser/v2/minio"
+	"github.com/futureharmony/storagebrowser/v2/rules"
+	"github.com/futureharmony/storagebrowser/v2/upload"
 )
 
 const maxUploadWait = 3 * time.Minute
@@ -28,6 +32,12 @@ type UploadState struct {
 	UploadLength int64
 	UploadID     string                  // For S3 multipart uploads
 	Parts        []aferos3.CompletedPart // For S3 multipart uploads
+	// Owner is who created the upload, as told by uploadOwner: only they
+	// may continue or cancel it.
+	Owner string
+	// Release gives back the quota reserved for the upload once it's
+	// abandoned.
+	Release func()
 }
 
 // Tracks active uploads along with their respective upload lengths
@@ -38,6 +48,9 @@ func initActiveUploads() *ttlcache.Cache[string, *UploadState] {
 	cache.OnEviction(func(_ context.Context, reason ttlcache.EvictionReason, item *ttlcache.Item[string, *UploadState]) {
 		if reason == ttlcache.EvictionReasonExpired {
 			state := item.Value()
+			if state != nil && state.Release != nil {
+				state.Release()
+			}
 			if state != nil && state.UploadID != "" {
 				// For S3, abort the multipart upload
 				fmt.Printf("aborting incomplete S3 multipart upload: \"%s\" (uploadID: %s)", item.Key(), state.UploadID)
@@ -54,10 +67,12 @@ func initActiveUploads() *ttlcache.Cache[string, *UploadState] {
 	return cache
 }
 
-func registerUpload(filePath string, fileSize int64) {
+func registerUpload(filePath string, fileSize int64, owner string, release func()) {
 	state := &UploadState{
 		UploadLength: fileSize,
 		Parts:        make([]aferos3.CompletedPart, 0),
+		Owner:        owner,
+		Release:      release,
 	}
 	activeUploads.Set(filePath, state, maxUploadWait)
 }
@@ -66,13 +81,13 @@ func completeUpload(filePath string) {
 	activeUploads.Delete(filePath)
 }
 
-func getActiveUploadLength(filePath string) (int64, error) {
-	item := activeUploads.Get(filePath)
-	if item == nil {
-		return 0, fmt.Errorf("no active upload found for the given path")
+// abandonUpload forgets an upload whose file was removed, giving back the
+// quota reserved for it.
+func abandonUpload(filePath string, state *UploadState) {
+	completeUpload(filePath)
+	if state.Release != nil {
+		state.Release()
 	}
-
-	return item.Value().UploadLength, nil
 }
 
 func getUploadState(filePath string) (*UploadState, error) {
@@ -84,6 +99,30 @@ func getUploadState(filePath string) (*UploadState, error) {
 	return item.Value(), nil
 }
 
+// uploadOwner tells who an upload of a request belongs to. Users share
+// the uploads of their scope, while the visitors of a file drop only reach
+// the uploads they created, by the token of their location.
+func uploadOwner(r *http.Request, d *data) string {
+	if d.share == nil {
+		return ""
+	}
+	return d.share.Hash + "/" + r.URL.Query().Get("upload")
+}
+
+// getOwnUpload returns the state of the upload of a file, when it belongs
+// to the requester.
+func getOwnUpload(r *http.Request, d *data, filePath string) (*UploadState, error) {
+	state, err := getUploadState(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(state.Owner), []byte(uploadOwner(r, d))) != 1 {
+		return nil, fmt.Errorf("no active upload found for the given path")
+	}
+
+	return state, nil
+}
+
 func updateUploadState(filePath string, state *UploadState) {
 	activeUploads.Set(filePath, state, maxUploadWait)
 }
@@ -111,16 +150,31 @@ func keepUploadActive(filePath string) func() {
 }
 
 func tusPostHandler() handleFunc {
-	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	return withUser(tusPost(func(*data) string { return "/api/tus" }))
+}
+
+// tusPost creates the uploads of a tus endpoint, which the location of
+// each upload is built from.
+func tusPost(endpoint func(d *data) string) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		// Get path from query parameter and decode any URL-encoded characters
 		path := decodePath(r.URL.Query().Get("path"))
 		if path == "" {
 			return http.StatusBadRequest, nil
 		}
 
-		if !d.user.Perm.Create || !d.Check(path) {
+		policy := d.uploadPolicy()
+		path, err := uploadPath(policy, path)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		if !d.user.Perm.Create || !d.Check(path) || !d.CheckOp(rules.OpCreate, path) {
 			return http.StatusForbidden, nil
 		}
+		if err = policy.CheckName(path); err != nil {
+			return errToStatus(err), err
+		}
 		file, err := files.NewFileInfo(&files.FileOptions{
 			Fs:         d.requestFs,
 			Path:       path,
@@ -148,7 +202,16 @@ func tusPostHandler() handleFunc {
 
 		fileFlags := os.O_CREATE | os.O_WRONLY
 
+		uploadLength, err := getUploadLength(r)
+		if err != nil {
+			return http.StatusBadRequest, fmt.Errorf("invalid upload length: %w", err)
+		}
+		if err = policy.CheckSize(uploadLength); err != nil {
+			return errToStatus(err), err
+		}
+
 		// if file exists
+		var oldSize, oldObjects int64
 		if file != nil {
 			if file.IsDir {
 				return http.StatusBadRequest, fmt.Errorf("cannot upload to a directory %s", file.RealPath())
@@ -160,15 +223,24 @@ func tusPostHandler() handleFunc {
 			}
 
 			// Permission for overwriting the file
-			if !d.user.Perm.Modify {
+			if !d.user.Perm.Modify || !d.CheckOp(rules.OpModify, path) {
 				return http.StatusForbidden, nil
 			}
 
 			fileFlags |= os.O_TRUNC
+			oldSize, oldObjects = file.Size, 1
+		}
+
+		// The upload is accounted for from now on, and given back if it's
+		// abandoned.
+		res, err := d.reserveQuota(uploadLength-oldSize, 1-oldObjects)
+		if err != nil {
+			return errToStatus(err), err
 		}
 
 		openFile, err := d.requestFs.OpenFile(path, fileFlags, d.settings.FileMode)
 		if err != nil {
+			res.settle(0, 0)
 			return errToStatus(err), err
 		}
 		defer openFile.Close()
@@ -210,13 +282,21 @@ func tusPostHandler() handleFunc {
 			}
 		}
 
-		uploadLength, err := getUploadLength(r)
-		if err != nil {
-			return http.StatusBadRequest, fmt.Errorf("invalid upload length: %w", err)
+		// The visitors of a file drop are given a token to reach their
+		// upload with.
+		locationQuery := url.Values{}
+		owner := ""
+		if d.share != nil {
+			token, tokenErr := newShareToken()
+			if tokenErr != nil {
+				return http.StatusInternalServerError, tokenErr
+			}
+			locationQuery.Set("upload", token)
+			owner = d.share.Hash + "/" + token
 		}
 
 		// Enables the user to utilize the PATCH endpoint for uploading file data
-		registerUpload(file.RealPath(), uploadLength)
+		registerUpload(file.RealPath(), uploadLength, owner, func() { d.addUsage(-uploadLength, -1) })
 
 		// Check if it's an S3 filesystem to handle it differently
 		if s3wrapper, ok := d.requestFs.(*aferos3.FsWrapper); ok {
@@ -235,13 +315,12 @@ func tusPostHandler() handleFunc {
 		}
 
 		// Set Location header with path and scope as query parameters for tus protocol
-		locationPath, err := url.JoinPath("/", d.server.BaseURL, "/api/tus")
+		locationPath, err := url.JoinPath("/", d.server.BaseURL, endpoint(d))
 		if err != nil {
 			return http.StatusBadRequest, fmt.Errorf("invalid path: %w", err)
 		}
 
 		// Add path and scope as query parameters
-		locationQuery := url.Values{}
 		locationQuery.Set("path", path)
 		if scopeParam := r.URL.Query().Get("scope"); scopeParam != "" {
 			locationQuery.Set("scope", scopeParam)
@@ -250,11 +329,15 @@ func tusPostHandler() handleFunc {
 
 		w.Header().Set("Location", locationPath)
 		return http.StatusCreated, nil
-	})
+	}
 }
 
 func tusHeadHandler() handleFunc {
-	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	return withUser(tusHead())
+}
+
+func tusHead() handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		w.Header().Set("Cache-Control", "no-store")
 
 		// Get path from query parameter and decode any URL-encoded characters
@@ -263,7 +346,7 @@ func tusHeadHandler() handleFunc {
 			return http.StatusBadRequest, nil
 		}
 
-		if !d.user.Perm.Create || !d.Check(path) {
+		if !d.user.Perm.Create || !d.Check(path) || !d.CheckOp(rules.OpCreate, path) {
 			return http.StatusForbidden, nil
 		}
 
@@ -279,7 +362,7 @@ func tusHeadHandler() handleFunc {
 			return errToStatus(err), err
 		}
 
-		uploadLength, err := getActiveUploadLength(file.RealPath())
+		state, err := getOwnUpload(r, d, file.RealPath())
 		if err != nil {
 			return http.StatusNotFound, err
 		}
@@ -287,31 +370,32 @@ func tusHeadHandler() handleFunc {
 		// Check if S3
 		offset := file.Size
 		if minio.IsS3FileSystem(d.requestFs) {
-			state, err := getUploadState(file.RealPath())
-			if err == nil && state != nil {
-				offset = 0
-				for _, part := range state.Parts {
-					offset += part.Size
-				}
+			offset = 0
+			for _, part := range state.Parts {
+				offset += part.Size
 			}
 		}
 
 		w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
-		w.Header().Set("Upload-Length", strconv.FormatInt(uploadLength, 10))
+		w.Header().Set("Upload-Length", strconv.FormatInt(state.UploadLength, 10))
 
 		return http.StatusOK, nil
-	})
+	}
 }
 
 func tusPatchHandler() handleFunc {
-	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	return withUser(tusPatch())
+}
+
+func tusPatch() handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		// Get path from query parameter and decode any URL-encoded characters
 		path := decodePath(r.URL.Query().Get("path"))
 		if path == "" {
 			return http.StatusBadRequest, nil
 		}
 
-		if !d.user.Perm.Create || !d.Check(path) {
+		if !d.user.Perm.Create || !d.Check(path) || !d.CheckOp(rules.OpCreate, path) {
 			return http.StatusForbidden, nil
 		}
 		if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
@@ -339,10 +423,32 @@ func tusPatchHandler() handleFunc {
 			return errToStatus(err), err
 		}
 
-		uploadLength, err := getActiveUploadLength(file.RealPath())
+		state, err := getOwnUpload(r, d, file.RealPath())
 		if err != nil {
 			return http.StatusNotFound, err
 		}
+		uploadLength := state.UploadLength
+
+		// The quota and the usage trust the declared length: no more is
+		// written.
+		policy := d.uploadPolicy()
+		var body io.Reader = http.MaxBytesReader(w, r.Body, uploadLength-uploadOffset)
+
+		// The type is sniffed from the first chunk; a rejected upload is
+		// cancelled.
+		if uploadOffset == 0 && policy.SniffsContent() {
+			buf := bufio.NewReaderSize(body, upload.SniffLen)
+			header, peekErr := buf.Peek(upload.SniffLen)
+			if peekErr != nil && !errors.Is(peekErr, io.EOF) && !errors.Is(peekErr, bufio.ErrBufferFull) {
+				return http.StatusBadRequest, peekErr
+			}
+			if err = policy.CheckContent(path, header); err != nil {
+				_ = d.requestFs.RemoveAll(path)
+				abandonUpload(file.RealPath(), state)
+				return errToStatus(err), err
+			}
+			body = buf
+		}
 
 		// Prevent the upload from being evicted during the transfer
 		stop := keepUploadActive(file.RealPath())
@@ -355,8 +461,7 @@ func tusPatchHandler() handleFunc {
 		// Check if it's an S3 filesystem to handle it differently
 		if s3wrapper, ok := d.requestFs.(*aferos3.FsWrapper); ok {
 			// Handle S3 multipart upload
-			state, err := getUploadState(file.RealPath())
-			if err != nil || state == nil || state.UploadID == "" {
+			if state.UploadID == "" {
 				return http.StatusNotFound, fmt.Errorf("no active S3 multipart upload found")
 			}
 
@@ -377,9 +482,9 @@ func tusPatchHandler() handleFunc {
 			}
 
 			// Read the request body
-			bodyBytes, readErr := io.ReadAll(r.Body)
+			bodyBytes, readErr := io.ReadAll(body)
 			if readErr != nil {
-				return http.StatusInternalServerError, fmt.Errorf("could not read request body: %w", readErr)
+				return errToStatus(readErr), fmt.Errorf("could not read request body: %w", readErr)
 			}
 
 			// Upload part
@@ -431,9 +536,9 @@ func tusPatchHandler() handleFunc {
 		defer openFile.Close()
 
 		defer r.Body.Close()
-		bytesWritten, err := io.Copy(openFile, r.Body)
+		bytesWritten, err := io.Copy(openFile, body)
 		if err != nil {
-			return http.StatusInternalServerError, fmt.Errorf("could not write to file: %w", err)
+			return errToStatus(err), fmt.Errorf("could not write to file: %w", err)
 		}
 
 		newOffset := uploadOffset + bytesWritten
@@ -445,11 +550,15 @@ func tusPatchHandler() handleFunc {
 		}
 
 		return http.StatusNoContent, nil
-	})
+	}
 }
 
 func tusDeleteHandler() handleFunc {
-	return withUser(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	return withUser(tusDelete())
+}
+
+func tusDelete() handleFunc {
+	return func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		// Get path from query parameter and decode any URL-encoded characters
 		path := decodePath(r.URL.Query().Get("path"))
 		if path == "" || path == "/" || !d.user.Perm.Create {
@@ -468,7 +577,7 @@ func tusDeleteHandler() handleFunc {
 			return errToStatus(err), err
 		}
 
-		_, err = getActiveUploadLength(file.RealPath())
+		state, err := getOwnUpload(r, d, file.RealPath())
 		if err != nil {
 			return http.StatusNotFound, err
 		}
@@ -478,10 +587,10 @@ func tusDeleteHandler() handleFunc {
 			return errToStatus(err), err
 		}
 
-		completeUpload(file.RealPath())
+		abandonUpload(file.RealPath(), state)
 
 		return http.StatusNoContent, nil
-	})
+	}
 }
 
 func getUploadLength(r *http.Request) (int64, error) {
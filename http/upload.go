@@ -0,0 +1,104 @@
+package http
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/spf13/afero"
+
+	"github.com/futureharmony/storagebrowser/v2/upload"
+)
+
+// uploadPolicy returns the upload policy of the request: the scope's,
+// then the user's and finally the global one.
+func (d *data) uploadPolicy() *upload.Policy {
+	if p := d.user.ScopeUploadPolicy(d.scope().Name); p != nil {
+		return p
+	}
+	return &d.settings.Upload
+}
+
+// uploadPath returns the path a file is uploaded to, with its name
+// normalized if the policy requires it.
+func uploadPath(policy *upload.Policy, p string) (string, error) {
+	if !policy.NormalizeNames {
+		return p, nil
+	}
+	return upload.NormalizePath(p)
+}
+
+// checkUploadBody enforces the upload policy on the body of a plain upload
+// and returns the reader the content must be read from.
+func checkUploadBody(w http.ResponseWriter, r *http.Request, policy *upload.Policy, name string) (io.Reader, error) {
+	if err := policy.CheckName(name); err != nil {
+		return nil, err
+	}
+	if err := policy.CheckSize(r.ContentLength); err != nil {
+		return nil, err
+	}
+
+	var body io.Reader = r.Body
+	if policy.MaxSize != 0 {
+		body = http.MaxBytesReader(w, r.Body, int64(policy.MaxSize)) //nolint:gosec
+	}
+
+	if !policy.SniffsContent() {
+		return body, nil
+	}
+
+	buf := bufio.NewReaderSize(body, upload.SniffLen)
+	header, err := buf.Peek(upload.SniffLen)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	if err := policy.CheckContent(name, header); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// checkPatchPolicy enforces the upload policy on the files a PATCH action
+// writes: their names, and their sizes when copied. The files of a renamed
+// directory keep their names, and the entries of an archive are checked
+// as it is extracted.
+func (d *data) checkPatchPolicy(action, src, dst string) error {
+	policy := d.uploadPolicy()
+	if action == "compress" {
+		return policy.CheckName(dst)
+	}
+
+	info, err := d.requestFs.Stat(src)
+	if err != nil {
+		// The action fails on its own on a missing source.
+		return nil
+	}
+
+	switch {
+	case action != "copy" && action != "rename":
+		return nil
+	case !info.IsDir():
+		if err := policy.CheckName(dst); err != nil {
+			return err
+		}
+		if action == "copy" {
+			return policy.CheckSize(info.Size())
+		}
+		return nil
+	case action == "copy":
+		return afero.Walk(d.requestFs, src, func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			if err := policy.CheckName(p); err != nil {
+				return err
+			}
+			return policy.CheckSize(info.Size())
+		})
+	default:
+		return nil
+	}
+}
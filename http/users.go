@@ -13,11 +13,12 @@ import (
 	"golang.org/x/text/language"
 
 	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/lockout"
 	"github.com/futureharmony/storagebrowser/v2/users"
 )
 
 var (
-	NonModifiableFieldsForNonAdmin = []string{"Username", "Bucket", "Scope", "LockPassword", "Perm", "Commands", "Rules"}
+	NonModifiableFieldsForNonAdmin = []string{"Username", "Bucket", "Scope", "LockPassword", "Perm", "Quota", "UploadPolicy", "AvailableScopes", "Groups", "Commands", "Rules"}
 )
 
 type modifyUserRequest struct {
@@ -256,3 +257,45 @@ var userPutHandler = withSelfOrAdmin(func(w http.ResponseWriter, r *http.Request
 
 	return http.StatusOK, nil
 })
+
+var userLockoutGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	id, err := getUserID(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	u, err := d.store.Users.Get(d.server.Root, id)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	key := lockout.UserKey(u.Username)
+	attempt, err := d.store.Lockout.Get(key)
+	if errors.Is(err, fbErrors.ErrNotExist) {
+		return renderJSON(w, r, &lockout.Attempt{Key: key})
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return renderJSON(w, r, attempt)
+})
+
+var userLockoutDeleteHandler = withAdmin(func(_ http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	id, err := getUserID(r)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	u, err := d.store.Users.Get(d.server.Root, id)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	err = d.store.Lockout.Reset(lockout.UserKey(u.Username))
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return http.StatusNoContent, nil
+})
@@ -40,8 +40,14 @@ func errToStatus(err error) int {
 		return http.StatusForbidden
 	case errors.Is(err, libErrors.ErrInvalidRequestParams):
 		return http.StatusBadRequest
-	case errors.Is(err, libErrors.ErrRootUserDeletion):
+	case errors.Is(err, libErrors.ErrRootUserDeletion), errors.Is(err, libErrors.ErrUploadLimit):
 		return http.StatusForbidden
+	case errors.Is(err, libErrors.ErrQuotaExceeded):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, libErrors.ErrUploadTooLarge), errors.As(err, new(*http.MaxBytesError)):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, libErrors.ErrUploadNotAllowed):
+		return http.StatusUnsupportedMediaType
 	default:
 		return http.StatusInternalServerError
 	}
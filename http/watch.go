@@ -0,0 +1,210 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+)
+
+// watchBufferSize is the number of changes kept for a slow client before
+// new ones are dropped.
+const watchBufferSize = 64
+
+// watchRequest is sent by clients to set the directories they view.
+type watchRequest struct {
+	Watch []string `json:"watch"`
+}
+
+// dirChange is sent to clients when an entry of a directory they view
+// changes.
+type dirChange struct {
+	Type        string    `json:"type"`
+	Path        string    `json:"path"`
+	Destination string    `json:"destination,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	ETag        string    `json:"etag,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// Types of directory changes.
+const (
+	changeCreate = "create"
+	changeModify = "modify"
+	changeDelete = "delete"
+	changeRename = "rename"
+)
+
+// watcher holds the directories a client views.
+type watcher struct {
+	d    *data
+	mux  sync.RWMutex
+	dirs map[string]bool
+}
+
+func (wt *watcher) watch(dirs []string) {
+	set := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		set[path.Clean("/"+dir)] = true
+	}
+
+	wt.mux.Lock()
+	wt.dirs = set
+	wt.mux.Unlock()
+}
+
+func (wt *watcher) watches(p string) bool {
+	wt.mux.RLock()
+	defer wt.mux.RUnlock()
+	return wt.dirs[path.Dir(p)]
+}
+
+// localPath returns the path, relative to the scope of the client, of a
+// path of the event, or false when the client can't see it.
+func (wt *watcher) localPath(e *events.Event, p string) (string, bool) {
+	if p == "" {
+		return "", false
+	}
+
+	scope := wt.d.scope()
+	if e.Scope.Name == "" || scope.Name == "" {
+		// Without buckets, paths are relative to the home of the user
+		// that made the change.
+		if e.User == nil || e.User.Scope != wt.d.user.Scope || e.Scope.Name != scope.Name {
+			return "", false
+		}
+	} else {
+		if e.Scope.Name != scope.Name {
+			return "", false
+		}
+
+		root := path.Clean("/" + scope.RootPrefix)
+		full := path.Join("/", e.Scope.RootPrefix, p)
+		if root != "/" {
+			if !strings.HasPrefix(full, root+"/") {
+				return "", false
+			}
+			full = strings.TrimPrefix(full, root)
+		}
+		p = full
+	}
+
+	p = path.Clean("/" + p)
+	if !wt.d.Check(p) {
+		return "", false
+	}
+	return p, true
+}
+
+// change returns the change a client must be told of for an event.
+func (wt *watcher) change(e *events.Event) (*dirChange, bool) {
+	if e.Phase != events.After || e.Err != nil {
+		return nil, false
+	}
+
+	src, srcOk := wt.localPath(e, e.Path)
+	srcOk = srcOk && wt.watches(src)
+
+	c := &dirChange{Path: src, Size: e.Size, ETag: e.ETag, Time: e.Time}
+	switch e.Type {
+	case events.Upload:
+		c.Type = changeCreate
+	case events.Save:
+		c.Type = changeModify
+	case events.Delete:
+		c.Type = changeDelete
+	case events.Copy, events.Rename, events.Compress:
+		dst, dstOk := wt.localPath(e, e.Destination)
+		dstOk = dstOk && wt.watches(dst)
+
+		switch {
+		case e.Type == events.Rename && srcOk && dstOk:
+			c.Type = changeRename
+			c.Destination = dst
+			return c, true
+		case e.Type == events.Rename && srcOk:
+			c.Type = changeDelete
+			return c, true
+		case dstOk:
+			c.Type = changeCreate
+			c.Path = dst
+			return c, true
+		default:
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	return c, srcOk
+}
+
+// eventsHandler streams over a WebSocket the changes made to the entries
+// of the directories the client views. Clients set those directories by
+// sending a watchRequest, which replaces the previous one; the "path"
+// query parameter sets the first one.
+var eventsHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer conn.Close()
+
+	wt := &watcher{d: d}
+	if p := r.URL.Query().Get("path"); p != "" {
+		wt.watch([]string{p})
+	}
+
+	changes := make(chan *dirChange, watchBufferSize)
+	unsubscribe := d.bus.Subscribe(func(e *events.Event) error {
+		c, ok := wt.change(e)
+		if !ok {
+			return nil
+		}
+
+		select {
+		case changes <- c:
+		default:
+			log.Printf("%s: dropping change of %s for %s", r.URL.Path, c.Path, d.user.Username)
+		}
+		return nil
+	})
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			var req watchRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			wt.watch(req.Watch)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return 0, nil
+		case c := <-changes:
+			msg, err := json.Marshal(c)
+			if err != nil {
+				return 0, err
+			}
+			if err := conn.SetWriteDeadline(time.Now().Add(WSWriteDeadline)); err != nil {
+				return 0, nil
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return 0, nil
+			}
+		}
+	}
+})
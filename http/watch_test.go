@@ -0,0 +1,89 @@
+package http
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/rules"
+	"github.com/futureharmony/storagebrowser/v2/settings"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func TestWatcherChange(t *testing.T) {
+	t.Parallel()
+
+	alice := &users.User{
+		Username:     "alice",
+		CurrentScope: users.Scope{Name: "team", RootPrefix: "/projects"},
+		Rules:        []rules.Rule{{Path: "/docs/secret", Allow: false}},
+	}
+	bob := &users.User{
+		Username:     "bob",
+		CurrentScope: users.Scope{Name: "team", RootPrefix: "/"},
+	}
+
+	wt := &watcher{d: &data{user: alice, settings: &settings.Settings{}}}
+	wt.watch([]string{"/docs", "/inbox/"})
+
+	bobScope := bob.CurrentScope
+	aliceScope := alice.CurrentScope
+
+	testCases := map[string]struct {
+		event *events.Event
+		want  *dirChange
+	}{
+		"upload by another user in another scope root": {
+			&events.Event{Type: events.Upload, Phase: events.After, User: bob, Scope: bobScope, Path: "/projects/docs/a.txt", Size: 3},
+			&dirChange{Type: changeCreate, Path: "/docs/a.txt", Size: 3},
+		},
+		"external upload with the full object key": {
+			&events.Event{Type: events.Upload, Phase: events.After, Scope: users.Scope{Name: "team"}, Path: "/projects/inbox/b.txt"},
+			&dirChange{Type: changeCreate, Path: "/inbox/b.txt"},
+		},
+		"save in a watched directory": {
+			&events.Event{Type: events.Save, Phase: events.After, User: alice, Scope: aliceScope, Path: "/docs/a.txt"},
+			&dirChange{Type: changeModify, Path: "/docs/a.txt"},
+		},
+		"rename between watched directories": {
+			&events.Event{Type: events.Rename, Phase: events.After, User: alice, Scope: aliceScope, Path: "/docs/a.txt", Destination: "/inbox/a.txt"},
+			&dirChange{Type: changeRename, Path: "/docs/a.txt", Destination: "/inbox/a.txt"},
+		},
+		"rename out of the watched directories": {
+			&events.Event{Type: events.Rename, Phase: events.After, User: alice, Scope: aliceScope, Path: "/docs/a.txt", Destination: "/other/a.txt"},
+			&dirChange{Type: changeDelete, Path: "/docs/a.txt"},
+		},
+		"copy into a watched directory": {
+			&events.Event{Type: events.Copy, Phase: events.After, User: alice, Scope: aliceScope, Path: "/other/a.txt", Destination: "/docs/a.txt"},
+			&dirChange{Type: changeCreate, Path: "/docs/a.txt"},
+		},
+		"unwatched directory":   {&events.Event{Type: events.Delete, Phase: events.After, User: alice, Scope: aliceScope, Path: "/other/a.txt"}, nil},
+		"nested directory":      {&events.Event{Type: events.Delete, Phase: events.After, User: alice, Scope: aliceScope, Path: "/docs/sub/a.txt"}, nil},
+		"outside of the scope":  {&events.Event{Type: events.Upload, Phase: events.After, User: bob, Scope: bobScope, Path: "/docs/a.txt"}, nil},
+		"other bucket":          {&events.Event{Type: events.Upload, Phase: events.After, Scope: users.Scope{Name: "other"}, Path: "/projects/docs/a.txt"}, nil},
+		"hidden by a rule":      {&events.Event{Type: events.Upload, Phase: events.After, User: alice, Scope: aliceScope, Path: "/docs/secret"}, nil},
+		"before the operation":  {&events.Event{Type: events.Upload, Phase: events.Before, User: alice, Scope: aliceScope, Path: "/docs/a.txt"}, nil},
+		"failed operation":      {&events.Event{Type: events.Upload, Phase: events.After, User: alice, Scope: aliceScope, Path: "/docs/a.txt", Err: errors.New("fail")}, nil},
+		"traversal out of root": {&events.Event{Type: events.Upload, Phase: events.After, User: bob, Scope: bobScope, Path: "/projects/../docs/a.txt"}, nil},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := wt.change(tc.event)
+			if tc.want == nil {
+				if ok {
+					t.Fatalf("got change %+v, want none", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("got no change, want %+v", tc.want)
+			}
+			if got.Type != tc.want.Type || got.Path != tc.want.Path || got.Destination != tc.want.Destination || got.Size != tc.want.Size {
+				t.Fatalf("got change %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/webhook"
+)
+
+type webhookTestRequest struct {
+	URL   string `json:"url"`
+	Event string `json:"event"`
+}
+
+var webhookDeliveriesGetHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+
+	deliveries, err := d.store.Webhooks.List(limit)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	return renderJSON(w, r, deliveries)
+})
+
+// webhookTestHandler synchronously delivers a test payload to a webhook,
+// with the configured secret and retries, and returns the delivery.
+var webhookTestHandler = withAdmin(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if r.Body == nil {
+		return http.StatusBadRequest, fbErrors.ErrEmptyRequest
+	}
+
+	req := &webhookTestRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return http.StatusBadRequest, nil
+	}
+	if req.Event == "" {
+		req.Event = "test"
+	}
+
+	dispatcher := webhook.NewDispatcher(d.store.Webhooks)
+	p := dispatcher.NewPayload(req.Event, d.user.Username, d.scope().Name, "", "")
+	delivery := dispatcher.Deliver(d.settings.Webhooks, req.URL, p)
+
+	return renderJSON(w, r, delivery)
+})
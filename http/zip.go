@@ -0,0 +1,252 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	gopath "path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	aferos3 "github.com/futureharmony/afero-aws-s3"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/spf13/afero"
+
+	"github.com/futureharmony/storagebrowser/v2/metaindex"
+	"github.com/futureharmony/storagebrowser/v2/minio"
+	"github.com/futureharmony/storagebrowser/v2/rules"
+	"github.com/futureharmony/storagebrowser/v2/zipstream"
+)
+
+// skippedFilesName is the name of the manifest added to the archives
+// listing the files which couldn't be put in them.
+const skippedFilesName = "SKIPPED-FILES.txt"
+
+// zipCRCTTL is how long the checksums of the files of streamed archives
+// are kept, for a resumed download not to read again the files before the
+// range it asks for.
+const zipCRCTTL = 24 * time.Hour
+
+var zipCRCs = initZipCRCs()
+
+// crcCache keeps the checksums of the files of streamed archives.
+type crcCache struct {
+	*ttlcache.Cache[string, uint32]
+}
+
+func initZipCRCs() crcCache {
+	cache := ttlcache.New[string, uint32](ttlcache.WithTTL[string, uint32](zipCRCTTL))
+	go cache.Start()
+
+	return crcCache{cache}
+}
+
+// Get implements zipstream.Cache.
+func (c crcCache) Get(id string) (uint32, bool) {
+	item := c.Cache.Get(id)
+	if item == nil {
+		return 0, false
+	}
+	return item.Value(), true
+}
+
+// Set implements zipstream.Cache.
+func (c crcCache) Set(id string, crc uint32) {
+	c.Cache.Set(id, crc, ttlcache.DefaultTTL)
+}
+
+// zipLister collects the entries of a streamed archive, the names being
+// relative to a common directory.
+type zipLister struct {
+	d         *data
+	commonDir string
+	entries   []*zipstream.Entry
+	dirs      map[string]bool
+	skipped   []string
+}
+
+// serveZip streams a store-only ZIP archive of the files. Its layout is
+// known before any file is read, so that it has a length, and ranges of
+// it can be asked for to resume the download.
+func serveZip(w http.ResponseWriter, r *http.Request, d *data, filenames []string, commonDir, name string) (int, error) {
+	l := &zipLister{d: d, commonDir: commonDir, dirs: map[string]bool{}}
+	for _, fname := range filenames {
+		if err := l.add(r.Context(), fname); err != nil {
+			return errToStatus(err), err
+		}
+	}
+
+	sort.Slice(l.entries, func(i, j int) bool { return l.entries[i].Name < l.entries[j].Name })
+	if len(l.skipped) > 0 {
+		// The manifest takes the time of the files for the archive to be
+		// the same when the download is resumed.
+		var modTime time.Time
+		for _, e := range l.entries {
+			if e.ModTime.After(modTime) {
+				modTime = e.ModTime
+			}
+		}
+		sort.Strings(l.skipped)
+		manifest := strings.Join(l.skipped, "\n") + "\n"
+		l.entries = append(l.entries, zipstream.Bytes(skippedFilesName, []byte(manifest), modTime))
+	}
+
+	a := zipstream.New(l.entries, zipCRCs)
+	defer a.Close()
+
+	w.Header().Set("Content-Disposition", "attachment; filename*=utf-8''"+url.PathEscape(name))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Cache-Control", "private")
+	w.Header().Set("ETag", `"`+a.ETag()+`"`)
+	http.ServeContent(w, r, name, a.ModTime(), a)
+	return 0, nil
+}
+
+// add adds a file or a directory with its content. The files the rules
+// hide are left out silently, not to reveal their names.
+func (l *zipLister) add(ctx context.Context, fname string) error {
+	if !l.d.CheckOp(rules.OpDownload, fname) {
+		return nil
+	}
+
+	info, err := l.d.requestFs.Stat(fname)
+	if err != nil {
+		l.skip(fname, err.Error())
+		return nil
+	}
+	if !info.IsDir() {
+		l.addFile(fname, info.Size(), info.ModTime(), info.Mode(), "")
+		return nil
+	}
+
+	l.addDir(fname, info.ModTime())
+	if wrapper, ok := l.d.requestFs.(*aferos3.FsWrapper); ok {
+		return l.listObjects(ctx, wrapper, fname)
+	}
+	return l.walk(fname)
+}
+
+// listObjects adds the objects below a directory of a bucket, listing them
+// page by page.
+func (l *zipLister) listObjects(ctx context.Context, wrapper *aferos3.FsWrapper, dir string) error {
+	prefix := metaindex.Key(wrapper.RootPrefix, dir)
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	source := &metaindex.S3Source{Client: minio.GetS3Client()}
+	return source.ListObjects(ctx, wrapper.Bucket, prefix, func(page []*metaindex.Object) error {
+		for _, obj := range page {
+			p := gopath.Join(dir, strings.TrimPrefix(obj.Key, prefix))
+			if !l.allowed(dir, p) {
+				continue
+			}
+
+			// Directories are made of the keys of the objects below them,
+			// and of the empty objects standing for them.
+			for parent := gopath.Dir(p); parent != dir && parent != "/" && parent != "."; parent = gopath.Dir(parent) {
+				l.addDir(parent, obj.ModTime)
+			}
+			if strings.HasSuffix(obj.Key, "/") {
+				l.addDir(p, obj.ModTime)
+				continue
+			}
+			l.addFile(p, obj.Size, obj.ModTime, 0o644, obj.ETag)
+		}
+		return nil
+	})
+}
+
+// walk adds the files below a directory of a local file system.
+func (l *zipLister) walk(dir string) error {
+	return afero.Walk(l.d.requestFs, dir, func(p string, info os.FileInfo, err error) error {
+		if p == dir {
+			return err
+		}
+		if !l.d.CheckOp(rules.OpDownload, p) {
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if err != nil {
+			l.skip(p, err.Error())
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = l.d.requestFs.Stat(p); err != nil {
+				l.skip(p, err.Error())
+				return nil
+			}
+			if info.IsDir() {
+				l.skip(p, "symbolic link to a directory")
+				return nil
+			}
+		}
+
+		switch {
+		case info.IsDir():
+			l.addDir(p, info.ModTime())
+		case info.Mode().IsRegular():
+			l.addFile(p, info.Size(), info.ModTime(), info.Mode(), "")
+		default:
+			l.skip(p, "not a regular file")
+		}
+		return nil
+	})
+}
+
+// allowed tells if the rules let a path below dir be downloaded, as well
+// as all the directories between them.
+func (l *zipLister) allowed(dir, p string) bool {
+	for ; p != dir && p != "/" && p != "."; p = gopath.Dir(p) {
+		if !l.d.CheckOp(rules.OpDownload, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// nameInArchive returns the name of a path in the archive.
+func (l *zipLister) nameInArchive(p string) string {
+	if p == l.commonDir {
+		return filepath.ToSlash(filepath.Base(p))
+	}
+	name := strings.TrimPrefix(p, l.commonDir)
+	return strings.TrimPrefix(filepath.ToSlash(name), "/")
+}
+
+func (l *zipLister) addDir(p string, modTime time.Time) {
+	name := l.nameInArchive(p)
+	if p == l.commonDir || l.dirs[name] {
+		return
+	}
+
+	l.dirs[name] = true
+	l.entries = append(l.entries, &zipstream.Entry{Name: name + "/", ModTime: modTime, Mode: fs.ModeDir | 0o755})
+}
+
+func (l *zipLister) addFile(p string, size int64, modTime time.Time, mode fs.FileMode, etag string) {
+	requestFs := l.d.requestFs
+	l.entries = append(l.entries, &zipstream.Entry{
+		Name:    l.nameInArchive(p),
+		Size:    size,
+		ModTime: modTime,
+		Mode:    mode,
+		// The checksum of a file is kept as long as it doesn't change.
+		ID: fmt.Sprintf("%s\x00%d\x00%d\x00%s", fsObjectID(requestFs, p), size, modTime.UnixNano(), etag),
+		Open: func() (zipstream.File, error) {
+			return requestFs.Open(p)
+		},
+	})
+}
+
+func (l *zipLister) skip(p, reason string) {
+	l.skipped = append(l.skipped, l.nameInArchive(p)+": "+reason)
+}
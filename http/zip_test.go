@@ -0,0 +1,93 @@
+package http
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/futureharmony/storagebrowser/v2/rules"
+	"github.com/futureharmony/storagebrowser/v2/settings"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func TestServeZip(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	for name, content := range map[string]string{
+		"/dir/a.txt":         "alpha",
+		"/dir/sub/b.txt":     "bravo",
+		"/dir/private/c.txt": "charlie",
+	} {
+		if err := afero.WriteFile(fs, name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := &data{
+		user: &users.User{
+			CurrentScope: users.Scope{Name: "current", RootPrefix: "/"},
+			Rules:        []rules.Rule{{Path: "/dir/private", Allow: false}},
+		},
+		settings:  &settings.Settings{},
+		requestFs: fs,
+	}
+
+	serve := func(header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/raw/dir", http.NoBody)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		if _, err := serveZip(w, r, d, []string{"/dir"}, "/dir", "dir.zip"); err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+
+	full := serve(nil)
+	content := full.Body.Bytes()
+	if full.Code != http.StatusOK || full.Header().Get("Content-Length") != fmt.Sprint(len(content)) {
+		t.Fatalf("status %d, length %q for %d bytes", full.Code, full.Header().Get("Content-Length"), len(content))
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		got = append(got, f.Name+"="+string(data))
+	}
+	if want := "[a.txt=alpha sub/= sub/b.txt=bravo]"; fmt.Sprint(got) != want {
+		t.Errorf("archive files = %v, want %v", got, want)
+	}
+
+	// The download is resumed from the middle of the archive as long as
+	// it didn't change.
+	etag := full.Header().Get("ETag")
+	part := serve(http.Header{"Range": {"bytes=40-"}, "If-Range": {etag}})
+	if part.Code != http.StatusPartialContent || !bytes.Equal(part.Body.Bytes(), content[40:]) {
+		t.Errorf("range request: status %d, content differs: %v", part.Code, !bytes.Equal(part.Body.Bytes(), content[40:]))
+	}
+
+	if err := afero.WriteFile(fs, "/dir/a.txt", []byte("alpha2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changed := serve(http.Header{"Range": {"bytes=40-"}, "If-Range": {etag}}); changed.Code != http.StatusOK {
+		t.Errorf("range request on a changed archive: status %d", changed.Code)
+	}
+}
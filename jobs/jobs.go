@@ -0,0 +1,105 @@
+// Package jobs runs the long operations of the users in the background,
+// keeping a record of their progress which outlives the requests that
+// started them.
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+// Status is the state a job is in.
+type Status string
+
+// Job statuses.
+const (
+	Queued   Status = "queued"
+	Running  Status = "running"
+	Done     Status = "done"
+	Failed   Status = "failed"
+	Canceled Status = "canceled"
+)
+
+// Job is the record of an operation run in the background.
+type Job struct {
+	ID     uint64 `json:"id" storm:"id,increment"`
+	UserID uint   `json:"userID" storm:"index"`
+	// Type is the operation, named like its hook event, e.g. "copy".
+	Type        string `json:"type"`
+	Scope       string `json:"scope"`
+	Path        string `json:"path"`
+	Destination string `json:"destination,omitempty"`
+	Status      Status `json:"status"`
+	Error       string `json:"error,omitempty"`
+	Progress
+	Created  time.Time `json:"created"`
+	Started  time.Time `json:"started,omitempty"`
+	Finished time.Time `json:"finished,omitempty"`
+}
+
+// Over tells if the job ended, whatever its outcome.
+func (j *Job) Over() bool {
+	return j.Status == Done || j.Status == Failed || j.Status == Canceled
+}
+
+// Progress counts the objects and the bytes a job went through, out of
+// the totals when they are known.
+type Progress struct {
+	ObjectsDone  int64 `json:"objectsDone"`
+	ObjectsTotal int64 `json:"objectsTotal"`
+	BytesDone    int64 `json:"bytesDone"`
+	BytesTotal   int64 `json:"bytesTotal"`
+}
+
+// progressInterval is the least time between two saves of the progress
+// of a job.
+const progressInterval = time.Second
+
+// Tracker reports the progress of a running job. It may be used from
+// several goroutines. A nil tracker does nothing, for the operations to
+// run the same outside of a job.
+type Tracker struct {
+	s     *Storage
+	job   *Job
+	mu    sync.Mutex
+	saved time.Time
+}
+
+// SetTotal sets the objects and the bytes the job has to go through.
+func (t *Tracker) SetTotal(objects, bytes int64) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.job.ObjectsTotal, t.job.BytesTotal = objects, bytes
+	t.save(true)
+}
+
+// Add counts objects and bytes the job went through.
+func (t *Tracker) Add(objects, bytes int64) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.job.ObjectsDone += objects
+	t.job.BytesDone += bytes
+	t.save(false)
+}
+
+// save records the job, unless it was recently and force is false. It
+// must be called with the lock held.
+func (t *Tracker) save(force bool) {
+	now := t.s.now()
+	if !force && now.Sub(t.saved) < progressInterval {
+		return
+	}
+
+	t.saved = now
+	t.s.save(t.job)
+}
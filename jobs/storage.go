@@ -0,0 +1,219 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+)
+
+// DefaultWorkers is how many jobs run at the same time.
+const DefaultWorkers = 4
+
+// Retention is how long the records of the jobs are kept once over.
+const Retention = 7 * 24 * time.Hour
+
+// Func is the work of a job. It must stop when ctx is done, which happens
+// when the job is canceled.
+type Func func(ctx context.Context, t *Tracker) error
+
+// StorageBackend is the interface to implement for a job storage.
+type StorageBackend interface {
+	Save(j *Job) error
+	Get(id uint64) (*Job, error)
+	// All returns every job, newest first.
+	All() ([]*Job, error)
+	// FindByUserID returns the jobs of a user, newest first.
+	FindByUserID(id uint) ([]*Job, error)
+	Delete(id uint64) error
+}
+
+// Storage records the jobs and runs them, a limited number at a time.
+type Storage struct {
+	back    StorageBackend
+	workers chan struct{}
+	now     func() time.Time
+
+	// mu guards the cancel functions of the jobs not over yet.
+	mu      sync.Mutex
+	cancels map[uint64]context.CancelFunc
+}
+
+// NewStorage creates a job storage from a backend.
+func NewStorage(back StorageBackend) *Storage {
+	return &Storage{
+		back:    back,
+		workers: make(chan struct{}, DefaultWorkers),
+		now:     time.Now,
+		cancels: map[uint64]context.CancelFunc{},
+	}
+}
+
+// Recover marks the jobs left queued or running by a previous run of the
+// server as failed, their work being lost, and deletes the records of
+// the jobs over for longer than Retention.
+func (s *Storage) Recover() error {
+	jobs, err := s.back.All()
+	if err != nil && !errors.Is(err, fbErrors.ErrNotExist) {
+		return err
+	}
+
+	now := s.now()
+	for _, j := range jobs {
+		if j.Over() {
+			continue
+		}
+		j.Status, j.Error, j.Finished = Failed, "interrupted by a restart of the server", now
+		if err = s.back.Save(j); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.Prune()
+	return err
+}
+
+// Prune deletes the records of the jobs over for longer than Retention,
+// returning how many were.
+func (s *Storage) Prune() (int, error) {
+	jobs, err := s.back.All()
+	if errors.Is(err, fbErrors.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	now := s.now()
+	for _, j := range jobs {
+		if !j.Over() || now.Sub(j.Finished) <= Retention {
+			continue
+		}
+		if err = s.back.Delete(j.ID); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// Sweep prunes the records of the jobs every interval until ctx is done.
+func (s *Storage) Sweep(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		if n, err := s.Prune(); err != nil {
+			log.Printf("jobs: deleting old jobs: %v", err)
+		} else if n > 0 {
+			log.Printf("jobs: deleted %d old jobs", n)
+		}
+	}
+}
+
+// Submit records a job and runs fn for it in the background once a
+// worker is free. The job given is left as recorded, the running job
+// being a copy of it.
+func (s *Storage) Submit(j *Job, fn Func) error {
+	j.Status, j.Created = Queued, s.now()
+	if err := s.back.Save(j); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancels[j.ID] = cancel
+	s.mu.Unlock()
+
+	running := *j
+	go s.run(ctx, &running, fn)
+	return nil
+}
+
+func (s *Storage) run(ctx context.Context, j *Job, fn Func) {
+	defer func() {
+		s.mu.Lock()
+		s.cancels[j.ID]()
+		delete(s.cancels, j.ID)
+		s.mu.Unlock()
+	}()
+
+	t := &Tracker{s: s, job: j}
+	select {
+	case s.workers <- struct{}{}:
+		defer func() { <-s.workers }()
+	case <-ctx.Done():
+		s.finish(ctx, t, ctx.Err())
+		return
+	}
+
+	t.mu.Lock()
+	j.Status, j.Started = Running, s.now()
+	t.save(true)
+	t.mu.Unlock()
+
+	s.finish(ctx, t, fn(ctx, t))
+}
+
+// finish records the outcome of a job.
+func (s *Storage) finish(ctx context.Context, t *Tracker, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	j := t.job
+	switch {
+	case err == nil:
+		j.Status = Done
+	case ctx.Err() != nil && errors.Is(err, context.Canceled):
+		j.Status = Canceled
+	default:
+		j.Status, j.Error = Failed, err.Error()
+	}
+	j.Finished = s.now()
+	t.save(true)
+}
+
+// save records a job, logging the failures since the job goes on anyway.
+func (s *Storage) save(j *Job) {
+	if err := s.back.Save(j); err != nil {
+		log.Printf("WARNING: couldn't save job %d: %v", j.ID, err)
+	}
+}
+
+// Get wraps a StorageBackend.Get.
+func (s *Storage) Get(id uint64) (*Job, error) {
+	return s.back.Get(id)
+}
+
+// All wraps a StorageBackend.All.
+func (s *Storage) All() ([]*Job, error) {
+	return s.back.All()
+}
+
+// FindByUserID wraps a StorageBackend.FindByUserID.
+func (s *Storage) FindByUserID(id uint) ([]*Job, error) {
+	return s.back.FindByUserID(id)
+}
+
+// Cancel stops a job. Canceling a job which is over does nothing.
+func (s *Storage) Cancel(id uint64) error {
+	s.mu.Lock()
+	cancel, ok := s.cancels[id]
+	s.mu.Unlock()
+
+	if ok {
+		cancel()
+		return nil
+	}
+
+	_, err := s.Get(id)
+	return err
+}
@@ -0,0 +1,42 @@
+package lockout
+
+import "time"
+
+// Attempt holds the failed login attempts recorded for a single key,
+// which identifies either a username or a client IP.
+type Attempt struct {
+	Key         string `json:"key" storm:"id"`
+	Failures    uint   `json:"failures"`
+	LastFailure int64  `json:"lastFailure"`
+	NextAttempt int64  `json:"nextAttempt"`
+	LockedUntil int64  `json:"lockedUntil"`
+}
+
+// UserKey returns the attempt key for a username.
+func UserKey(username string) string {
+	return "user:" + username
+}
+
+// IPKey returns the attempt key for a client IP.
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Locked tells if the key is locked out at the given time.
+func (a *Attempt) Locked(now time.Time) bool {
+	return a.LockedUntil > now.Unix()
+}
+
+// Wait returns how long a new login attempt must be delayed at the
+// given time, either because of the backoff or because of a lockout.
+func (a *Attempt) Wait(now time.Time) time.Duration {
+	until := a.NextAttempt
+	if a.LockedUntil > until {
+		until = a.LockedUntil
+	}
+
+	if until <= now.Unix() {
+		return 0
+	}
+	return time.Duration(until-now.Unix()) * time.Second
+}
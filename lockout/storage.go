@@ -0,0 +1,202 @@
+package lockout
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/settings"
+)
+
+// StorageBackend is the interface to implement for a lockout storage.
+type StorageBackend interface {
+	All() ([]*Attempt, error)
+	Get(key string) (*Attempt, error)
+	Save(a *Attempt) error
+	Delete(key string) error
+}
+
+// Storage is a login attempts storage.
+type Storage struct {
+	back StorageBackend
+	mux  sync.Mutex
+	now  func() time.Time
+}
+
+// NewStorage creates a login attempts storage from a backend.
+func NewStorage(back StorageBackend) *Storage {
+	return &Storage{back: back, now: time.Now}
+}
+
+// All wraps a StorageBackend.All.
+func (s *Storage) All() ([]*Attempt, error) {
+	return s.back.All()
+}
+
+// Get wraps a StorageBackend.Get.
+func (s *Storage) Get(key string) (*Attempt, error) {
+	return s.back.Get(key)
+}
+
+// Check returns how long the caller must wait before a login attempt
+// for any of the given keys is evaluated. A zero duration means the
+// attempt may proceed.
+func (s *Storage) Check(policy settings.Lockout, keys ...string) (time.Duration, error) {
+	if policy.Disabled {
+		return 0, nil
+	}
+
+	return s.wait(s.now(), keys)
+}
+
+func (s *Storage) wait(now time.Time, keys []string) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range keys {
+		a, err := s.back.Get(key)
+		if errors.Is(err, fbErrors.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if w := a.Wait(now); w > wait {
+			wait = w
+		}
+	}
+
+	return wait, nil
+}
+
+// Fail records a failed login attempt for each of the given keys. Every
+// failure doubles the backoff up to policy.MaxBackoff, and once
+// policy.MaxAttempts is reached the key is locked for policy.Duration.
+func (s *Storage) Fail(policy settings.Lockout, keys ...string) error {
+	if policy.Disabled {
+		return nil
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.fail(policy, s.now().Unix(), keys)
+}
+
+// Attempt starts a login attempt for the given keys, returning how long
+// the caller must wait first like Check. An attempt that may proceed is
+// recorded as failed right away, as Fail does, so that the attempts made
+// meanwhile wait for its backoff. Release gives it back once the login
+// didn't fail.
+func (s *Storage) Attempt(policy settings.Lockout, keys ...string) (time.Duration, error) {
+	if policy.Disabled {
+		return 0, nil
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	now := s.now()
+	wait, err := s.wait(now, keys)
+	if err != nil || wait > 0 {
+		return wait, err
+	}
+
+	return 0, s.fail(policy, now.Unix(), keys)
+}
+
+// Release gives back an attempt of the given keys recorded by Attempt,
+// restoring the backoff of the failures before it.
+func (s *Storage) Release(policy settings.Lockout, keys ...string) error {
+	if policy.Disabled {
+		return nil
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, key := range keys {
+		a, err := s.back.Get(key)
+		if errors.Is(err, fbErrors.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if a.Failures <= 1 {
+			if err := s.back.Delete(key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		a.Failures--
+		a.NextAttempt = a.LastFailure + int64(backoff(policy, a.Failures))
+		if policy.MaxAttempts == 0 || a.Failures < policy.MaxAttempts {
+			a.LockedUntil = 0
+		}
+		if err := s.back.Save(a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) fail(policy settings.Lockout, now int64, keys []string) error {
+	for _, key := range keys {
+		a, err := s.back.Get(key)
+		if errors.Is(err, fbErrors.ErrNotExist) {
+			a = &Attempt{Key: key}
+		} else if err != nil {
+			return err
+		}
+
+		// Failures are forgotten once a lockout is over or when the last
+		// one is older than the lockout duration.
+		if (a.LockedUntil != 0 && a.LockedUntil <= now) || a.LastFailure+int64(policy.Duration) <= now {
+			a.Failures = 0
+			a.LockedUntil = 0
+		}
+
+		a.Failures++
+		a.LastFailure = now
+		a.NextAttempt = now + int64(backoff(policy, a.Failures))
+		if policy.MaxAttempts > 0 && a.Failures >= policy.MaxAttempts {
+			a.LockedUntil = now + int64(policy.Duration)
+		}
+
+		if err := s.back.Save(a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reset forgets every failed attempt recorded for the given keys.
+func (s *Storage) Reset(keys ...string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for _, key := range keys {
+		if err := s.back.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func backoff(policy settings.Lockout, failures uint) uint {
+	delay := policy.Backoff
+	for i := uint(1); i < failures && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	return delay
+}
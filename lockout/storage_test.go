@@ -0,0 +1,146 @@
+package lockout
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/settings"
+)
+
+type memBackend map[string]Attempt
+
+func (m memBackend) All() ([]*Attempt, error) {
+	var v []*Attempt
+	for _, a := range m {
+		a := a
+		v = append(v, &a)
+	}
+	return v, nil
+}
+
+func (m memBackend) Get(key string) (*Attempt, error) {
+	a, ok := m[key]
+	if !ok {
+		return nil, fbErrors.ErrNotExist
+	}
+	return &a, nil
+}
+
+func (m memBackend) Save(a *Attempt) error {
+	m[a.Key] = *a
+	return nil
+}
+
+func (m memBackend) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func TestStorageLockout(t *testing.T) {
+	policy := settings.Lockout{MaxAttempts: 3, Duration: 60, Backoff: 1, MaxBackoff: 4}
+	now := time.Unix(1_700_000_000, 0)
+
+	s := NewStorage(memBackend{})
+	s.now = func() time.Time { return now }
+
+	key := UserKey("alice")
+	wantWaits := []time.Duration{1 * time.Second, 2 * time.Second, 60 * time.Second}
+	for i, want := range wantWaits {
+		if err := s.Fail(policy, key); err != nil {
+			t.Fatalf("Fail() error: %v", err)
+		}
+
+		wait, err := s.Check(policy, key, IPKey("127.0.0.1"))
+		if err != nil {
+			t.Fatalf("Check() error: %v", err)
+		}
+		if wait != want {
+			t.Errorf("after %d failures wait=%v; want %v", i+1, wait, want)
+		}
+	}
+
+	now = now.Add(61 * time.Second)
+	if wait, _ := s.Check(policy, key); wait != 0 {
+		t.Errorf("after lockout expired wait=%v; want 0", wait)
+	}
+
+	if err := s.Fail(policy, key); err != nil {
+		t.Fatalf("Fail() error: %v", err)
+	}
+	if a, _ := s.Get(key); a.Failures != 1 || a.Locked(now) {
+		t.Errorf("after expired lockout failures=%d locked=%t; want 1, false", a.Failures, a.Locked(now))
+	}
+
+	if err := s.Reset(key); err != nil {
+		t.Fatalf("Reset() error: %v", err)
+	}
+	if wait, _ := s.Check(policy, key); wait != 0 {
+		t.Errorf("after reset wait=%v; want 0", wait)
+	}
+}
+
+func TestStorageLockoutDisabled(t *testing.T) {
+	policy := settings.Lockout{Disabled: true, MaxAttempts: 1, Duration: 60, Backoff: 1}
+	s := NewStorage(memBackend{})
+
+	for i := 0; i < 3; i++ {
+		if err := s.Fail(policy, IPKey("10.0.0.1")); err != nil {
+			t.Fatalf("Fail() error: %v", err)
+		}
+	}
+
+	if wait, _ := s.Check(policy, IPKey("10.0.0.1")); wait != 0 {
+		t.Errorf("disabled lockout wait=%v; want 0", wait)
+	}
+}
+
+func TestStorageAttempt(t *testing.T) {
+	policy := settings.Lockout{MaxAttempts: 3, Duration: 60, Backoff: 1, MaxBackoff: 4}
+	now := time.Unix(1_700_000_000, 0)
+
+	s := NewStorage(memBackend{})
+	s.now = func() time.Time { return now }
+
+	// Concurrent attempts wait for the first one, counted as failed.
+	key := UserKey("alice")
+	var proceeded atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if wait, err := s.Attempt(policy, key); err == nil && wait == 0 {
+				proceeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := proceeded.Load(); n != 1 {
+		t.Fatalf("%d concurrent attempts proceeded, want 1", n)
+	}
+
+	// A released attempt leaves the failures before it.
+	if err := s.Release(policy, key); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	if _, err := s.Get(key); err == nil {
+		t.Error("a released attempt left a record")
+	}
+
+	if err := s.Fail(policy, key); err != nil {
+		t.Fatalf("Fail() error: %v", err)
+	}
+	now = now.Add(time.Second)
+	if wait, _ := s.Attempt(policy, key); wait != 0 {
+		t.Fatalf("attempt after the backoff wait=%v; want 0", wait)
+	}
+	if err := s.Release(policy, key); err != nil {
+		t.Fatalf("Release() error: %v", err)
+	}
+	if a, _ := s.Get(key); a.Failures != 1 || a.Locked(now) {
+		t.Errorf("after release failures=%d locked=%t; want 1, false", a.Failures, a.Locked(now))
+	}
+}
@@ -0,0 +1,103 @@
+package metaindex
+
+import (
+	"context"
+	"log"
+	"time"
+
+	aferos3 "github.com/futureharmony/afero-aws-s3"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+)
+
+// queueSize is how many changes may wait to be synchronized. Changes
+// past that are left to the next rescan.
+const queueSize = 256
+
+// Run rescans the buckets returned by buckets every interval, until ctx
+// is done. Buckets scanned less than interval ago, before a restart for
+// instance, wait for the next round.
+func (i *Index) Run(ctx context.Context, interval time.Duration, buckets func() ([]string, error)) {
+	for {
+		names, err := buckets()
+		if err != nil {
+			log.Printf("metadata index: listing buckets: %v", err)
+		}
+
+		for _, bucket := range names {
+			if scanned, err := i.back.Scanned(bucket); err == nil && i.now().Sub(scanned) < interval {
+				continue
+			}
+
+			start := time.Now()
+			changed, err := i.Rescan(ctx, bucket)
+			if err != nil {
+				log.Printf("metadata index: scanning %s: %v", bucket, err)
+				continue
+			}
+			log.Printf("metadata index: scanned %s in %v, %d changes", bucket, time.Since(start), changed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+type change struct {
+	bucket, prefix string
+}
+
+// Subscribe keeps the index up to date with the objects changed by the
+// events of the bus, synchronizing them in the background.
+func (i *Index) Subscribe(bus *events.Bus) {
+	if i == nil {
+		return
+	}
+
+	queue := make(chan change, queueSize)
+	go func() {
+		for c := range queue {
+			if _, err := i.Sync(context.Background(), c.bucket, c.prefix); err != nil {
+				log.Printf("metadata index: syncing %s/%s: %v", c.bucket, c.prefix, err)
+			}
+		}
+	}()
+
+	bus.Subscribe(func(e *events.Event) error {
+		if e.Phase != events.After || e.Err != nil {
+			return nil
+		}
+
+		var bucket, rootPrefix string
+		switch fs := e.Fs.(type) {
+		case *aferos3.FsWrapper:
+			bucket, rootPrefix = fs.Bucket, fs.RootPrefix
+		case nil:
+			// Changes notified by the bucket have no file system.
+			bucket, rootPrefix = e.Scope.Name, e.Scope.RootPrefix
+		}
+		if bucket == "" {
+			return nil
+		}
+
+		paths := []string{e.Path}
+		switch e.Type {
+		case events.Copy, events.Compress:
+			paths = []string{e.Destination}
+		case events.Rename:
+			paths = append(paths, e.Destination)
+		}
+
+		for _, p := range paths {
+			select {
+			case queue <- change{bucket: bucket, prefix: Key(rootPrefix, p)}:
+			default:
+				log.Printf("metadata index: queue full, %s of %s left to the next scan", e.Type, p)
+			}
+		}
+		return nil
+	}, events.Upload, events.Save, events.Delete, events.Rename, events.Copy, events.Compress)
+}
@@ -0,0 +1,243 @@
+// Package metaindex mirrors the metadata of the objects of S3 buckets in
+// a local database, for them to be searched and listed without walking
+// the buckets.
+package metaindex
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	aferos3 "github.com/futureharmony/afero-aws-s3"
+	"github.com/spf13/afero"
+)
+
+// Object is the metadata of an object of a bucket.
+type Object struct {
+	Key     string    `json:"-"`
+	Size    int64     `json:"size"`
+	ETag    string    `json:"etag"`
+	ModTime time.Time `json:"modified"`
+	// Dir is set on the directories of listings, which stand for all the
+	// objects below them. Their key ends with a slash.
+	Dir bool `json:"-"`
+}
+
+// Equal tells if two objects have the same metadata.
+func (o *Object) Equal(other *Object) bool {
+	return o.Key == other.Key && o.Size == other.Size && o.ETag == other.ETag &&
+		o.ModTime.Equal(other.ModTime)
+}
+
+// Backend is the interface to implement for a metadata index storage.
+type Backend interface {
+	// Replace replaces the objects of a bucket whose keys are in
+	// [from, to) by objs, which are sorted by key. An empty to has no
+	// upper bound. It returns how many objects were added, changed or
+	// removed.
+	Replace(bucket, from, to string, objs []*Object) (int, error)
+	// List returns the objects of a bucket whose keys begin with prefix
+	// and have no slash after it, and the directories of the others.
+	List(bucket, prefix string) ([]*Object, error)
+	// Walk calls fn for the objects of a bucket whose keys begin with
+	// prefix, in key order.
+	Walk(bucket, prefix string, fn func(o *Object) error) error
+	// Scanned returns when a bucket was last fully scanned, the zero time
+	// if never.
+	Scanned(bucket string) (time.Time, error)
+	SetScanned(bucket string, t time.Time) error
+}
+
+// Source lists the objects of the buckets.
+type Source interface {
+	// ListObjects calls fn with the objects of a bucket whose keys begin
+	// with prefix, page by page in key order.
+	ListObjects(ctx context.Context, bucket, prefix string, fn func(page []*Object) error) error
+}
+
+// Index is the metadata index of the buckets.
+type Index struct {
+	back   Backend
+	source Source
+	// mux serializes the synchronizations, for an older listing to never
+	// overwrite a newer one.
+	mux sync.Mutex
+	now func() time.Time
+}
+
+// NewIndex creates a metadata index kept in back of the objects listed
+// by source.
+func NewIndex(back Backend, source Source) *Index {
+	return &Index{back: back, source: source, now: time.Now}
+}
+
+// Key returns the key of the object at a path of a bucket seen from a
+// root prefix.
+func Key(rootPrefix, p string) string {
+	return strings.TrimPrefix(path.Join("/", rootPrefix, p), "/")
+}
+
+// prefixEnd returns the smallest key greater than all the keys beginning
+// with prefix, empty if there is none.
+func prefixEnd(prefix string) string {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			return prefix[:i] + string([]byte{prefix[i] + 1})
+		}
+	}
+	return ""
+}
+
+// Sync updates the objects of a bucket whose keys begin with prefix from
+// the source, and returns how many changed.
+func (i *Index) Sync(ctx context.Context, bucket, prefix string) (int, error) {
+	i.mux.Lock()
+	defer i.mux.Unlock()
+
+	changed := 0
+	from := prefix
+	err := i.source.ListObjects(ctx, bucket, prefix, func(page []*Object) error {
+		if len(page) == 0 {
+			return nil
+		}
+
+		// Keys right after the last one of the page belong to the next.
+		to := page[len(page)-1].Key + "\x00"
+		n, err := i.back.Replace(bucket, from, to, page)
+		changed += n
+		from = to
+		return err
+	})
+	if err != nil {
+		return changed, err
+	}
+
+	n, err := i.back.Replace(bucket, from, prefixEnd(prefix), nil)
+	return changed + n, err
+}
+
+// Rescan synchronizes a whole bucket.
+func (i *Index) Rescan(ctx context.Context, bucket string) (int, error) {
+	start := i.now()
+	changed, err := i.Sync(ctx, bucket, "")
+	if err != nil {
+		return changed, err
+	}
+
+	return changed, i.back.SetScanned(bucket, start)
+}
+
+// Ready tells if a bucket was fully scanned, and so if the index can be
+// used instead of the bucket.
+func (i *Index) Ready(bucket string) bool {
+	if i == nil {
+		return false
+	}
+
+	scanned, err := i.back.Scanned(bucket)
+	return err == nil && !scanned.IsZero()
+}
+
+// indexedFs returns the S3 file system of fs when its bucket is indexed.
+func (i *Index) indexedFs(fs afero.Fs) (*aferos3.FsWrapper, bool) {
+	wrapper, ok := fs.(*aferos3.FsWrapper)
+	if !ok || !i.Ready(wrapper.Bucket) {
+		return nil, false
+	}
+	return wrapper, true
+}
+
+// List lists a directory of a file system from the index. It returns
+// false when the file system isn't an indexed bucket.
+func (i *Index) List(fs afero.Fs, dir string) ([]os.FileInfo, bool, error) {
+	wrapper, ok := i.indexedFs(fs)
+	if !ok {
+		return nil, false, nil
+	}
+
+	prefix := Key(wrapper.RootPrefix, dir)
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	objs, err := i.back.List(wrapper.Bucket, prefix)
+	if err != nil {
+		return nil, true, err
+	}
+
+	infos := make([]os.FileInfo, 0, len(objs))
+	for _, o := range objs {
+		infos = append(infos, &fileInfo{o})
+	}
+	return infos, true, nil
+}
+
+// Walk calls fn with the files and directories below root of a file
+// system, from the index. It returns false when the file system isn't an
+// indexed bucket.
+func (i *Index) Walk(fs afero.Fs, root string, fn func(p string, info os.FileInfo) error) (bool, error) {
+	wrapper, ok := i.indexedFs(fs)
+	if !ok {
+		return false, nil
+	}
+
+	base := Key(wrapper.RootPrefix, "/")
+	if base != "" {
+		base += "/"
+	}
+	prefix := Key(wrapper.RootPrefix, root)
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	// Keys are sorted, so the objects of a directory follow each other:
+	// a directory is seen when the first of them is.
+	lastDir := prefix
+	return true, i.back.Walk(wrapper.Bucket, prefix, func(o *Object) error {
+		dir := o.Key[:strings.LastIndex(o.Key, "/")+1]
+		if dir != lastDir {
+			common := lastDir
+			for !strings.HasPrefix(dir, common) {
+				common = common[:strings.LastIndex(strings.TrimSuffix(common, "/"), "/")+1]
+			}
+			for _, name := range strings.SplitAfter(strings.TrimPrefix(dir, common), "/") {
+				if name == "" {
+					continue
+				}
+				common += name
+				d := &Object{Key: common, Dir: true}
+				if err := fn(path.Join("/", strings.TrimPrefix(common, base)), &fileInfo{d}); err != nil {
+					return err
+				}
+			}
+			lastDir = dir
+		}
+
+		if o.Key == dir {
+			// A directory marker.
+			return nil
+		}
+		return fn(path.Join("/", strings.TrimPrefix(o.Key, base)), &fileInfo{o})
+	})
+}
+
+// fileInfo is the os.FileInfo of an indexed object.
+type fileInfo struct {
+	o *Object
+}
+
+func (i *fileInfo) Name() string       { return path.Base(i.o.Key) }
+func (i *fileInfo) Size() int64        { return i.o.Size }
+func (i *fileInfo) ModTime() time.Time { return i.o.ModTime }
+func (i *fileInfo) IsDir() bool        { return i.o.Dir }
+func (i *fileInfo) Sys() interface{}   { return i.o }
+
+func (i *fileInfo) Mode() os.FileMode {
+	if i.o.Dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
@@ -0,0 +1,220 @@
+package metaindex
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	aferos3 "github.com/futureharmony/afero-aws-s3"
+)
+
+type memBackend struct {
+	objects map[string]map[string]*Object
+	scans   map[string]time.Time
+}
+
+func newMemBackend() *memBackend {
+	return &memBackend{objects: map[string]map[string]*Object{}, scans: map[string]time.Time{}}
+}
+
+func (m *memBackend) sorted(bucket, prefix string) []*Object {
+	var objs []*Object
+	for key, o := range m.objects[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			objs = append(objs, o)
+		}
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Key < objs[j].Key })
+	return objs
+}
+
+func (m *memBackend) Replace(bucket, from, to string, objs []*Object) (int, error) {
+	if m.objects[bucket] == nil {
+		m.objects[bucket] = map[string]*Object{}
+	}
+	stored := m.objects[bucket]
+
+	changed := 0
+	kept := map[string]bool{}
+	for _, o := range objs {
+		kept[o.Key] = true
+		if old, ok := stored[o.Key]; ok && old.Equal(o) {
+			continue
+		}
+		stored[o.Key] = o
+		changed++
+	}
+	for key := range stored {
+		if key >= from && (to == "" || key < to) && !kept[key] {
+			delete(stored, key)
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (m *memBackend) List(bucket, prefix string) ([]*Object, error) {
+	objs := []*Object{}
+	dirs := map[string]bool{}
+	for _, o := range m.sorted(bucket, prefix) {
+		name := strings.TrimPrefix(o.Key, prefix)
+		if name == "" {
+			continue
+		}
+		if i := strings.Index(name, "/"); i >= 0 {
+			if dir := prefix + name[:i+1]; !dirs[dir] {
+				dirs[dir] = true
+				objs = append(objs, &Object{Key: dir, Dir: true})
+			}
+			continue
+		}
+		objs = append(objs, o)
+	}
+	return objs, nil
+}
+
+func (m *memBackend) Walk(bucket, prefix string, fn func(o *Object) error) error {
+	for _, o := range m.sorted(bucket, prefix) {
+		if err := fn(o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memBackend) Scanned(bucket string) (time.Time, error) {
+	return m.scans[bucket], nil
+}
+
+func (m *memBackend) SetScanned(bucket string, t time.Time) error {
+	m.scans[bucket] = t
+	return nil
+}
+
+// memSource lists objects two by two, to go through several pages.
+type memSource struct {
+	objects map[string]*Object
+}
+
+func (s *memSource) put(key string, size int64) {
+	s.objects[key] = &Object{Key: key, Size: size, ETag: `"` + key + `"`}
+}
+
+func (s *memSource) ListObjects(_ context.Context, _, prefix string, fn func(page []*Object) error) error {
+	var objs []*Object
+	for key, o := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objs = append(objs, o)
+		}
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].Key < objs[j].Key })
+
+	for len(objs) > 0 {
+		n := min(2, len(objs))
+		if err := fn(objs[:n]); err != nil {
+			return err
+		}
+		objs = objs[n:]
+	}
+	return nil
+}
+
+func newTestIndex() (*Index, *memSource) {
+	source := &memSource{objects: map[string]*Object{}}
+	for _, key := range []string{"a.txt", "docs/", "docs/b.txt", "docs/c.txt", "docs/sub/d.txt", "e.txt"} {
+		source.put(key, int64(len(key)))
+	}
+	return NewIndex(newMemBackend(), source), source
+}
+
+func TestSync(t *testing.T) {
+	index, source := newTestIndex()
+	ctx := context.Background()
+
+	changed, err := index.Rescan(ctx, "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 6 {
+		t.Errorf("first scan changed %d objects, want 6", changed)
+	}
+
+	if changed, _ = index.Rescan(ctx, "bucket"); changed != 0 {
+		t.Errorf("unchanged scan changed %d objects, want 0", changed)
+	}
+
+	delete(source.objects, "docs/c.txt")
+	delete(source.objects, "e.txt")
+	source.put("docs/b.txt", 100)
+	source.put("docs/sub/f.txt", 1)
+
+	if changed, _ = index.Sync(ctx, "bucket", "docs/"); changed != 3 {
+		t.Errorf("docs/ sync changed %d objects, want 3", changed)
+	}
+	if changed, _ = index.Rescan(ctx, "bucket"); changed != 1 {
+		t.Errorf("rescan changed %d objects, want 1", changed)
+	}
+
+	var keys []string
+	_ = index.back.Walk("bucket", "", func(o *Object) error {
+		keys = append(keys, o.Key)
+		return nil
+	})
+	if got, want := strings.Join(keys, ","), "a.txt,docs/,docs/b.txt,docs/sub/d.txt,docs/sub/f.txt"; got != want {
+		t.Errorf("indexed keys = %s, want %s", got, want)
+	}
+}
+
+func TestListAndWalk(t *testing.T) {
+	index, _ := newTestIndex()
+	fs := &aferos3.FsWrapper{Bucket: "bucket", RootPrefix: "/"}
+
+	if _, ok, _ := index.List(fs, "/"); ok {
+		t.Fatal("List used the index before the bucket was scanned")
+	}
+	if _, err := index.Rescan(context.Background(), "bucket"); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, ok, err := index.List(fs, "/docs")
+	if !ok || err != nil {
+		t.Fatalf("List = %v, %v", ok, err)
+	}
+	var names []string
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	if got, want := strings.Join(names, ","), "b.txt,c.txt,sub/"; got != want {
+		t.Errorf("List(/docs) = %s, want %s", got, want)
+	}
+
+	var paths []string
+	ok, err = index.Walk(fs, "/", func(p string, info os.FileInfo) error {
+		if info.IsDir() {
+			p += "/"
+		}
+		paths = append(paths, p)
+		return nil
+	})
+	if !ok || err != nil {
+		t.Fatalf("Walk = %v, %v", ok, err)
+	}
+	want := "/a.txt,/docs/,/docs/b.txt,/docs/c.txt,/docs/sub/,/docs/sub/d.txt,/e.txt"
+	if got := strings.Join(paths, ","); got != want {
+		t.Errorf("Walk(/) = %s, want %s", got, want)
+	}
+}
+
+func TestNilIndex(t *testing.T) {
+	var index *Index
+	if _, ok, _ := index.List(&aferos3.FsWrapper{Bucket: "bucket"}, "/"); ok {
+		t.Error("a nil index listed a directory")
+	}
+}
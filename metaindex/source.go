@@ -0,0 +1,43 @@
+package metaindex
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Source lists the objects of the buckets of an S3 server.
+type S3Source struct {
+	Client *s3.Client
+}
+
+// ListObjects implements Source.
+func (s *S3Source) ListObjects(ctx context.Context, bucket, prefix string, fn func(page []*Object) error) error {
+	paginator := s3.NewListObjectsV2Paginator(s.Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		page := make([]*Object, 0, len(out.Contents))
+		for _, obj := range out.Contents {
+			page = append(page, &Object{
+				Key:     aws.ToString(obj.Key),
+				Size:    aws.ToInt64(obj.Size),
+				ETag:    aws.ToString(obj.ETag),
+				ModTime: aws.ToTime(obj.LastModified),
+			})
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
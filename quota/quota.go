@@ -0,0 +1,44 @@
+package quota
+
+import (
+	"path"
+	"strconv"
+)
+
+// Limit caps the storage used by a user or a scope. Zero values
+// mean unlimited.
+type Limit struct {
+	Bytes   uint64 `json:"bytes"`
+	Objects uint64 `json:"objects"`
+}
+
+// IsZero tells if the limit doesn't restrict anything.
+func (l Limit) IsZero() bool {
+	return l.Bytes == 0 && l.Objects == 0
+}
+
+// Usage is the storage tracked for a single key, which identifies
+// either a user or a scope.
+type Usage struct {
+	Key       string `json:"key" storm:"id"`
+	Bytes     int64  `json:"bytes"`
+	Objects   int64  `json:"objects"`
+	UpdatedAt int64  `json:"updatedAt"`
+}
+
+// Target is a usage key together with the limit that applies to it.
+type Target struct {
+	Key   string
+	Limit Limit
+}
+
+// UserKey returns the usage key for a user.
+func UserKey(id uint) string {
+	return "user:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// ScopeKey returns the usage key for a scope, identified by its
+// bucket name and root prefix.
+func ScopeKey(name, rootPrefix string) string {
+	return "scope:" + name + ":" + path.Clean("/"+rootPrefix)
+}
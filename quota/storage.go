@@ -0,0 +1,151 @@
+package quota
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+)
+
+// StorageBackend is the interface to implement for a usage storage.
+type StorageBackend interface {
+	All() ([]*Usage, error)
+	Get(key string) (*Usage, error)
+	Save(u *Usage) error
+	Delete(key string) error
+}
+
+// Storage is a storage usage tracker. Usage is updated incrementally
+// as files are written and removed, instead of being recomputed.
+type Storage struct {
+	back StorageBackend
+	mux  sync.Mutex
+	now  func() time.Time
+}
+
+// NewStorage creates a usage storage from a backend.
+func NewStorage(back StorageBackend) *Storage {
+	return &Storage{back: back, now: time.Now}
+}
+
+// All wraps a StorageBackend.All.
+func (s *Storage) All() ([]*Usage, error) {
+	return s.back.All()
+}
+
+// Get wraps a StorageBackend.Get.
+func (s *Storage) Get(key string) (*Usage, error) {
+	return s.back.Get(key)
+}
+
+// Set replaces the usage tracked for a key.
+func (s *Storage) Set(key string, bytes, objects int64) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.back.Save(&Usage{
+		Key:       key,
+		Bytes:     max(bytes, 0),
+		Objects:   max(objects, 0),
+		UpdatedAt: s.now().Unix(),
+	})
+}
+
+// Delete wraps a StorageBackend.Delete.
+func (s *Storage) Delete(key string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.back.Delete(key)
+}
+
+// Check returns fbErrors.ErrQuotaExceeded if adding the given bytes and
+// objects would take any of the targets over its limit. Decreases are
+// always allowed, so that users over quota can still clean up.
+func (s *Storage) Check(targets []Target, bytes, objects int64) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.check(targets, bytes, objects)
+}
+
+// Reserve adds the given bytes and objects to the usage of the targets
+// once checked like Check does, under the same lock: writes reserving
+// room at the same time can't take a target over its limit together.
+func (s *Storage) Reserve(targets []Target, bytes, objects int64) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if err := s.check(targets, bytes, objects); err != nil {
+		return err
+	}
+
+	keys := make([]string, len(targets))
+	for i, t := range targets {
+		keys[i] = t.Key
+	}
+	return s.add(keys, bytes, objects)
+}
+
+func (s *Storage) check(targets []Target, bytes, objects int64) error {
+	for _, t := range targets {
+		if t.Limit.IsZero() {
+			continue
+		}
+
+		u, err := s.get(t.Key)
+		if err != nil {
+			return err
+		}
+
+		if bytes > 0 && t.Limit.Bytes != 0 && u.Bytes+bytes > int64(t.Limit.Bytes) { //nolint:gosec
+			return fbErrors.ErrQuotaExceeded
+		}
+		if objects > 0 && t.Limit.Objects != 0 && u.Objects+objects > int64(t.Limit.Objects) { //nolint:gosec
+			return fbErrors.ErrQuotaExceeded
+		}
+	}
+
+	return nil
+}
+
+// Add adds the given bytes and objects, which may be negative, to the
+// usage of every key.
+func (s *Storage) Add(keys []string, bytes, objects int64) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.add(keys, bytes, objects)
+}
+
+func (s *Storage) add(keys []string, bytes, objects int64) error {
+	if bytes == 0 && objects == 0 {
+		return nil
+	}
+
+	now := s.now().Unix()
+	for _, key := range keys {
+		u, err := s.get(key)
+		if err != nil {
+			return err
+		}
+
+		u.Bytes = max(u.Bytes+bytes, 0)
+		u.Objects = max(u.Objects+objects, 0)
+		u.UpdatedAt = now
+		if err := s.back.Save(u); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) get(key string) (*Usage, error) {
+	u, err := s.back.Get(key)
+	if errors.Is(err, fbErrors.ErrNotExist) {
+		return &Usage{Key: key}, nil
+	}
+	return u, err
+}
@@ -0,0 +1,114 @@
+package quota
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+)
+
+type memBackend map[string]Usage
+
+func (m memBackend) All() ([]*Usage, error) {
+	var v []*Usage
+	for _, u := range m {
+		u := u
+		v = append(v, &u)
+	}
+	return v, nil
+}
+
+func (m memBackend) Get(key string) (*Usage, error) {
+	u, ok := m[key]
+	if !ok {
+		return nil, fbErrors.ErrNotExist
+	}
+	return &u, nil
+}
+
+func (m memBackend) Save(u *Usage) error {
+	m[u.Key] = *u
+	return nil
+}
+
+func (m memBackend) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func TestStorageQuota(t *testing.T) {
+	s := NewStorage(memBackend{})
+	user := Target{Key: UserKey(1), Limit: Limit{Bytes: 100, Objects: 2}}
+	scope := Target{Key: ScopeKey("bucket", "team/"), Limit: Limit{Bytes: 150}}
+	targets := []Target{user, scope}
+	keys := []string{user.Key, scope.Key}
+
+	if err := s.Set(scope.Key, 60, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Check(targets, 91, 1); !errors.Is(err, fbErrors.ErrQuotaExceeded) {
+		t.Errorf("expected scope bytes quota to be exceeded, got %v", err)
+	}
+	if err := s.Check(targets, 80, 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Add(keys, 80, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Check(targets, 30, 1); !errors.Is(err, fbErrors.ErrQuotaExceeded) {
+		t.Errorf("expected user bytes quota to be exceeded, got %v", err)
+	}
+	if err := s.Check(targets, 10, 2); !errors.Is(err, fbErrors.ErrQuotaExceeded) {
+		t.Errorf("expected user objects quota to be exceeded, got %v", err)
+	}
+	if err := s.Check(targets, -50, -1); err != nil {
+		t.Errorf("decreases must always be allowed, got %v", err)
+	}
+
+	if err := s.Add(keys, -200, -10); err != nil {
+		t.Fatal(err)
+	}
+	u, err := s.Get(user.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Bytes != 0 || u.Objects != 0 {
+		t.Errorf("usage must not go below zero, got %d bytes and %d objects", u.Bytes, u.Objects)
+	}
+}
+
+func TestStorageReserve(t *testing.T) {
+	s := NewStorage(memBackend{})
+	target := Target{Key: UserKey(1), Limit: Limit{Bytes: 100}}
+
+	// Concurrent writes can't take the same room.
+	var reserved atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Reserve([]Target{target}, 30, 1); err == nil {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := reserved.Load(); n != 3 {
+		t.Errorf("%d writes of 30 bytes reserved room out of 100, want 3", n)
+	}
+	if u, _ := s.Get(target.Key); u.Bytes != 90 || u.Objects != 3 {
+		t.Errorf("usage = %d bytes, %d objects, want 90 and 3", u.Bytes, u.Objects)
+	}
+}
+
+func TestScopeKey(t *testing.T) {
+	if got, want := ScopeKey("bucket", "team/"), ScopeKey("bucket", "/team"); got != want {
+		t.Errorf("ScopeKey not normalized: %q != %q", got, want)
+	}
+}
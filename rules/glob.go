@@ -0,0 +1,122 @@
+package rules
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrBadGlob is returned when a glob pattern is malformed.
+var ErrBadGlob = errors.New("syntax error in glob pattern")
+
+var globCache sync.Map // pattern -> *regexp.Regexp
+
+// MatchGlob reports whether the path matches a gitignore-style glob
+// pattern:
+//
+//   - "*" matches any sequence of characters except "/", "?" matches a
+//     single one and "[...]" a character class ("[!...]" negates it).
+//   - "**" matches across directories: "**/x" matches x at any depth,
+//     "x/**" everything inside x and "a/**/b" any number of directories
+//     in between.
+//   - A pattern without a slash matches the name at any depth, otherwise
+//     it is anchored to the scope root.
+//   - A pattern that matches a directory also matches everything below it.
+func MatchGlob(pattern, name string) (bool, error) {
+	re, err := compileGlob(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	return re.MatchString(name), nil
+}
+
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	if re, ok := globCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	expr, err := globToRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, ErrBadGlob
+	}
+
+	globCache.Store(pattern, re)
+	return re, nil
+}
+
+func globToRegexp(pattern string) (string, error) {
+	p := strings.TrimSuffix(pattern, "/")
+	if p == "" || p == "/" {
+		return "", ErrBadGlob
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+
+	// Patterns without a slash are matched against the name at any
+	// depth; the rest are anchored at the root.
+	if strings.HasPrefix(p, "/") || strings.Contains(p, "/") {
+		p = strings.TrimPrefix(p, "/")
+	} else {
+		b.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				atStart := i == 0 || p[i-1] == '/'
+				atEnd := i+2 == len(p)
+				switch {
+				case atStart && atEnd:
+					b.WriteString(".*")
+				case atStart && p[i+2] == '/':
+					b.WriteString("(?:.*/)?")
+					i++
+				default:
+					b.WriteString(".*")
+				}
+				i++
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(p[i+1:], ']')
+			if end < 0 {
+				return "", ErrBadGlob
+			}
+			class := p[i+1 : i+1+end]
+			if class == "" || class == "!" {
+				return "", ErrBadGlob
+			}
+			if class[0] == '!' {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 == len(p) {
+				return "", ErrBadGlob
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(string(p[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("(?:/.*)?$")
+	return b.String(), nil
+}
@@ -0,0 +1,92 @@
+package rules
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.exe", "/setup.exe", true},
+		{"*.exe", "/a/b/setup.exe", true},
+		{"*.exe", "/a/setup.exe.txt", false},
+		{"*.exe", "/bin.exe/readme", true},
+		{"/archive/**", "/archive/2020/a.txt", true},
+		{"/archive/**", "/archive", false},
+		{"/archive/**", "/other/archive/a.txt", false},
+		{"/archive", "/archive/a.txt", true},
+		{"/archive", "/archive2", false},
+		{"archive/", "/archive/a.txt", true},
+		{"**/secret", "/secret", true},
+		{"**/secret", "/a/b/secret/key", true},
+		{"/a/**/z", "/a/z", true},
+		{"/a/**/z", "/a/b/c/z", true},
+		{"/a/**/z", "/a/b/zz", false},
+		{"/docs/*.md", "/docs/readme.md", true},
+		{"/docs/*.md", "/docs/sub/readme.md", false},
+		{"/logs/201?", "/logs/2019", true},
+		{"/logs/201[0-4]", "/logs/2015", false},
+		{"/logs/201[!0-4]", "/logs/2015", true},
+		{`/a\*b`, "/a*b", true},
+		{`/a\*b`, "/axb", false},
+		{"docs", "docs/readme.md", true},
+	}
+
+	for _, tc := range cases {
+		got, err := MatchGlob(tc.pattern, tc.path)
+		if err != nil {
+			t.Errorf("MatchGlob(%q, %q) error: %v", tc.pattern, tc.path, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("MatchGlob(%q, %q)=%v; want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestMatchGlobInvalid(t *testing.T) {
+	for _, pattern := range []string{"", "/", "/a[", "/a[]", `/a\`} {
+		if _, err := MatchGlob(pattern, "/a"); err == nil {
+			t.Errorf("MatchGlob(%q) expected error", pattern)
+		}
+	}
+}
+
+func TestRuleAppliesTo(t *testing.T) {
+	all := Rule{}
+	if !all.AppliesTo(OpDelete) || !all.AppliesTo(OpRead) {
+		t.Error("rule without operations should apply to all operations")
+	}
+
+	deleteOnly := Rule{Ops: []Operation{OpDelete}}
+	if !deleteOnly.AppliesTo(OpDelete) {
+		t.Error("rule should apply to delete")
+	}
+	if deleteOnly.AppliesTo(OpRead) {
+		t.Error("rule shouldn't apply to read")
+	}
+}
+
+func TestRuleValidateOps(t *testing.T) {
+	for _, tt := range []struct {
+		ops  []Operation
+		want Operation
+	}{
+		{[]Operation{"upload"}, OpCreate},
+		{[]Operation{"Delete"}, OpDelete},
+	} {
+		r := Rule{Path: "/", Ops: tt.ops}
+		if err := r.Validate(); err != nil {
+			t.Fatalf("Validate(%v): %v", tt.ops, err)
+		}
+		if !r.AppliesTo(tt.want) {
+			t.Errorf("the rule of %v doesn't apply to %s", tt.ops, tt.want)
+		}
+	}
+
+	r := Rule{Path: "/", Ops: []Operation{"teleport"}}
+	if err := r.Validate(); err == nil {
+		t.Error("Validate accepted an unknown operation")
+	}
+}
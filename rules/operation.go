@@ -0,0 +1,41 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Operation is an action performed on a path that rules can
+// be restricted to.
+type Operation string
+
+// Operations a rule can be restricted to.
+const (
+	OpRead     Operation = "read"
+	OpCreate   Operation = "create"
+	OpModify   Operation = "modify"
+	OpRename   Operation = "rename"
+	OpDelete   Operation = "delete"
+	OpShare    Operation = "share"
+	OpDownload Operation = "download"
+)
+
+// Operations lists every known operation.
+var Operations = []Operation{OpRead, OpCreate, OpModify, OpRename, OpDelete, OpShare, OpDownload}
+
+// ParseOperation parses an operation name. "upload" is accepted
+// as an alias of create.
+func ParseOperation(s string) (Operation, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "upload" {
+		return OpCreate, nil
+	}
+
+	for _, op := range Operations {
+		if string(op) == s {
+			return op, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown operation %q", s)
+}
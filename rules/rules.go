@@ -1,22 +1,33 @@
 package rules
 
 import (
+	"fmt"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
 )
 
 // Checker is a Rules checker.
 type Checker interface {
+	// Check reports whether the path can be read. It is equivalent
+	// to CheckOp(OpRead, path).
 	Check(path string) bool
+	// CheckOp reports whether the operation can be performed on the path.
+	CheckOp(op Operation, path string) bool
 }
 
 // Rule is a allow/disallow rule.
 type Rule struct {
 	Regex  bool    `json:"regex"`
+	Glob   bool    `json:"glob"`
 	Allow  bool    `json:"allow"`
 	Path   string  `json:"path"`
 	Regexp *Regexp `json:"regexp"`
+	// Ops restricts the rule to the given operations. A rule
+	// without operations applies to all of them.
+	Ops []Operation `json:"ops,omitempty"`
 }
 
 // MatchHidden matches paths with a basename
@@ -31,9 +42,53 @@ func (r *Rule) Matches(path string) bool {
 		return r.Regexp.MatchString(path)
 	}
 
+	if r.Glob {
+		ok, err := MatchGlob(r.Path, path)
+		return err == nil && ok
+	}
+
 	return strings.HasPrefix(path, r.Path)
 }
 
+// Validate checks that the rule's pattern and operations are valid,
+// normalizing the names of the operations.
+func (r *Rule) Validate() error {
+	if r.Regex && r.Glob {
+		return fmt.Errorf("%w: rule can't be both regex and glob", fbErrors.ErrInvalidRequestParams)
+	}
+
+	if r.Glob {
+		if _, err := compileGlob(r.Path); err != nil {
+			return fmt.Errorf("%w: %w: %q", fbErrors.ErrInvalidRequestParams, err, r.Path)
+		}
+	}
+
+	for i, op := range r.Ops {
+		parsed, err := ParseOperation(string(op))
+		if err != nil {
+			return fmt.Errorf("%w: %w", fbErrors.ErrInvalidRequestParams, err)
+		}
+		r.Ops[i] = parsed
+	}
+
+	return nil
+}
+
+// AppliesTo checks if the rule is evaluated for an operation.
+func (r *Rule) AppliesTo(op Operation) bool {
+	if len(r.Ops) == 0 {
+		return true
+	}
+
+	for _, o := range r.Ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Regexp is a wrapper to the native regexp type where we
 // save the raw expression.
 type Regexp struct {
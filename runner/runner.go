@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/futureharmony/storagebrowser/v2/events"
 	"github.com/futureharmony/storagebrowser/v2/settings"
 	"github.com/futureharmony/storagebrowser/v2/users"
 )
@@ -17,35 +18,41 @@ type Runner struct {
 	*settings.Settings
 }
 
-// RunHook runs the hooks for the before and after event.
-func (r *Runner) RunHook(fn func() error, evt, path, dst string, user *users.User) error {
-	path = user.FullPath(path)
-	dst = user.FullPath(dst)
+// Subscribe makes the commands configured in the current settings run
+// for the events published on the bus. Commands of the after phase only
+// run when the operation succeeded.
+func Subscribe(bus *events.Bus, enabled bool, st *settings.Storage) {
+	if !enabled {
+		return
+	}
 
-	if r.Enabled {
-		if val, ok := r.Commands["before_"+evt]; ok {
-			for _, command := range val {
-				err := r.exec(command, "before_"+evt, path, dst, user)
-				if err != nil {
-					return err
-				}
-			}
+	bus.Subscribe(func(e *events.Event) error {
+		if e.Err != nil {
+			return nil
 		}
-	}
 
-	err := fn()
-	if err != nil {
-		return err
+		set, err := st.Get()
+		if err != nil {
+			return err
+		}
+
+		r := &Runner{Enabled: enabled, Settings: set}
+		return r.Run(e)
+	})
+}
+
+// Run runs the commands of an event.
+func (r *Runner) Run(e *events.Event) error {
+	if !r.Enabled || e.User == nil {
+		return nil
 	}
 
-	if r.Enabled {
-		if val, ok := r.Commands["after_"+evt]; ok {
-			for _, command := range val {
-				err := r.exec(command, "after_"+evt, path, dst, user)
-				if err != nil {
-					return err
-				}
-			}
+	path := e.User.FullPath(e.Path)
+	dst := e.User.FullPath(e.Destination)
+	for _, command := range r.Commands[e.Name()] {
+		err := r.exec(command, e.Name(), path, dst, e.User)
+		if err != nil {
+			return err
 		}
 	}
 
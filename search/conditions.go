@@ -2,15 +2,12 @@ package search
 
 import (
 	"mime"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 )
 
-var (
-	typeRegexp = regexp.MustCompile(`type:(\w+)`)
-)
-
 type condition func(path string) bool
 
 func extensionCondition(extension string) condition {
@@ -40,63 +37,61 @@ func videoCondition(path string) bool {
 	return strings.HasPrefix(mimetype, "video")
 }
 
-func parseSearch(value string) *searchOptions {
-	opts := &searchOptions{
-		CaseSensitive: strings.Contains(value, "case:sensitive"),
-		Conditions:    []condition{},
-		Terms:         []string{},
-	}
-
-	// removes the options from the value
-	value = strings.Replace(value, "case:insensitive", "", -1)
-	value = strings.Replace(value, "case:sensitive", "", -1)
-	value = strings.TrimSpace(value)
-
-	types := typeRegexp.FindAllStringSubmatch(value, -1)
-	for _, t := range types {
-		if len(t) == 1 {
-			continue
-		}
-
-		switch t[1] {
-		case "image":
-			opts.Conditions = append(opts.Conditions, imageCondition)
-		case "audio", "music":
-			opts.Conditions = append(opts.Conditions, audioCondition)
-		case "video":
-			opts.Conditions = append(opts.Conditions, videoCondition)
-		default:
-			opts.Conditions = append(opts.Conditions, extensionCondition(t[1]))
-		}
+// typeCondition returns the condition of a type: operator, a kind of
+// media or else an extension.
+func typeCondition(kind string) condition {
+	switch kind {
+	case "image":
+		return imageCondition
+	case "audio", "music":
+		return audioCondition
+	case "video":
+		return videoCondition
+	default:
+		return extensionCondition(kind)
 	}
+}
 
-	if len(types) > 0 {
-		// Remove the fields from the search value.
-		value = typeRegexp.ReplaceAllString(value, "")
+// extCondition matches the files with an extension, whatever its case.
+func extCondition(extension string) condition {
+	extension = "." + strings.TrimPrefix(extension, ".")
+	return func(path string) bool {
+		return strings.EqualFold(filepath.Ext(path), extension)
 	}
+}
 
-	// If it's case insensitive, put everything in lowercase.
-	if !opts.CaseSensitive {
-		value = strings.ToLower(value)
+// pathCondition matches the paths containing pattern or, when it has
+// wildcards, the paths it matches as a whole or by their end.
+func pathCondition(pattern string, caseSensitive bool) condition {
+	if !caseSensitive {
+		pattern = strings.ToLower(pattern)
 	}
+	glob := strings.ContainsAny(pattern, "*?[")
 
-	// Remove the spaces from the search value.
-	value = strings.TrimSpace(value)
+	return func(p string) bool {
+		if !caseSensitive {
+			p = strings.ToLower(p)
+		}
+		if !glob {
+			return strings.Contains(p, pattern)
+		}
 
-	if value == "" {
-		return opts
+		for {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+			i := strings.Index(p, "/")
+			if i < 0 {
+				return false
+			}
+			p = p[i+1:]
+		}
 	}
+}
 
-	// if the value starts with " and finishes what that character, we will
-	// only search for that term
-	if value[0] == '"' && value[len(value)-1] == '"' {
-		unique := strings.TrimPrefix(value, "\"")
-		unique = strings.TrimSuffix(unique, "\"")
-
-		opts.Terms = []string{unique}
-		return opts
+// regexCondition matches the file names matching re.
+func regexCondition(re *regexp.Regexp) condition {
+	return func(path string) bool {
+		return re.MatchString(filepath.Base(path))
 	}
-
-	opts.Terms = strings.Split(value, " ")
-	return opts
 }
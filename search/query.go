@@ -0,0 +1,626 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+)
+
+// The query language:
+//
+//	query    = or
+//	or       = and { "OR" and }
+//	and      = run { "AND" run }
+//	run      = unary { unary }
+//	unary    = ( "-" | "NOT" ) unary | "(" or ")" | operator | word
+//	operator = name ":" value
+//
+// The words of a run are alternatives, and so are its type: operators,
+// while the rest of the run must match as well: `report draft type:pdf
+// size:>1MB` finds the PDF files larger than 1 MiB whose name contains
+// report or draft. Words and values may be quoted to hold spaces.
+//
+// The operators are:
+//
+//	type:image|audio|video|<extension>
+//	ext:<extension>       extension, whatever its case
+//	path:<text|glob>      path containing text, or matching the glob
+//	regex:<expression>    name matching the regular expression
+//	size:[<|<=|>|>=]<n>   size, with a B, K, M, G or T unit (powers of 1024)
+//	modified:[<|<=|>|>=]<date>
+//	                      date as 2006-01-02, 2006-01 or 2006, or 7d, 12h
+//	                      or 2w ago
+//	content:<text>        text in the file, searched in the content index
+//	case:sensitive|insensitive
+//
+// The files are searched for the content: texts apart from the rest of the
+// query, so a content: operator may be neither negated nor an alternative.
+
+// Query is a parsed search query.
+type Query struct {
+	CaseSensitive bool
+	// Conditions and Terms are the type: operators and the words of the
+	// query, but the negated ones.
+	Conditions []condition
+	Terms      []string
+	// Content holds the texts of the content: operators, which are
+	// searched in the full-text index rather than in file names.
+	Content []string
+
+	root node
+	err  error
+}
+
+// Parse parses a search query.
+func Parse(query string) (*Query, error) {
+	q := parseSearch(query)
+	return q, q.err
+}
+
+// ContentQuery returns the text searched in the contents of the files by
+// the content: operators of the query, empty when it has none.
+func (q *Query) ContentQuery() string {
+	return strings.Join(q.Content, " ")
+}
+
+// Match tells if the file at a path matches the query, but its content:
+// operators. stat, which may be nil, is only called when the query needs
+// the size or the modification time of the file.
+func (q *Query) Match(fPath string, stat func() (os.FileInfo, error)) bool {
+	return q.match(&candidate{path: fPath, stat: stat})
+}
+
+func (q *Query) match(c *candidate) bool {
+	return q.root == nil || q.root.match(c)
+}
+
+// candidate is a file tested against a query. Its info is fetched only
+// when a condition needs it, as that may take a request to S3.
+type candidate struct {
+	path    string
+	info    os.FileInfo
+	stat    func() (os.FileInfo, error)
+	statted bool
+}
+
+func (c *candidate) fileInfo() os.FileInfo {
+	if c.info == nil && c.stat != nil && !c.statted {
+		c.statted = true
+		if info, err := c.stat(); err == nil {
+			c.info = info
+		}
+	}
+	return c.info
+}
+
+type node interface {
+	match(c *candidate) bool
+}
+
+type andNode []node
+
+func (n andNode) match(c *candidate) bool {
+	for _, child := range n {
+		if !child.match(c) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []node
+
+func (n orNode) match(c *candidate) bool {
+	for _, child := range n {
+		if child.match(c) {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct {
+	node
+}
+
+func (n notNode) match(c *candidate) bool {
+	return !n.node.match(c)
+}
+
+type conditionNode condition
+
+func (n conditionNode) match(c *candidate) bool {
+	return n(c.path)
+}
+
+// termNode matches the file names containing a word.
+type termNode struct {
+	term          string
+	caseSensitive bool
+}
+
+func (n termNode) match(c *candidate) bool {
+	_, fileName := path.Split(c.path)
+	if !n.caseSensitive {
+		fileName = strings.ToLower(fileName)
+	}
+	return strings.Contains(fileName, n.term)
+}
+
+// comparison is the comparison operator of size: and modified:.
+type comparison string
+
+const (
+	equal          comparison = ""
+	less           comparison = "<"
+	lessOrEqual    comparison = "<="
+	greater        comparison = ">"
+	greaterOrEqual comparison = ">="
+)
+
+func parseComparison(value string) (comparison, string) {
+	for _, cmp := range []comparison{lessOrEqual, greaterOrEqual, less, greater} {
+		if strings.HasPrefix(value, string(cmp)) {
+			return cmp, value[len(cmp):]
+		}
+	}
+	return equal, strings.TrimPrefix(value, "=")
+}
+
+// sizeNode matches the files, not the directories, of a size.
+type sizeNode struct {
+	cmp  comparison
+	size int64
+}
+
+func (n sizeNode) match(c *candidate) bool {
+	info := c.fileInfo()
+	if info == nil || info.IsDir() {
+		return false
+	}
+
+	switch size := info.Size(); n.cmp {
+	case less:
+		return size < n.size
+	case lessOrEqual:
+		return size <= n.size
+	case greater:
+		return size > n.size
+	case greaterOrEqual:
+		return size >= n.size
+	default:
+		return size == n.size
+	}
+}
+
+var sizeUnits = map[string]int64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+}
+
+func parseSize(value string) (int64, error) {
+	i := strings.IndexFunc(value, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	if i < 0 {
+		i = len(value)
+	}
+
+	n, err := strconv.ParseFloat(value[:i], 64)
+	unit, ok := sizeUnits[strings.ToLower(value[i:])]
+	if err != nil || !ok {
+		return 0, fmt.Errorf("%w: invalid size %q", fbErrors.ErrInvalidRequestParams, value)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// modifiedNode matches the files modified in, before or after the period
+// [from, to).
+type modifiedNode struct {
+	cmp      comparison
+	from, to time.Time
+}
+
+func (n modifiedNode) match(c *candidate) bool {
+	info := c.fileInfo()
+	if info == nil {
+		return false
+	}
+
+	switch t := info.ModTime(); n.cmp {
+	case less:
+		return t.Before(n.from)
+	case lessOrEqual:
+		return t.Before(n.to)
+	case greater:
+		return !t.Before(n.to)
+	case greaterOrEqual:
+		return !t.Before(n.from)
+	default:
+		return !t.Before(n.from) && t.Before(n.to)
+	}
+}
+
+var relativeUnits = map[byte]time.Duration{
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// parsePeriod parses the date of a modified: operator into the period it
+// stands for. A relative date is an instant.
+func parsePeriod(value string, now time.Time) (from, to time.Time, err error) {
+	if len(value) > 1 {
+		if unit, ok := relativeUnits[value[len(value)-1]]; ok {
+			if n, err := strconv.Atoi(value[:len(value)-1]); err == nil {
+				t := now.Add(-time.Duration(n) * unit)
+				return t, t, nil
+			}
+		}
+	}
+
+	for _, layout := range []struct {
+		layout string
+		next   func(t time.Time) time.Time
+	}{
+		{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+		{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+		{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+	} {
+		if t, err := time.ParseInLocation(layout.layout, value, now.Location()); err == nil {
+			return t, layout.next(t), nil
+		}
+	}
+
+	return from, to, fmt.Errorf("%w: invalid date %q", fbErrors.ErrInvalidRequestParams, value)
+}
+
+// contentNode stands for a content: operator, matched by the full-text
+// index and not by the file names.
+type contentNode struct{}
+
+func (contentNode) match(*candidate) bool { return true }
+
+// requiredTerm returns a word the name of every file matching n contains,
+// empty if there is none.
+func requiredTerm(n node) string {
+	switch n := n.(type) {
+	case termNode:
+		return n.term
+	case andNode:
+		for _, child := range n {
+			if term := requiredTerm(child); term != "" {
+				return term
+			}
+		}
+	}
+	return ""
+}
+
+func andOf(nodes []node) node {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	return andNode(nodes)
+}
+
+func orOf(nodes []node) node {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+	return orNode(nodes)
+}
+
+type tokenKind int
+
+const (
+	wordToken tokenKind = iota
+	openToken
+	closeToken
+	notToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits a query into words and parentheses. Parentheses inside
+// a word, as in regex:^(a|b)$, and quoted text are part of the word.
+func tokenize(value string) []token {
+	var (
+		tokens []token
+		word   strings.Builder
+		quoted bool
+		depth  int
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, token{kind: wordToken, text: word.String()})
+			word.Reset()
+		}
+		depth = 0
+	}
+
+	runes := []rune(value)
+	for i, r := range runes {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			word.WriteRune(r)
+		case quoted:
+			word.WriteRune(r)
+		case unicode.IsSpace(r):
+			flush()
+		case r == '(' && word.Len() == 0:
+			tokens = append(tokens, token{kind: openToken})
+		case r == '-' && word.Len() == 0 && i+1 < len(runes) && runes[i+1] == '(':
+			tokens = append(tokens, token{kind: notToken})
+		case r == '(':
+			depth++
+			word.WriteRune(r)
+		case r == ')' && depth > 0:
+			depth--
+			word.WriteRune(r)
+		case r == ')':
+			flush()
+			tokens = append(tokens, token{kind: closeToken})
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	q      *Query
+	now    time.Time
+	// contents counts the content: operators parsed so far.
+	contents int
+}
+
+func (p *parser) keyword(keyword string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == wordToken && p.tokens[p.pos].text == keyword
+}
+
+func (p *parser) closing() bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == closeToken
+}
+
+func (p *parser) fail(err error) {
+	if p.q.err == nil {
+		p.q.err = err
+	}
+}
+
+func (p *parser) parseOr() node {
+	var nodes []node
+	contents := p.contents
+	for {
+		if n := p.parseAnd(); n != nil {
+			nodes = append(nodes, n)
+		}
+		if !p.keyword("OR") {
+			if len(nodes) > 1 && p.contents > contents {
+				p.fail(fmt.Errorf("%w: content: can't be an alternative", fbErrors.ErrInvalidRequestParams))
+			}
+			return orOf(nodes)
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) parseAnd() node {
+	var nodes []node
+	for {
+		if n := p.parseRun(); n != nil {
+			nodes = append(nodes, n)
+		}
+		if !p.keyword("AND") {
+			return andOf(nodes)
+		}
+		p.pos++
+	}
+}
+
+// wordKind tells how a word combines with the others of its run.
+type wordKind int
+
+const (
+	otherWord wordKind = iota
+	termWord
+	typeWord
+)
+
+func (p *parser) parseRun() node {
+	var terms, types, rest []node
+	for p.pos < len(p.tokens) && !p.keyword("OR") && !p.keyword("AND") && !p.closing() {
+		n, kind := p.parseUnary(false)
+		if n == nil {
+			continue
+		}
+
+		switch kind {
+		case termWord:
+			terms = append(terms, n)
+		case typeWord:
+			types = append(types, n)
+		default:
+			rest = append(rest, n)
+		}
+	}
+
+	var nodes []node
+	if n := orOf(types); n != nil {
+		nodes = append(nodes, n)
+	}
+	if n := orOf(terms); n != nil {
+		nodes = append(nodes, n)
+	}
+	return andOf(append(nodes, rest...))
+}
+
+func (p *parser) parseUnary(negated bool) (node, wordKind) {
+	tok := p.tokens[p.pos]
+	p.pos++
+
+	switch {
+	case tok.kind == notToken || tok.kind == wordToken && tok.text == "NOT":
+		if p.pos >= len(p.tokens) || p.closing() {
+			return nil, otherWord
+		}
+		contents := p.contents
+		n, kind := p.parseUnary(!negated)
+		p.checkNotNegated(contents)
+		return negate(n, kind)
+	case tok.kind == openToken:
+		n := p.parseOr()
+		if p.closing() {
+			p.pos++
+		}
+		return n, otherWord
+	case tok.kind == closeToken:
+		p.fail(fmt.Errorf("%w: unbalanced parenthesis", fbErrors.ErrInvalidRequestParams))
+		return nil, otherWord
+	case strings.HasPrefix(tok.text, "-") && len(tok.text) > 1:
+		contents := p.contents
+		n, kind := p.parseWord(tok.text[1:], !negated)
+		p.checkNotNegated(contents)
+		return negate(n, kind)
+	default:
+		return p.parseWord(tok.text, negated)
+	}
+}
+
+// checkNotNegated fails if content: operators were parsed in a negation
+// since contents were counted.
+func (p *parser) checkNotNegated(contents int) {
+	if p.contents > contents {
+		p.fail(fmt.Errorf("%w: content: can't be negated", fbErrors.ErrInvalidRequestParams))
+	}
+}
+
+func negate(n node, _ wordKind) (node, wordKind) {
+	if n == nil {
+		return nil, otherWord
+	}
+	return notNode{n}, otherWord
+}
+
+func unquote(value string) string {
+	return strings.ReplaceAll(value, `"`, "")
+}
+
+func (p *parser) parseWord(word string, negated bool) (node, wordKind) {
+	name, value, ok := strings.Cut(word, ":")
+	if !ok || strings.Contains(name, `"`) {
+		return p.parseTerm(word, negated)
+	}
+	value = unquote(value)
+
+	switch name {
+	case "case":
+		return nil, otherWord
+	case "type":
+		c := typeCondition(value)
+		if !negated {
+			p.q.Conditions = append(p.q.Conditions, c)
+		}
+		return conditionNode(c), typeWord
+	case "ext":
+		return conditionNode(extCondition(value)), otherWord
+	case "path":
+		return conditionNode(pathCondition(value, p.q.CaseSensitive)), otherWord
+	case "regex":
+		if !p.q.CaseSensitive {
+			value = "(?i)" + value
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			p.fail(fmt.Errorf("%w: %w", fbErrors.ErrInvalidRequestParams, err))
+			return nil, otherWord
+		}
+		return conditionNode(regexCondition(re)), otherWord
+	case "size":
+		cmp, value := parseComparison(value)
+		size, err := parseSize(value)
+		if err != nil {
+			p.fail(err)
+			return nil, otherWord
+		}
+		return sizeNode{cmp: cmp, size: size}, otherWord
+	case "modified":
+		cmp, value := parseComparison(value)
+		from, to, err := parsePeriod(value, p.now)
+		if err != nil {
+			p.fail(err)
+			return nil, otherWord
+		}
+		return modifiedNode{cmp: cmp, from: from, to: to}, otherWord
+	case "content":
+		p.contents++
+		if value != "" {
+			p.q.Content = append(p.q.Content, value)
+		}
+		return contentNode{}, otherWord
+	default:
+		return p.parseTerm(word, negated)
+	}
+}
+
+func (p *parser) parseTerm(word string, negated bool) (node, wordKind) {
+	term := unquote(word)
+	if term == "" {
+		return nil, otherWord
+	}
+	if !p.q.CaseSensitive {
+		term = strings.ToLower(term)
+	}
+
+	if !negated {
+		p.q.Terms = append(p.q.Terms, term)
+	}
+	return termNode{term: term, caseSensitive: p.q.CaseSensitive}, termWord
+}
+
+func parseSearch(value string) *Query {
+	return parseQuery(value, time.Now())
+}
+
+func parseQuery(value string, now time.Time) *Query {
+	q := &Query{
+		CaseSensitive: strings.Contains(value, "case:sensitive"),
+		Conditions:    []condition{},
+		Terms:         []string{},
+	}
+
+	p := &parser{tokens: tokenize(value), q: q, now: now}
+	q.root = p.parseOr()
+	for p.pos < len(p.tokens) {
+		// Only a closing parenthesis stops the parser before the end.
+		p.pos++
+		p.fail(fmt.Errorf("%w: unbalanced parenthesis", fbErrors.ErrInvalidRequestParams))
+	}
+
+	return q
+}
@@ -0,0 +1,135 @@
+package search
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+)
+
+type testFileInfo struct {
+	os.FileInfo
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i testFileInfo) Size() int64        { return i.size }
+func (i testFileInfo) ModTime() time.Time { return i.modTime }
+func (i testFileInfo) IsDir() bool        { return i.dir }
+
+func TestQueryMatch(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	files := map[string]testFileInfo{
+		"/docs/Report-2025.pdf":    {size: 2 << 20, modTime: time.Date(2025, 12, 20, 0, 0, 0, 0, time.UTC)},
+		"/docs/draft.PDF":          {size: 10 << 10, modTime: time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)},
+		"/docs/notes.txt":          {size: 300, modTime: time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)},
+		"/photos/IMG_0001.jpg":     {size: 4 << 20, modTime: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+		"/photos/IMG_0002.png":     {size: 150 << 20, modTime: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		"/photos/report-cover.jpg": {size: 1 << 20, modTime: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		"/photos/trip":             {dir: true, modTime: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"report", []string{"/docs/Report-2025.pdf", "/photos/report-cover.jpg"}},
+		{"report draft", []string{"/docs/Report-2025.pdf", "/docs/draft.PDF", "/photos/report-cover.jpg"}},
+		{"report AND cover", []string{"/photos/report-cover.jpg"}},
+		{"report type:pdf", []string{"/docs/Report-2025.pdf"}},
+		{"type:image -report", []string{"/photos/IMG_0001.jpg", "/photos/IMG_0002.png"}},
+		{"ext:pdf", []string{"/docs/Report-2025.pdf", "/docs/draft.PDF"}},
+		{"size:>100MB", []string{"/photos/IMG_0002.png"}},
+		{"size:<=10K", []string{"/docs/draft.PDF", "/docs/notes.txt"}},
+		{"modified:<2026-01-01", []string{"/docs/Report-2025.pdf", "/photos/IMG_0001.jpg"}},
+		{"modified:2026-01-01", []string{"/docs/notes.txt"}},
+		{"modified:>=2026-03 -type:image", []string{"/docs/draft.PDF", "/photos/trip"}},
+		{"modified:>7d", []string{"/docs/draft.PDF"}},
+		{"path:docs/*.txt", []string{"/docs/notes.txt"}},
+		{"path:PHOTOS/img", []string{"/photos/IMG_0001.jpg", "/photos/IMG_0002.png"}},
+		{`regex:^img_\d+\.(jpg|png)$`, []string{"/photos/IMG_0001.jpg", "/photos/IMG_0002.png"}},
+		{"IMG case:sensitive", []string{"/photos/IMG_0001.jpg", "/photos/IMG_0002.png"}},
+		{"img case:sensitive", []string{}},
+		{"(report OR notes) AND path:docs", []string{"/docs/Report-2025.pdf", "/docs/notes.txt"}},
+		{"ext:pdf OR size:>100M", []string{"/docs/Report-2025.pdf", "/docs/draft.PDF", "/photos/IMG_0002.png"}},
+		{"-(type:image OR ext:pdf) NOT trip", []string{"/docs/notes.txt"}},
+		{`"report-cover"`, []string{"/photos/report-cover.jpg"}},
+		{"content:budget notes", []string{"/docs/notes.txt"}},
+	}
+
+	for _, tt := range tests {
+		q := parseQuery(tt.query, now)
+		if q.err != nil {
+			t.Errorf("parse %q: %v", tt.query, q.err)
+			continue
+		}
+
+		got := []string{}
+		for _, p := range []string{
+			"/docs/Report-2025.pdf", "/docs/draft.PDF", "/docs/notes.txt", "/photos/IMG_0001.jpg",
+			"/photos/IMG_0002.png", "/photos/report-cover.jpg", "/photos/trip",
+		} {
+			if q.match(&candidate{path: p, info: files[p]}) {
+				got = append(got, p)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%q matches %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueryInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, query := range []string{"size:>lots", "modified:yesterday", "regex:(", "report)",
+		"-content:old", "NOT (content:old report)", "content:budget OR report", "(report OR content:budget) draft"} {
+		if _, err := Parse(query); !errors.Is(err, fbErrors.ErrInvalidRequestParams) {
+			t.Errorf("Parse(%q) error = %v, want invalid request params", query, err)
+		}
+	}
+}
+
+func TestQueryStatsLazily(t *testing.T) {
+	t.Parallel()
+
+	stats := 0
+	stat := func() (os.FileInfo, error) {
+		stats++
+		return testFileInfo{size: 2 << 30}, nil
+	}
+
+	q, _ := Parse("report size:>1G")
+	if q.Match("/notes.txt", stat) || stats != 0 {
+		t.Errorf("a file whose name doesn't match was stated %d times", stats)
+	}
+	if !q.Match("/report.bin", stat) || stats != 1 {
+		t.Errorf("a matching file was stated %d times, want 1", stats)
+	}
+}
+
+func TestQueryContentAndHint(t *testing.T) {
+	t.Parallel()
+
+	q, err := Parse(`content:"quarterly budget" content:draft type:pdf report`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := q.ContentQuery(); got != "quarterly budget draft" {
+		t.Errorf("ContentQuery() = %q", got)
+	}
+	if got := requiredTerm(q.root); got != "report" {
+		t.Errorf("requiredTerm = %q, want report", got)
+	}
+
+	q, _ = Parse("report OR draft")
+	if got := requiredTerm(q.root); got != "" {
+		t.Errorf("requiredTerm of alternatives = %q, want none", got)
+	}
+}
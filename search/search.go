@@ -14,26 +14,25 @@ import (
 	"github.com/futureharmony/storagebrowser/v2/rules"
 )
 
-type searchOptions struct {
-	CaseSensitive bool
-	Conditions    []condition
-	Terms         []string
+// Index walks file systems faster than themselves.
+type Index interface {
+	// Walk calls fn with the files and directories below root of a file
+	// system, or returns false when it can't walk it.
+	Walk(fs afero.Fs, root string, fn func(p string, info os.FileInfo) error) (bool, error)
 }
 
-// Search searches for a query in a fs.
-func Search(fs afero.Fs, scope, query string, checker rules.Checker, found func(path string, f os.FileInfo) error) error {
-	search := parseSearch(query)
+// Search searches for a query in a fs, walking it with index when it can.
+// index may be nil.
+func Search(fs afero.Fs, index Index, scope, query string, checker rules.Checker, found func(path string, f os.FileInfo) error) error {
+	q, err := Parse(query)
+	if err != nil {
+		return err
+	}
 
 	scope = filepath.ToSlash(filepath.Clean(scope))
 	scope = path.Join("/", scope)
 
-	// Check if this is an S3 filesystem for efficient search
-	if s3wrapper, ok := fs.(*s3lib.FsWrapper); ok {
-		return s3SearchOptimized(s3wrapper, scope, query, checker, found)
-	}
-
-	// Use the original implementation for all filesystem types
-	return afero.Walk(fs, scope, func(fPath string, f os.FileInfo, _ error) error {
+	visit := func(fPath string, f os.FileInfo) error {
 		fPath = filepath.ToSlash(filepath.Clean(fPath))
 		fPath = path.Join("/", fPath)
 		relativePath := strings.TrimPrefix(fPath, scope)
@@ -43,64 +42,66 @@ func Search(fs afero.Fs, scope, query string, checker rules.Checker, found func(
 			return nil
 		}
 
-		if !checker.Check(fPath) {
+		if !checker.Check(fPath) || !q.match(&candidate{path: fPath, info: f}) {
 			return nil
 		}
 
-		if len(search.Conditions) > 0 {
-			match := false
-
-			for _, t := range search.Conditions {
-				if t(fPath) {
-					match = true
-					break
-				}
-			}
+		return found(relativePath, f)
+	}
 
-			if !match {
-				return nil
-			}
+	if index != nil {
+		if ok, err := index.Walk(fs, scope, visit); ok {
+			return err
 		}
+	}
 
-		if len(search.Terms) > 0 {
-			for _, term := range search.Terms {
-				_, fileName := path.Split(fPath)
-				if !search.CaseSensitive {
-					fileName = strings.ToLower(fileName)
-					term = strings.ToLower(term)
-				}
-				if strings.Contains(fileName, term) {
-					return found(relativePath, f)
-				}
-			}
-			return nil
-		}
+	// Check if this is an S3 filesystem for efficient search
+	if s3wrapper, ok := fs.(*s3lib.FsWrapper); ok {
+		return s3SearchOptimized(s3wrapper, scope, query, checker, found)
+	}
 
-		return found(relativePath, f)
+	// Use the original implementation for all filesystem types
+	return afero.Walk(fs, scope, func(fPath string, f os.FileInfo, _ error) error {
+		return visit(fPath, f)
 	})
 }
 
-// s3SearchOptimized performs efficient S3 search using library/afero-s3 SearchDeep method
+// s3SearchOptimized performs efficient S3 search using library/afero-s3 SearchDeep method.
+// SearchDeep only narrows the objects down by a word their name must
+// contain, so the query is then applied to each of them, stating those it
+// needs the size or the modification time of.
 func s3SearchOptimized(s3fs *s3lib.FsWrapper, scope, query string, checker rules.Checker, found func(path string, f os.FileInfo) error) error {
-	prefix := scope
-	if prefix == "/" {
-		prefix = ""
+	q, err := Parse(query)
+	if err != nil {
+		return err
 	}
 
-	return s3fs.SearchDeep(scope, query, func(relPath string, isDir bool) error {
+	return s3fs.SearchDeep(scope, requiredTerm(q.root), func(relPath string, isDir bool) error {
 		fullPath := path.Join("/", relPath)
 		if !checker.Check(fullPath) {
 			return nil
 		}
 
-		mockInfo := &s3FileInfo{
-			name:    relPath,
-			size:    0,
-			modTime: time.Now(),
-			isDir:   isDir,
+		c := &candidate{
+			path: fullPath,
+			stat: func() (os.FileInfo, error) {
+				return s3fs.Stat(fullPath)
+			},
+		}
+		if !q.match(c) {
+			return nil
 		}
 
-		return found(relPath, mockInfo)
+		info := c.info
+		if info == nil {
+			info = &s3FileInfo{
+				name:    relPath,
+				size:    0,
+				modTime: time.Now(),
+				isDir:   isDir,
+			}
+		}
+		return found(relPath, info)
 	})
 }
 
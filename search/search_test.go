@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	s3lib "github.com/futureharmony/afero-aws-s3"
+
+	"github.com/futureharmony/storagebrowser/v2/rules"
 )
 
 type mockChecker struct {
@@ -15,6 +17,10 @@ func (m *mockChecker) Check(path string) bool {
 	return m.allowed
 }
 
+func (m *mockChecker) CheckOp(_ rules.Operation, _ string) bool {
+	return m.allowed
+}
+
 func TestSearchDetectsS3AndUsesEfficientMethod(t *testing.T) {
 	t.Parallel()
 
@@ -29,7 +35,7 @@ func TestSearchDetectsS3AndUsesEfficientMethod(t *testing.T) {
 
 		checker := &mockChecker{allowed: true}
 
-		err := Search(mockFs, "/", "test", checker, func(path string, f os.FileInfo) error {
+		err := Search(mockFs, nil, "/", "test", checker, func(path string, f os.FileInfo) error {
 			return nil
 		})
 
@@ -50,7 +56,7 @@ func TestSearchDetectsS3AndUsesEfficientMethod(t *testing.T) {
 		var detectedS3 bool
 		checker := &mockChecker{allowed: true}
 
-		_ = Search(mockFs, "/", "test", checker, func(path string, f os.FileInfo) error {
+		_ = Search(mockFs, nil, "/", "test", checker, func(path string, f os.FileInfo) error {
 			detectedS3 = true
 			return nil
 		})
@@ -2,6 +2,7 @@ package settings
 
 import (
 	"github.com/futureharmony/storagebrowser/v2/files"
+	"github.com/futureharmony/storagebrowser/v2/quota"
 	"github.com/futureharmony/storagebrowser/v2/users"
 )
 
@@ -14,6 +15,7 @@ type UserDefaults struct {
 	SingleClick  bool              `json:"singleClick"`
 	Sorting      files.Sorting     `json:"sorting"`
 	Perm         users.Permissions `json:"perm"`
+	Quota        quota.Limit       `json:"quota"`
 	Commands     []string          `json:"commands"`
 	HideDotfiles bool              `json:"hideDotfiles"`
 	DateFormat   bool              `json:"dateFormat"`
@@ -26,6 +28,7 @@ func (d *UserDefaults) Apply(u *users.User) {
 	u.ViewMode = d.ViewMode
 	u.SingleClick = d.SingleClick
 	u.Perm = d.Perm
+	u.Quota = d.Quota
 	u.Sorting = d.Sorting
 	u.Commands = d.Commands
 	u.HideDotfiles = d.HideDotfiles
@@ -0,0 +1,16 @@
+package settings
+
+const DefaultLockoutMaxAttempts = 5
+const DefaultLockoutDuration = 15 * 60 // 15 minutes
+const DefaultLockoutBackoff = 1
+const DefaultLockoutMaxBackoff = 30
+
+// Lockout contains the login brute-force protection settings of the app.
+// All durations are expressed in seconds.
+type Lockout struct {
+	Disabled    bool `json:"disabled"`
+	MaxAttempts uint `json:"maxAttempts"`
+	Duration    uint `json:"duration"`
+	Backoff     uint `json:"backoff"`
+	MaxBackoff  uint `json:"maxBackoff"`
+}
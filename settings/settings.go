@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/futureharmony/storagebrowser/v2/rules"
+	"github.com/futureharmony/storagebrowser/v2/upload"
 )
 
 const DefaultUsersHomeBasePath = "/users"
@@ -29,7 +30,10 @@ type Settings struct {
 	AuthMethod            AuthMethod          `json:"authMethod"`
 	Branding              Branding            `json:"branding"`
 	Tus                   Tus                 `json:"tus"`
+	Lockout               Lockout             `json:"lockout"`
+	Upload                upload.Policy       `json:"upload"`
 	Commands              map[string][]string `json:"commands"`
+	Webhooks              Webhooks            `json:"webhooks"`
 	Shell                 []string            `json:"shell"`
 	Rules                 []rules.Rule        `json:"rules"`
 	MinimumPasswordLength uint                `json:"minimumPasswordLength"`
@@ -52,6 +56,10 @@ type Server struct {
 	Port                  string `json:"port"`
 	Address               string `json:"address"`
 	Log                   string `json:"log"`
+	AuditLog              string `json:"auditLog"`
+	ContentIndex          string `json:"contentIndex"`
+	MetadataIndex         string `json:"metadataIndex"`
+	MetadataRescan        string `json:"metadataRescan"`
 	EnableThumbnails      bool   `json:"enableThumbnails"`
 	ResizePreview         bool   `json:"resizePreview"`
 	EnableExec            bool   `json:"enableExec"`
@@ -63,6 +71,9 @@ type Server struct {
 	S3AccessKey           string `json:"s3AccessKey"`
 	S3SecretKey           string `json:"s3SecretKey"`
 	S3Region              string `json:"s3Region"`
+	// BucketEventsToken enables the bucket notifications endpoint for
+	// the ones that carry it.
+	BucketEventsToken string `json:"bucketEventsToken"`
 }
 
 // Clean cleans any variables that might need cleaning.
@@ -99,6 +110,21 @@ func (s *Server) GetTokenExpirationTime(fallback time.Duration) time.Duration {
 	return duration
 }
 
+// GetMetadataRescan returns how often the metadata index rescans the
+// buckets.
+func (s *Server) GetMetadataRescan(fallback time.Duration) time.Duration {
+	if s.MetadataRescan == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(s.MetadataRescan)
+	if err != nil {
+		log.Printf("[WARN] Failed to parse metadataRescan: %v", err)
+		return fallback
+	}
+	return duration
+}
+
 // Validate validates the server configuration.
 func (s *Server) Validate() error {
 	if s.StorageType == "s3" {
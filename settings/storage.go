@@ -42,6 +42,23 @@ func (s *Storage) Get() (*Settings, error) {
 			RetryCount: DefaultTusRetryCount,
 		}
 	}
+	if set.Lockout == (Lockout{}) {
+		set.Lockout = Lockout{
+			MaxAttempts: DefaultLockoutMaxAttempts,
+			Duration:    DefaultLockoutDuration,
+			Backoff:     DefaultLockoutBackoff,
+			MaxBackoff:  DefaultLockoutMaxBackoff,
+		}
+	}
+	if set.Webhooks.MaxAttempts == 0 {
+		set.Webhooks.MaxAttempts = DefaultWebhookMaxAttempts
+	}
+	if set.Webhooks.Backoff == 0 {
+		set.Webhooks.Backoff = DefaultWebhookBackoff
+	}
+	if set.Webhooks.Timeout == 0 {
+		set.Webhooks.Timeout = DefaultWebhookTimeout
+	}
 	if set.FileMode == 0 {
 		set.FileMode = DefaultFileMode
 	}
@@ -54,6 +71,7 @@ func (s *Storage) Get() (*Settings, error) {
 var defaultEvents = []string{
 	"save",
 	"copy",
+	"compress",
 	"rename",
 	"upload",
 	"delete",
@@ -89,6 +107,10 @@ func (s *Storage) Save(set *Settings) error {
 		set.Commands = map[string][]string{}
 	}
 
+	if set.Webhooks.Events == nil {
+		set.Webhooks.Events = map[string][]string{}
+	}
+
 	for _, event := range defaultEvents {
 		if _, ok := set.Commands["before_"+event]; !ok {
 			set.Commands["before_"+event] = []string{}
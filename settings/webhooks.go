@@ -0,0 +1,18 @@
+package settings
+
+const DefaultWebhookMaxAttempts = 3
+const DefaultWebhookBackoff = 1
+const DefaultWebhookTimeout = 10
+
+// Webhooks contains the HTTP webhooks notified of file events. Events
+// are named like the keys of Settings.Commands, e.g. "after_upload",
+// besides "share_revoked", sent when a share link reaches its download
+// limit. All durations are expressed in seconds.
+type Webhooks struct {
+	// Secret signs the payloads with HMAC-SHA256 when not empty.
+	Secret      string              `json:"secret"`
+	MaxAttempts uint                `json:"maxAttempts"`
+	Backoff     uint                `json:"backoff"`
+	Timeout     uint                `json:"timeout"`
+	Events      map[string][]string `json:"events"`
+}
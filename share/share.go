@@ -1,20 +1,77 @@
 package share
 
+import "time"
+
 type CreateBody struct {
-	Password string `json:"password"`
-	Expires  string `json:"expires"`
-	Unit     string `json:"unit"`
+	Password      string `json:"password"`
+	Expires       string `json:"expires"`
+	Unit          string `json:"unit"`
+	Description   string `json:"description"`
+	Upload        bool   `json:"upload"`
+	HideListing   bool   `json:"hideListing"`
+	MaxUploadSize int64  `json:"maxUploadSize"`
+	MaxUploads    int    `json:"maxUploads"`
+	MaxDownloads  int    `json:"maxDownloads"`
+}
+
+// UpdateBody is the body of an edit of a link. The fields left out are
+// kept, while an empty password or expiry removes it.
+type UpdateBody struct {
+	Password    *string `json:"password"`
+	Expires     *string `json:"expires"`
+	Unit        string  `json:"unit"`
+	Description *string `json:"description"`
 }
 
 // Link is the information needed to build a shareable link.
 type Link struct {
-	Hash         string `json:"hash" storm:"id,index"`
-	Path         string `json:"path" storm:"index"`
-	UserID       uint   `json:"userID"`
+	Hash   string `json:"hash" storm:"id,index"`
+	Path   string `json:"path" storm:"index"`
+	UserID uint   `json:"userID"`
+	// Description is a note of the owner about the link.
+	Description string `json:"description,omitempty"`
+	// Bucket and RootPrefix are the scope the link was created in, which
+	// Path is relative to whatever the scope its owner is in later.
+	Bucket       string `json:"bucket,omitempty"`
+	RootPrefix   string `json:"rootPrefix,omitempty"`
 	Expire       int64  `json:"expire"`
 	PasswordHash string `json:"password_hash,omitempty"`
 	// Token is a random value that will only be set when PasswordHash is set. It is
 	// URL-Safe and is used to download links in password-protected shares via a
 	// query arg.
 	Token string `json:"token,omitempty"`
+	// Upload makes the link a file drop: its visitors upload files into
+	// the shared directory instead of downloading from it, and only see
+	// its content unless HideListing is set.
+	Upload      bool `json:"upload,omitempty"`
+	HideListing bool `json:"hideListing,omitempty"`
+	// MaxUploadSize and MaxUploads limit the size of each file and the
+	// number of files uploaded through a file drop, when not zero.
+	MaxUploadSize int64 `json:"maxUploadSize,omitempty"`
+	MaxUploads    int   `json:"maxUploads,omitempty"`
+	Uploads       int   `json:"uploads,omitempty"`
+	// MaxDownloads revokes the link once it was downloaded that many
+	// times, when not zero. Revoked links no longer resolve but are kept
+	// along with their access log until deleted.
+	MaxDownloads int  `json:"maxDownloads,omitempty"`
+	Downloads    int  `json:"downloads,omitempty"`
+	Revoked      bool `json:"revoked,omitempty"`
+}
+
+// Expired tells if the link is expired at t.
+func (l *Link) Expired(t time.Time) bool {
+	return l.Expire != 0 && l.Expire <= t.Unix()
+}
+
+// Access is the record of a download made through a link.
+type Access struct {
+	ID   uint64    `json:"id" storm:"id,increment"`
+	Hash string    `json:"hash" storm:"index"`
+	Time time.Time `json:"time"`
+	IP   string    `json:"ip"`
+	// Path is the file fetched, relative to the shared directory for
+	// directory links.
+	Path string `json:"path"`
+	// Size is the number of bytes sent.
+	Size int64 `json:"size"`
 }
@@ -1,9 +1,13 @@
 package share
 
 import (
+	"context"
+	"errors"
+	"log"
+	"sync"
 	"time"
 
-	"github.com/futureharmony/storagebrowser/v2/errors"
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
 )
 
 // StorageBackend is the interface to implement for a share storage.
@@ -15,17 +19,29 @@ type StorageBackend interface {
 	Gets(path string, id uint) ([]*Link, error)
 	Save(s *Link) error
 	Delete(hash string) error
-	DeleteWithPathPrefix(path string) error
+	DeleteWithPathPrefix(bucket, path string) error
+	LogAccess(a *Access) error
+	// Accesses returns the latest accesses of a link, newest first, up
+	// to limit when it's not zero.
+	Accesses(hash string, limit int) ([]*Access, error)
 }
 
+// ResumeWindow is how long after a counted download the ranged requests
+// of its client continue it rather than count as other downloads.
+const ResumeWindow = time.Hour
+
 // Storage is a storage.
 type Storage struct {
 	back StorageBackend
+	mu   sync.Mutex
+	// downloads holds when the downloads were counted, by link hash and
+	// client.
+	downloads map[string]time.Time
 }
 
 // NewStorage creates a share links storage from a backend.
 func NewStorage(back StorageBackend) *Storage {
-	return &Storage{back: back}
+	return &Storage{back: back, downloads: map[string]time.Time{}}
 }
 
 // All wraps a StorageBackend.All.
@@ -36,16 +52,7 @@ func (s *Storage) All() ([]*Link, error) {
 		return nil, err
 	}
 
-	for i, link := range links {
-		if link.Expire != 0 && link.Expire <= time.Now().Unix() {
-			if err := s.Delete(link.Hash); err != nil {
-				return nil, err
-			}
-			links = append(links[:i], links[i+1:]...)
-		}
-	}
-
-	return links, nil
+	return s.dropExpired(links)
 }
 
 // FindByUserID wraps a StorageBackend.FindByUserID.
@@ -56,30 +63,26 @@ func (s *Storage) FindByUserID(id uint) ([]*Link, error) {
 		return nil, err
 	}
 
-	for i, link := range links {
-		if link.Expire != 0 && link.Expire <= time.Now().Unix() {
-			if err := s.Delete(link.Hash); err != nil {
-				return nil, err
-			}
-			links = append(links[:i], links[i+1:]...)
-		}
-	}
-
-	return links, nil
+	return s.dropExpired(links)
 }
 
-// GetByHash wraps a StorageBackend.GetByHash.
+// GetByHash wraps a StorageBackend.GetByHash. Revoked links don't
+// exist for it.
 func (s *Storage) GetByHash(hash string) (*Link, error) {
 	link, err := s.back.GetByHash(hash)
 	if err != nil {
 		return nil, err
 	}
 
-	if link.Expire != 0 && link.Expire <= time.Now().Unix() {
+	if link.Revoked {
+		return nil, fbErrors.ErrNotExist
+	}
+
+	if link.Expired(time.Now()) {
 		if err := s.Delete(link.Hash); err != nil {
 			return nil, err
 		}
-		return nil, errors.ErrNotExist
+		return nil, fbErrors.ErrNotExist
 	}
 
 	return link, nil
@@ -98,16 +101,7 @@ func (s *Storage) Gets(path string, id uint) ([]*Link, error) {
 		return nil, err
 	}
 
-	for i, link := range links {
-		if link.Expire != 0 && link.Expire <= time.Now().Unix() {
-			if err := s.Delete(link.Hash); err != nil {
-				return nil, err
-			}
-			links = append(links[:i], links[i+1:]...)
-		}
-	}
-
-	return links, nil
+	return s.dropExpired(links)
 }
 
 // Save wraps a StorageBackend.Save
@@ -120,6 +114,162 @@ func (s *Storage) Delete(hash string) error {
 	return s.back.Delete(hash)
 }
 
-func (s *Storage) DeleteWithPathPrefix(path string) error {
-	return s.back.DeleteWithPathPrefix(path)
+// DeleteWithPathPrefix deletes the links of a bucket whose path from the
+// root of the bucket begins with path.
+func (s *Storage) DeleteWithPathPrefix(bucket, path string) error {
+	return s.back.DeleteWithPathPrefix(bucket, path)
+}
+
+// Update edits the link with fn, which must not change its counters,
+// and saves it unless fn fails. The link is returned as updated.
+func (s *Storage) Update(hash string, fn func(l *Link) error) (*Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, err := s.GetByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = fn(link); err != nil {
+		return nil, err
+	}
+
+	return link, s.back.Save(link)
+}
+
+// AddUploads counts n files uploaded through a file drop, a negative n
+// giving back uploads that failed. It fails with errors.ErrUploadLimit
+// when the link would exceed its maximum number of uploads.
+func (s *Storage) AddUploads(hash string, n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, err := s.GetByHash(hash)
+	if err != nil {
+		return err
+	}
+
+	if n > 0 && link.MaxUploads != 0 && link.Uploads+n > link.MaxUploads {
+		return fbErrors.ErrUploadLimit
+	}
+	link.Uploads = max(link.Uploads+n, 0)
+
+	return s.back.Save(link)
+}
+
+// AddDownload counts a download through a link by a client, revoking the
+// link once it reaches its maximum number of downloads. It must be called
+// before serving the download: a link revoked meanwhile doesn't exist.
+// The link is returned as updated.
+func (s *Storage) AddDownload(hash, client string) (*Link, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, err := s.GetByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	link.Downloads++
+	if link.MaxDownloads != 0 && link.Downloads >= link.MaxDownloads {
+		link.Revoked = true
+	}
+	if err = s.back.Save(link); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for key, counted := range s.downloads {
+		if now.Sub(counted) > ResumeWindow {
+			delete(s.downloads, key)
+		}
+	}
+	s.downloads[hash+" "+client] = now
+
+	return link, nil
+}
+
+// Resumes tells if a client had a download through a link counted less
+// than ResumeWindow ago, which its ranged requests continue.
+func (s *Storage) Resumes(hash, client string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counted, ok := s.downloads[hash+" "+client]
+	return ok && time.Since(counted) <= ResumeWindow
+}
+
+// LogAccess wraps a StorageBackend.LogAccess.
+func (s *Storage) LogAccess(a *Access) error {
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	return s.back.LogAccess(a)
+}
+
+// Accesses wraps a StorageBackend.Accesses.
+func (s *Storage) Accesses(hash string, limit int) ([]*Access, error) {
+	return s.back.Accesses(hash, limit)
+}
+
+// dropExpired deletes the expired links and returns the others.
+func (s *Storage) dropExpired(links []*Link) ([]*Link, error) {
+	now := time.Now()
+	kept := links[:0]
+	for _, link := range links {
+		if !link.Expired(now) {
+			kept = append(kept, link)
+			continue
+		}
+		if err := s.Delete(link.Hash); err != nil {
+			return nil, err
+		}
+	}
+
+	return kept, nil
+}
+
+// DeleteExpired deletes the links expired at now and returns how many
+// there were.
+func (s *Storage) DeleteExpired(now time.Time) (int, error) {
+	links, err := s.back.All()
+	if errors.Is(err, fbErrors.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, link := range links {
+		if !link.Expired(now) {
+			continue
+		}
+		if err := s.Delete(link.Hash); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// Sweep deletes the expired links every interval, until ctx is done.
+// Links are otherwise only deleted once they're looked up after they
+// expired.
+func (s *Storage) Sweep(ctx context.Context, interval time.Duration) {
+	for {
+		if n, err := s.DeleteExpired(time.Now()); err != nil {
+			log.Printf("share: deleting expired links: %v", err)
+		} else if n > 0 {
+			log.Printf("share: deleted %d expired links", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
 }
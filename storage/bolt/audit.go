@@ -0,0 +1,28 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/asdine/storm/v3"
+
+	"github.com/futureharmony/storagebrowser/v2/audit"
+)
+
+type auditBackend struct {
+	db *storm.DB
+}
+
+func (s auditBackend) Append(e *audit.Entry) error {
+	e.ID = 0
+	return s.db.Save(e)
+}
+
+func (s auditBackend) Each(fn func(e *audit.Entry) error) error {
+	err := s.db.Select().Reverse().Each(new(audit.Entry), func(record interface{}) error {
+		return fn(record.(*audit.Entry))
+	})
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -1,23 +1,48 @@
 package bolt
 
 import (
+	"errors"
+
 	"github.com/asdine/storm/v3"
 
+	"github.com/futureharmony/storagebrowser/v2/audit"
 	"github.com/futureharmony/storagebrowser/v2/auth"
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/jobs"
+	"github.com/futureharmony/storagebrowser/v2/lockout"
+	"github.com/futureharmony/storagebrowser/v2/quota"
 	"github.com/futureharmony/storagebrowser/v2/settings"
 	"github.com/futureharmony/storagebrowser/v2/share"
 	"github.com/futureharmony/storagebrowser/v2/storage"
 	"github.com/futureharmony/storagebrowser/v2/users"
+	"github.com/futureharmony/storagebrowser/v2/webhook"
 )
 
 // NewStorage creates a storage.Storage based on Bolt DB.
 func NewStorage(db *storm.DB) (*storage.Storage, error) {
 	userStore := users.NewStorage(usersBackend{db: db})
+	groupStore := users.NewGroupStorage(groupsBackend{db: db})
 	shareStore := share.NewStorage(shareBackend{db: db})
 	settingsStore := settings.NewStorage(settingsBackend{db: db})
 	authStore := auth.NewStorage(authBackend{db: db}, userStore)
+	lockoutStore := lockout.NewStorage(lockoutBackend{db: db})
+	usageStore := quota.NewStorage(quotaBackend{db: db})
+	auditStore := audit.NewStorage(auditBackend{db: db})
+	webhookStore := webhook.NewStorage(webhookBackend{db: db})
+	jobStore := jobs.NewStorage(jobsBackend{db: db})
+
+	var version int
+	if err := get(db, "version", &version); err != nil && !errors.Is(err, fbErrors.ErrNotExist) {
+		return nil, err
+	}
+	// Version 3 binds the share links to the scope they were created in.
+	if version < 3 {
+		if err := migrateShareScopes(db); err != nil {
+			return nil, err
+		}
+	}
 
-	err := save(db, "version", 2)
+	err := save(db, "version", 3)
 	if err != nil {
 		return nil, err
 	}
@@ -25,7 +50,13 @@ func NewStorage(db *storm.DB) (*storage.Storage, error) {
 	return &storage.Storage{
 		Auth:     authStore,
 		Users:    userStore,
+		Groups:   groupStore,
 		Share:    shareStore,
 		Settings: settingsStore,
+		Lockout:  lockoutStore,
+		Usage:    usageStore,
+		Audit:    auditStore,
+		Webhooks: webhookStore,
+		Jobs:     jobStore,
 	}, nil
 }
@@ -0,0 +1,156 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/asdine/storm/v3"
+	bolt "go.etcd.io/bbolt"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/fulltext"
+)
+
+var (
+	fulltextDocsBucket  = []byte("fulltextDocs")
+	fulltextTermsBucket = []byte("fulltextTerms")
+)
+
+// NewContentIndex creates a full-text index stored in a Bolt DB. The
+// index is kept apart from the main database since it can be large and
+// rebuilt at any time.
+func NewContentIndex(db *storm.DB) (*fulltext.Index, error) {
+	err := db.Bolt.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(fulltextDocsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(fulltextTermsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fulltext.NewIndex(fulltextBackend{db: db.Bolt}), nil
+}
+
+type fulltextRecord struct {
+	fulltext.Document
+	Terms []string `json:"terms"`
+}
+
+type fulltextBackend struct {
+	db *bolt.DB
+}
+
+func (s fulltextBackend) Get(id string) (*fulltext.Document, error) {
+	var rec fulltextRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(fulltextDocsBucket).Get([]byte(id))
+		if raw == nil {
+			return fbErrors.ErrNotExist
+		}
+		return json.Unmarshal(raw, &rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rec.Document, nil
+}
+
+func (s fulltextBackend) Put(doc *fulltext.Document, freqs map[string]int) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := deleteFulltextDoc(tx, doc.ID); err != nil {
+			return err
+		}
+
+		rec := fulltextRecord{Document: *doc, Terms: make([]string, 0, len(freqs))}
+		terms := tx.Bucket(fulltextTermsBucket)
+		for term, freq := range freqs {
+			postings, err := terms.CreateBucketIfNotExists([]byte(term))
+			if err != nil {
+				return err
+			}
+			if err := postings.Put([]byte(doc.ID), binary.AppendUvarint(nil, uint64(freq))); err != nil { //nolint:gosec
+				return err
+			}
+			rec.Terms = append(rec.Terms, term)
+		}
+
+		raw, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(fulltextDocsBucket).Put([]byte(doc.ID), raw)
+	})
+}
+
+func (s fulltextBackend) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return deleteFulltextDoc(tx, id)
+	})
+}
+
+func (s fulltextBackend) Postings(term string) (map[string]int, error) {
+	postings := map[string]int{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(fulltextTermsBucket).Bucket([]byte(term))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			freq, _ := binary.Uvarint(v)
+			postings[string(k)] = int(freq) //nolint:gosec
+			return nil
+		})
+	})
+
+	return postings, err
+}
+
+func (s fulltextBackend) IDs(prefix string) ([]string, error) {
+	var ids []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(fulltextDocsBucket).Cursor()
+		for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Next() {
+			ids = append(ids, string(k))
+		}
+		return nil
+	})
+
+	return ids, err
+}
+
+// deleteFulltextDoc removes a document and its postings, if it exists.
+func deleteFulltextDoc(tx *bolt.Tx, id string) error {
+	docs := tx.Bucket(fulltextDocsBucket)
+	raw := docs.Get([]byte(id))
+	if raw == nil {
+		return nil
+	}
+
+	var rec fulltextRecord
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return err
+	}
+
+	terms := tx.Bucket(fulltextTermsBucket)
+	for _, term := range rec.Terms {
+		postings := terms.Bucket([]byte(term))
+		if postings == nil {
+			continue
+		}
+		if err := postings.Delete([]byte(id)); err != nil {
+			return err
+		}
+		if k, _ := postings.Cursor().First(); k == nil {
+			if err := terms.DeleteBucket([]byte(term)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return docs.Delete([]byte(id))
+}
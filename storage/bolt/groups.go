@@ -0,0 +1,60 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/asdine/storm/v3"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+type groupsBackend struct {
+	db *storm.DB
+}
+
+func (st groupsBackend) GetBy(i interface{}) (*users.Group, error) {
+	group := &users.Group{}
+
+	var arg string
+	switch i.(type) {
+	case uint:
+		arg = "ID"
+	case string:
+		arg = "Name"
+	default:
+		return nil, fbErrors.ErrInvalidDataType
+	}
+
+	err := st.db.One(arg, i, group)
+	if err != nil {
+		if errors.Is(err, storm.ErrNotFound) {
+			return nil, fbErrors.ErrNotExist
+		}
+		return nil, err
+	}
+
+	return group, nil
+}
+
+func (st groupsBackend) Gets() ([]*users.Group, error) {
+	var allGroups []*users.Group
+	err := st.db.All(&allGroups)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil, fbErrors.ErrNotExist
+	}
+
+	return allGroups, err
+}
+
+func (st groupsBackend) Save(group *users.Group) error {
+	err := st.db.Save(group)
+	if errors.Is(err, storm.ErrAlreadyExists) {
+		return fbErrors.ErrExist
+	}
+	return err
+}
+
+func (st groupsBackend) DeleteByID(id uint) error {
+	return st.db.DeleteStruct(&users.Group{ID: id})
+}
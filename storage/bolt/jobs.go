@@ -0,0 +1,58 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/asdine/storm/v3"
+	"github.com/asdine/storm/v3/q"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/jobs"
+)
+
+type jobsBackend struct {
+	db *storm.DB
+}
+
+func (s jobsBackend) Save(j *jobs.Job) error {
+	return s.db.Save(j)
+}
+
+func (s jobsBackend) Get(id uint64) (*jobs.Job, error) {
+	var v jobs.Job
+	err := s.db.One("ID", id, &v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil, fbErrors.ErrNotExist
+	}
+
+	return &v, err
+}
+
+func (s jobsBackend) All() ([]*jobs.Job, error) {
+	var v []*jobs.Job
+	err := s.db.Select().Reverse().Find(&v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return v, fbErrors.ErrNotExist
+	}
+
+	return v, err
+}
+
+func (s jobsBackend) FindByUserID(id uint) ([]*jobs.Job, error) {
+	var v []*jobs.Job
+	err := s.db.Select(q.Eq("UserID", id)).Reverse().Find(&v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return v, fbErrors.ErrNotExist
+	}
+
+	return v, err
+}
+
+func (s jobsBackend) Delete(id uint64) error {
+	err := s.db.DeleteStruct(&jobs.Job{ID: id})
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+
+	return err
+}
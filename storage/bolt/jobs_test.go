@@ -0,0 +1,187 @@
+package bolt
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asdine/storm/v3"
+
+	"github.com/futureharmony/storagebrowser/v2/jobs"
+)
+
+func newJobStorage(t *testing.T) *jobs.Storage {
+	t.Helper()
+
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return jobs.NewStorage(jobsBackend{db: db})
+}
+
+// waitJob waits for a job to be over and returns its record.
+func waitJob(t *testing.T, s *jobs.Storage, id uint64) *jobs.Job {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		j, err := s.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j.Over() {
+			return j
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("job %d still not over", id)
+	return nil
+}
+
+func TestJobsSubmit(t *testing.T) {
+	s := newJobStorage(t)
+
+	ok := &jobs.Job{UserID: 1, Type: "copy", Path: "/a", Destination: "/b"}
+	err := s.Submit(ok, func(_ context.Context, tr *jobs.Tracker) error {
+		tr.SetTotal(2, 30)
+		tr.Add(1, 10)
+		tr.Add(1, 20)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := &jobs.Job{UserID: 2, Type: "delete", Path: "/c"}
+	err = s.Submit(failed, func(context.Context, *jobs.Tracker) error {
+		return errors.New("disk on fire")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j := waitJob(t, s, ok.ID)
+	if j.Status != jobs.Done || j.ObjectsDone != 2 || j.BytesDone != 30 || j.BytesTotal != 30 {
+		t.Errorf("got job %+v, want it done with its progress", j)
+	}
+	j = waitJob(t, s, failed.ID)
+	if j.Status != jobs.Failed || j.Error != "disk on fire" {
+		t.Errorf("got job %+v, want it failed with its error", j)
+	}
+
+	list, err := s.FindByUserID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != ok.ID {
+		t.Errorf("got %d jobs for the user, want the one submitted", len(list))
+	}
+	list, err = s.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].ID != failed.ID {
+		t.Errorf("got %d jobs, want 2 newest first", len(list))
+	}
+}
+
+func TestJobsCancel(t *testing.T) {
+	s := newJobStorage(t)
+
+	started := make(chan struct{})
+	j := &jobs.Job{UserID: 1, Type: "compress", Path: "/a"}
+	err := s.Submit(j, func(ctx context.Context, _ *jobs.Tracker) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-started
+	if err = s.Cancel(j.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := waitJob(t, s, j.ID); got.Status != jobs.Canceled {
+		t.Errorf("got status %q, want %q", got.Status, jobs.Canceled)
+	}
+
+	// The job is over: canceling it again does nothing.
+	if err = s.Cancel(j.ID); err != nil {
+		t.Errorf("canceling a job over: %v", err)
+	}
+}
+
+func TestJobsRecover(t *testing.T) {
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	for _, j := range []*jobs.Job{
+		{Type: "copy", Status: jobs.Running},
+		{Type: "copy", Status: jobs.Done, Finished: now.Add(-time.Hour)},
+		{Type: "copy", Status: jobs.Done, Finished: now.Add(-jobs.Retention - time.Hour)},
+	} {
+		if err = db.Save(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := jobs.NewStorage(jobsBackend{db: db})
+	if err = s.Recover(); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := s.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("got %d jobs, want the expired one deleted", len(list))
+	}
+	for _, j := range list {
+		if j.ID == 1 && j.Status != jobs.Failed {
+			t.Errorf("got the interrupted job %q, want it failed", j.Status)
+		}
+	}
+}
+
+func TestJobsPrune(t *testing.T) {
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	old := time.Now().Add(-jobs.Retention - time.Hour)
+	for _, j := range []*jobs.Job{
+		{Type: "copy", Status: jobs.Done, Finished: old},
+		{Type: "copy", Status: jobs.Running, Started: old},
+		{Type: "copy", Status: jobs.Failed, Finished: time.Now()},
+	} {
+		if err = db.Save(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := jobs.NewStorage(jobsBackend{db: db})
+	n, err := s.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("pruned %d jobs, want only the one over for long", n)
+	}
+	if _, err = s.Get(2); err != nil {
+		t.Errorf("the running job was pruned: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/asdine/storm/v3"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/lockout"
+)
+
+type lockoutBackend struct {
+	db *storm.DB
+}
+
+func (s lockoutBackend) All() ([]*lockout.Attempt, error) {
+	var v []*lockout.Attempt
+	err := s.db.All(&v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return v, fbErrors.ErrNotExist
+	}
+
+	return v, err
+}
+
+func (s lockoutBackend) Get(key string) (*lockout.Attempt, error) {
+	var v lockout.Attempt
+	err := s.db.One("Key", key, &v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil, fbErrors.ErrNotExist
+	}
+
+	return &v, err
+}
+
+func (s lockoutBackend) Save(a *lockout.Attempt) error {
+	return s.db.Save(a)
+}
+
+func (s lockoutBackend) Delete(key string) error {
+	err := s.db.DeleteStruct(&lockout.Attempt{Key: key})
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+	return err
+}
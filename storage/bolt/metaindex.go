@@ -0,0 +1,171 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/asdine/storm/v3"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/futureharmony/storagebrowser/v2/metaindex"
+)
+
+var (
+	metadataObjectsBucket = []byte("metadataObjects")
+	metadataScansBucket   = []byte("metadataScans")
+)
+
+// NewMetadataIndex creates a metadata index of the objects listed by
+// source, stored in a Bolt DB. Like the content index, it's kept apart
+// from the main database.
+func NewMetadataIndex(db *storm.DB, source metaindex.Source) (*metaindex.Index, error) {
+	err := db.Bolt.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(metadataObjectsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(metadataScansBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return metaindex.NewIndex(metadataBackend{db: db.Bolt}, source), nil
+}
+
+type metadataBackend struct {
+	db *bolt.DB
+}
+
+func (s metadataBackend) Replace(bucket, from, to string, objs []*metaindex.Object) (int, error) {
+	changed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(metadataObjectsBucket).CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+
+		stored := map[string]*metaindex.Object{}
+		c := b.Cursor()
+		for k, v := c.Seek([]byte(from)); k != nil && (to == "" || bytes.Compare(k, []byte(to)) < 0); k, v = c.Next() {
+			o := &metaindex.Object{Key: string(k)}
+			if err := json.Unmarshal(v, o); err != nil {
+				return err
+			}
+			stored[o.Key] = o
+		}
+
+		for _, o := range objs {
+			if old, ok := stored[o.Key]; ok {
+				delete(stored, o.Key)
+				if old.Equal(o) {
+					continue
+				}
+			}
+
+			raw, err := json.Marshal(o)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(o.Key), raw); err != nil {
+				return err
+			}
+			changed++
+		}
+
+		for key := range stored {
+			if err := b.Delete([]byte(key)); err != nil {
+				return err
+			}
+			changed++
+		}
+		return nil
+	})
+
+	return changed, err
+}
+
+func (s metadataBackend) List(bucket, prefix string) ([]*metaindex.Object, error) {
+	objs := []*metaindex.Object{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(metadataObjectsBucket).Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		k, v := c.Seek([]byte(prefix))
+		for k != nil && bytes.HasPrefix(k, []byte(prefix)) {
+			name := k[len(prefix):]
+			if len(name) == 0 {
+				// The marker of the directory itself.
+				k, v = c.Next()
+				continue
+			}
+
+			if i := bytes.IndexByte(name, '/'); i >= 0 {
+				dir := prefix + string(name[:i+1])
+				objs = append(objs, &metaindex.Object{Key: dir, Dir: true})
+				// Skip the objects of the directory, '0' following '/'.
+				k, v = c.Seek([]byte(dir[:len(dir)-1] + "0"))
+				continue
+			}
+
+			o := &metaindex.Object{Key: string(k)}
+			if err := json.Unmarshal(v, o); err != nil {
+				return err
+			}
+			objs = append(objs, o)
+			k, v = c.Next()
+		}
+		return nil
+	})
+
+	return objs, err
+}
+
+func (s metadataBackend) Walk(bucket, prefix string, fn func(o *metaindex.Object) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(metadataObjectsBucket).Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		for k, v := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = c.Next() {
+			o := &metaindex.Object{Key: string(k)}
+			if err := json.Unmarshal(v, o); err != nil {
+				return err
+			}
+			if err := fn(o); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s metadataBackend) Scanned(bucket string) (time.Time, error) {
+	var t time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(metadataScansBucket).Get([]byte(bucket))
+		if raw == nil {
+			return nil
+		}
+		return t.UnmarshalBinary(raw)
+	})
+
+	return t, err
+}
+
+func (s metadataBackend) SetScanned(bucket string, t time.Time) error {
+	raw, err := t.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metadataScansBucket).Put([]byte(bucket), raw)
+	})
+}
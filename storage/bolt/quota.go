@@ -0,0 +1,46 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/asdine/storm/v3"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/quota"
+)
+
+type quotaBackend struct {
+	db *storm.DB
+}
+
+func (s quotaBackend) All() ([]*quota.Usage, error) {
+	var v []*quota.Usage
+	err := s.db.All(&v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return v, fbErrors.ErrNotExist
+	}
+
+	return v, err
+}
+
+func (s quotaBackend) Get(key string) (*quota.Usage, error) {
+	var v quota.Usage
+	err := s.db.One("Key", key, &v)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil, fbErrors.ErrNotExist
+	}
+
+	return &v, err
+}
+
+func (s quotaBackend) Save(u *quota.Usage) error {
+	return s.db.Save(u)
+}
+
+func (s quotaBackend) Delete(key string) error {
+	err := s.db.DeleteStruct(&quota.Usage{Key: key})
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+	return err
+}
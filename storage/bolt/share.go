@@ -2,12 +2,15 @@ package bolt
 
 import (
 	"errors"
+	"path"
+	"strings"
 
 	"github.com/asdine/storm/v3"
 	"github.com/asdine/storm/v3/q"
 
 	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
 	"github.com/futureharmony/storagebrowser/v2/share"
+	"github.com/futureharmony/storagebrowser/v2/users"
 )
 
 type shareBackend struct {
@@ -70,21 +73,89 @@ func (s shareBackend) Save(l *share.Link) error {
 
 func (s shareBackend) Delete(hash string) error {
 	err := s.db.DeleteStruct(&share.Link{Hash: hash})
+	if err != nil && !errors.Is(err, storm.ErrNotFound) {
+		return err
+	}
+
+	err = s.db.Select(q.Eq("Hash", hash)).Delete(new(share.Access))
 	if errors.Is(err, storm.ErrNotFound) {
 		return nil
 	}
 	return err
 }
 
-func (s shareBackend) DeleteWithPathPrefix(pathPrefix string) error {
+func (s shareBackend) DeleteWithPathPrefix(bucket, pathPrefix string) error {
 	var links []share.Link
-	if err := s.db.Prefix("Path", pathPrefix, &links); err != nil {
+	err := s.db.Select(q.Eq("Bucket", bucket)).Find(&links)
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
 		return err
 	}
 
-	var err error
+	pathPrefix = path.Clean("/" + pathPrefix)
 	for _, link := range links {
-		err = errors.Join(err, s.db.DeleteStruct(&share.Link{Hash: link.Hash}))
+		p := path.Join("/", link.RootPrefix, link.Path)
+		if pathPrefix != "/" && p != pathPrefix && !strings.HasPrefix(p, pathPrefix+"/") {
+			continue
+		}
+		err = errors.Join(err, s.Delete(link.Hash))
 	}
 	return err
 }
+
+func (s shareBackend) LogAccess(a *share.Access) error {
+	a.ID = 0
+	return s.db.Save(a)
+}
+
+func (s shareBackend) Accesses(hash string, limit int) ([]*share.Access, error) {
+	accesses := []*share.Access{}
+	query := s.db.Select(q.Eq("Hash", hash)).Reverse()
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	err := query.Find(&accesses)
+	if errors.Is(err, storm.ErrNotFound) {
+		return []*share.Access{}, nil
+	}
+	return accesses, err
+}
+
+// migrateShareScopes binds the links created before they recorded their
+// scope to the current scope of their owner, which is the one they were
+// resolved against until then.
+func migrateShareScopes(db *storm.DB) error {
+	var links []*share.Link
+	if err := db.All(&links); err != nil && !errors.Is(err, storm.ErrNotFound) {
+		return err
+	}
+
+	for _, link := range links {
+		if link.Bucket != "" || link.RootPrefix != "" {
+			continue
+		}
+
+		var owner users.User
+		err := db.One("ID", link.UserID, &owner)
+		if errors.Is(err, storm.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if owner.CurrentScope.Name == "" && owner.CurrentScope.RootPrefix == "" {
+			continue
+		}
+
+		link.Bucket = owner.CurrentScope.Name
+		link.RootPrefix = owner.CurrentScope.RootPrefix
+		if err := db.Save(link); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package bolt
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asdine/storm/v3"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/share"
+	"github.com/futureharmony/storagebrowser/v2/users"
+)
+
+func TestMigrateShareScopes(t *testing.T) {
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	owner := &users.User{ID: 1, Username: "alice", CurrentScope: users.Scope{Name: "photos", RootPrefix: "/2025"}}
+	if err := db.Save(owner); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range []*share.Link{
+		{Hash: "old", Path: "/trip", UserID: 1},
+		{Hash: "bound", Path: "/docs", UserID: 1, Bucket: "docs", RootPrefix: "/"},
+		{Hash: "orphan", Path: "/gone", UserID: 2},
+	} {
+		if err := db.Save(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := save(db, "version", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := NewStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for hash, want := range map[string]users.Scope{
+		"old":    {Name: "photos", RootPrefix: "/2025"},
+		"bound":  {Name: "docs", RootPrefix: "/"},
+		"orphan": {},
+	} {
+		l, err := st.Share.GetByHash(hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Bucket != want.Name || l.RootPrefix != want.RootPrefix {
+			t.Errorf("link %s bound to %q %q, want %q %q", hash, l.Bucket, l.RootPrefix, want.Name, want.RootPrefix)
+		}
+	}
+
+	// The migration runs once: links are only bound by their creation
+	// from then on.
+	if err := db.Save(&share.Link{Hash: "new", Path: "/new", UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStorage(db); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := st.Share.GetByHash("new"); l.Bucket != "" {
+		t.Errorf("link created after the migration bound to %q", l.Bucket)
+	}
+}
+
+func TestShareDeleteWithPathPrefix(t *testing.T) {
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	st, err := NewStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for hash, p := range map[string]string{"dir": "/docs", "file": "/docs/a.txt", "sibling": "/docs-old/b.txt"} {
+		if err := st.Share.Save(&share.Link{Hash: hash, Path: p, UserID: 1, Bucket: "bucket"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := st.Share.DeleteWithPathPrefix("bucket", "/docs"); err != nil {
+		t.Fatal(err)
+	}
+	for hash, kept := range map[string]bool{"dir": false, "file": false, "sibling": true} {
+		if _, err := st.Share.GetByHash(hash); (err == nil) != kept {
+			t.Errorf("link %s: kept %v, want %v", hash, err == nil, kept)
+		}
+	}
+}
+
+func TestShareDownloadLimit(t *testing.T) {
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	st, err := NewStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Share.Save(&share.Link{Hash: "h", Path: "/a.txt", UserID: 1, MaxDownloads: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, wantRevoked := range []bool{false, true} {
+		link, err := st.Share.AddDownload("h", "192.0.2.1 /a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.Downloads != i+1 || link.Revoked != wantRevoked {
+			t.Errorf("download %d: %d downloads, revoked %v", i+1, link.Downloads, link.Revoked)
+		}
+		if err := st.Share.LogAccess(&share.Access{Hash: "h", IP: "192.0.2.1", Path: "/a.txt", Size: int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := st.Share.AddDownload("h", "192.0.2.1 /a.txt"); !errors.Is(err, fbErrors.ErrNotExist) {
+		t.Errorf("download of a revoked link: %v", err)
+	}
+	if !st.Share.Resumes("h", "192.0.2.1 /a.txt") || st.Share.Resumes("h", "192.0.2.2 /a.txt") {
+		t.Error("only the client of a counted download resumes it")
+	}
+	if _, err := st.Share.GetByHash("h"); !errors.Is(err, fbErrors.ErrNotExist) {
+		t.Errorf("revoked link resolved: %v", err)
+	}
+
+	// Revoked links are still listed, with their access log.
+	links, err := st.Share.All()
+	if err != nil || len(links) != 1 || !links[0].Revoked {
+		t.Fatalf("All() = %v, %v", links, err)
+	}
+	accesses, err := st.Share.Accesses("h", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accesses) != 1 || accesses[0].Size != 1 {
+		t.Errorf("latest access = %+v", accesses)
+	}
+
+	if err := st.Share.Delete("h"); err != nil {
+		t.Fatal(err)
+	}
+	if accesses, _ = st.Share.Accesses("h", 0); len(accesses) != 0 {
+		t.Errorf("%d accesses left after deleting the link", len(accesses))
+	}
+}
+
+func TestShareDownloadLimitConcurrent(t *testing.T) {
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	st, err := NewStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Share.Save(&share.Link{Hash: "h", Path: "/a.txt", UserID: 1, MaxDownloads: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	var counted atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := st.Share.AddDownload("h", strconv.Itoa(i)); err == nil {
+				counted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := counted.Load(); n != 1 {
+		t.Errorf("%d downloads counted, want 1", n)
+	}
+}
+
+func TestShareUpdate(t *testing.T) {
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	st, err := NewStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Share.Save(&share.Link{Hash: "h", Path: "/a.txt", UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Edits don't lose the downloads counted meanwhile.
+	const n = 20
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := st.Share.AddDownload("h", "192.0.2.1 /a.txt"); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := st.Share.Update("h", func(l *share.Link) error {
+				l.Description = strconv.Itoa(i)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	link, err := st.Share.GetByHash("h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Downloads != n {
+		t.Errorf("got %d downloads, want %d", link.Downloads, n)
+	}
+
+	errDenied := errors.New("denied")
+	if _, err := st.Share.Update("h", func(*share.Link) error { return errDenied }); !errors.Is(err, errDenied) {
+		t.Errorf("got error %v, want %v", err, errDenied)
+	}
+}
+
+func TestShareExpiry(t *testing.T) {
+	db, err := storm.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	st, err := NewStorage(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for _, l := range []*share.Link{
+		{Hash: "a", Path: "/a", UserID: 1, Expire: now.Add(-time.Hour).Unix()},
+		{Hash: "b", Path: "/b", UserID: 1, Expire: now.Add(-time.Minute).Unix()},
+		{Hash: "c", Path: "/c", UserID: 1},
+		{Hash: "d", Path: "/d", UserID: 1, Expire: now.Add(time.Hour).Unix()},
+		{Hash: "e", Path: "/e", UserID: 1, Expire: now.Add(2 * time.Hour).Unix()},
+	} {
+		if err := st.Share.Save(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Consecutive expired links are all dropped.
+	links, err := st.Share.FindByUserID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashes []string
+	for _, l := range links {
+		hashes = append(hashes, l.Hash)
+	}
+	if got := strings.Join(hashes, ","); got != "c,d,e" {
+		t.Errorf("FindByUserID(1) = %s, want c,d,e", got)
+	}
+
+	n, err := st.Share.DeleteExpired(now.Add(90 * time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("DeleteExpired deleted %d links, want 1", n)
+	}
+	if _, err := st.Share.GetByHash("d"); !errors.Is(err, fbErrors.ErrNotExist) {
+		t.Errorf("swept link still exists: %v", err)
+	}
+	if _, err := st.Share.GetByHash("e"); err != nil {
+		t.Errorf("unexpired link was swept: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package bolt
+
+import (
+	"errors"
+
+	"github.com/asdine/storm/v3"
+
+	"github.com/futureharmony/storagebrowser/v2/webhook"
+)
+
+type webhookBackend struct {
+	db *storm.DB
+}
+
+func (s webhookBackend) Save(d *webhook.Delivery) error {
+	return s.db.Save(d)
+}
+
+func (s webhookBackend) Each(fn func(d *webhook.Delivery) error) error {
+	err := s.db.Select().Reverse().Each(new(webhook.Delivery), func(record interface{}) error {
+		return fn(record.(*webhook.Delivery))
+	})
+	if errors.Is(err, storm.ErrNotFound) {
+		return nil
+	}
+	return err
+}
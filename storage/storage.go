@@ -1,17 +1,35 @@
 package storage
 
 import (
+	"github.com/futureharmony/storagebrowser/v2/audit"
 	"github.com/futureharmony/storagebrowser/v2/auth"
+	"github.com/futureharmony/storagebrowser/v2/fulltext"
+	"github.com/futureharmony/storagebrowser/v2/jobs"
+	"github.com/futureharmony/storagebrowser/v2/lockout"
+	"github.com/futureharmony/storagebrowser/v2/metaindex"
+	"github.com/futureharmony/storagebrowser/v2/quota"
 	"github.com/futureharmony/storagebrowser/v2/settings"
 	"github.com/futureharmony/storagebrowser/v2/share"
 	"github.com/futureharmony/storagebrowser/v2/users"
+	"github.com/futureharmony/storagebrowser/v2/webhook"
 )
 
 // Storage is a storage powered by a Backend which makes the necessary
 // verifications when fetching and saving data to ensure consistency.
 type Storage struct {
 	Users    users.Store
+	Groups   *users.GroupStorage
 	Share    *share.Storage
 	Auth     *auth.Storage
 	Settings *settings.Storage
+	Lockout  *lockout.Storage
+	Usage    *quota.Storage
+	Audit    *audit.Storage
+	Webhooks *webhook.Storage
+	Jobs     *jobs.Storage
+	// Content is the full-text index of the files, nil when disabled.
+	Content *fulltext.Index
+	// Metadata is the index of the objects of the buckets, nil when
+	// disabled.
+	Metadata *metaindex.Index
 }
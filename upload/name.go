@@ -0,0 +1,55 @@
+package upload
+
+import (
+	"path"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+)
+
+// reservedChars are replaced in file names since they are not allowed
+// on Windows or have a special meaning in shells and URLs.
+const reservedChars = `<>:"\|?*`
+
+// NormalizeName returns the file name in Unicode NFC form, with reserved
+// and control characters replaced by an underscore and trailing dots and
+// spaces removed.
+func NormalizeName(name string) (string, error) {
+	name = norm.NFC.String(name)
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(reservedChars, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(name, ". ")
+
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return "", fbErrors.ErrInvalidRequestParams
+	}
+	return name, nil
+}
+
+// NormalizePath normalizes the last element of a path with NormalizeName,
+// keeping a trailing slash. Parent directories are left untouched since
+// they may already exist under their current names, and so is the root.
+func NormalizePath(p string) (string, error) {
+	dir := strings.HasSuffix(p, "/")
+	parent, name := path.Split(strings.TrimSuffix(p, "/"))
+	if name == "" {
+		return p, nil
+	}
+
+	name, err := NormalizeName(name)
+	if err != nil {
+		return "", err
+	}
+
+	p = parent + name
+	if dir {
+		p += "/"
+	}
+	return p, nil
+}
@@ -0,0 +1,124 @@
+package upload
+
+import (
+	"path"
+	"strings"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/files"
+)
+
+// SniffLen is the number of leading bytes needed to sniff the type
+// of an upload.
+const SniffLen = 512
+
+// Policy restricts the files that can be uploaded. Empty values don't
+// restrict anything.
+type Policy struct {
+	// MaxSize is the maximum size of a single file in bytes.
+	MaxSize uint64 `json:"maxSize"`
+	// AllowedExtensions, when not empty, are the only extensions that
+	// can be uploaded, e.g. ".jpg".
+	AllowedExtensions []string `json:"allowedExtensions"`
+	BlockedExtensions []string `json:"blockedExtensions"`
+	// AllowedTypes, when not empty, are the only MIME types that can be
+	// uploaded. Types are sniffed from the content and can end with a
+	// wildcard, e.g. "image/*".
+	AllowedTypes []string `json:"allowedTypes"`
+	BlockedTypes []string `json:"blockedTypes"`
+	// NormalizeNames normalizes the names of uploaded files to Unicode
+	// NFC and replaces characters that are reserved on common systems.
+	NormalizeNames bool `json:"normalizeNames"`
+}
+
+// Clean normalizes the lists of the policy.
+func (p *Policy) Clean() {
+	for i, ext := range p.AllowedExtensions {
+		p.AllowedExtensions[i] = cleanExtension(ext)
+	}
+	for i, ext := range p.BlockedExtensions {
+		p.BlockedExtensions[i] = cleanExtension(ext)
+	}
+	for i, typ := range p.AllowedTypes {
+		p.AllowedTypes[i] = strings.ToLower(strings.TrimSpace(typ))
+	}
+	for i, typ := range p.BlockedTypes {
+		p.BlockedTypes[i] = strings.ToLower(strings.TrimSpace(typ))
+	}
+}
+
+// CheckSize returns fbErrors.ErrUploadTooLarge if a file of the given
+// size can't be uploaded.
+func (p *Policy) CheckSize(size int64) error {
+	if p.MaxSize != 0 && size > 0 && uint64(size) > p.MaxSize {
+		return fbErrors.ErrUploadTooLarge
+	}
+	return nil
+}
+
+// CheckName returns fbErrors.ErrUploadNotAllowed if the extension of
+// the file name can't be uploaded.
+func (p *Policy) CheckName(name string) error {
+	ext := strings.ToLower(path.Ext(name))
+
+	if containsExtension(p.BlockedExtensions, ext) {
+		return fbErrors.ErrUploadNotAllowed
+	}
+	if len(p.AllowedExtensions) > 0 && !containsExtension(p.AllowedExtensions, ext) {
+		return fbErrors.ErrUploadNotAllowed
+	}
+
+	return nil
+}
+
+// SniffsContent tells if uploads must be checked with CheckContent.
+func (p *Policy) SniffsContent() bool {
+	return len(p.AllowedTypes) > 0 || len(p.BlockedTypes) > 0
+}
+
+// CheckContent returns fbErrors.ErrUploadNotAllowed if the type of the
+// file, sniffed from its first bytes, can't be uploaded. The type
+// implied by the extension is blocked as well.
+func (p *Policy) CheckContent(name string, header []byte) error {
+	sniffed := files.SniffMimeType(name, header)
+	byName := files.MimeTypeByName(name)
+
+	if matchesType(p.BlockedTypes, sniffed) || (byName != "" && matchesType(p.BlockedTypes, byName)) {
+		return fbErrors.ErrUploadNotAllowed
+	}
+	if len(p.AllowedTypes) > 0 && !matchesType(p.AllowedTypes, sniffed) {
+		return fbErrors.ErrUploadNotAllowed
+	}
+
+	return nil
+}
+
+func cleanExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+func containsExtension(list []string, ext string) bool {
+	for _, e := range list {
+		if cleanExtension(e) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(patterns []string, typ string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		switch {
+		case p == "*" || p == "*/*" || p == typ:
+			return true
+		case strings.HasSuffix(p, "/*") && strings.HasPrefix(typ, strings.TrimSuffix(p, "*")):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package upload
+
+import (
+	"errors"
+	"testing"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+)
+
+func TestNormalizeName(t *testing.T) {
+	cases := map[string]string{
+		"report.pdf":        "report.pdf",
+		"cafe\u0301.txt":    "caf\u00e9.txt",
+		`a<b>c:d"e|f?g*.md`: "a_b_c_d_e_f_g_.md",
+		"back\\slash":       "back_slash",
+		"tab\there":         "tab_here",
+		"trailing. . ":      "trailing",
+	}
+
+	for name, want := range cases {
+		got, err := NormalizeName(name)
+		if err != nil {
+			t.Errorf("NormalizeName(%q) error: %v", name, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("NormalizeName(%q)=%q; want %q", name, got, want)
+		}
+	}
+
+	for _, name := range []string{"", ".", "..", " ...", "a/b"} {
+		if _, err := NormalizeName(name); !errors.Is(err, fbErrors.ErrInvalidRequestParams) {
+			t.Errorf("NormalizeName(%q) expected an error, got %v", name, err)
+		}
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	cases := map[string]string{
+		"/docs/cafe\u0301.txt": "/docs/caf\u00e9.txt",
+		"/cafe\u0301/a?.txt":   "/cafe\u0301/a_.txt",
+		"/new:folder/":         "/new_folder/",
+		"/":                    "/",
+	}
+
+	for p, want := range cases {
+		got, err := NormalizePath(p)
+		if err != nil {
+			t.Errorf("NormalizePath(%q) error: %v", p, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("NormalizePath(%q)=%q; want %q", p, got, want)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	exe := []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff\x00\x00")
+
+	p := &Policy{
+		MaxSize:           100,
+		BlockedExtensions: []string{"EXE", ".bat"},
+		AllowedTypes:      []string{"image/*", "text/csv"},
+	}
+	p.Clean()
+
+	if err := p.CheckSize(101); !errors.Is(err, fbErrors.ErrUploadTooLarge) {
+		t.Errorf("expected size to be rejected, got %v", err)
+	}
+	if err := p.CheckSize(100); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := p.CheckName("/setup.Exe"); !errors.Is(err, fbErrors.ErrUploadNotAllowed) {
+		t.Errorf("expected extension to be blocked, got %v", err)
+	}
+	if err := p.CheckName("/photo.png"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := p.CheckContent("/photo.png", png); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := p.CheckContent("/data.csv", []byte("a,b\n1,2\n")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := p.CheckContent("/photo.png", exe); !errors.Is(err, fbErrors.ErrUploadNotAllowed) {
+		t.Errorf("expected disguised executable to be rejected, got %v", err)
+	}
+	if err := p.CheckContent("/notes.txt", []byte("hello")); !errors.Is(err, fbErrors.ErrUploadNotAllowed) {
+		t.Errorf("expected plain text to be rejected, got %v", err)
+	}
+
+	blocked := &Policy{BlockedTypes: []string{"text/html"}}
+	if err := blocked.CheckContent("/page.txt", []byte("<html><body></body></html>")); !errors.Is(err, fbErrors.ErrUploadNotAllowed) {
+		t.Errorf("expected sniffed HTML to be blocked, got %v", err)
+	}
+	if err := blocked.CheckContent("/page.html", []byte("plain")); !errors.Is(err, fbErrors.ErrUploadNotAllowed) {
+		t.Errorf("expected HTML by extension to be blocked, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package users
+
+import (
+	"errors"
+	"slices"
+
+	fbErrors "github.com/futureharmony/storagebrowser/v2/errors"
+	"github.com/futureharmony/storagebrowser/v2/rules"
+)
+
+// Group describes a set of permissions, rules, commands and scopes
+// shared by every user that belongs to it.
+type Group struct {
+	ID              uint         `storm:"id,increment" json:"id"`
+	Name            string       `storm:"unique" json:"name"`
+	Perm            Permissions  `json:"perm"`
+	Commands        []string     `json:"commands"`
+	Rules           []rules.Rule `json:"rules"`
+	AvailableScopes []Scope      `json:"availableScopes"`
+}
+
+// Clean cleans up a group and verifies if all its fields
+// are alright to be saved.
+func (g *Group) Clean() error {
+	if g.Name == "" {
+		return fbErrors.ErrEmptyGroupName
+	}
+	if g.Commands == nil {
+		g.Commands = []string{}
+	}
+	if g.Rules == nil {
+		g.Rules = []rules.Rule{}
+	}
+	for i := range g.Rules {
+		if err := g.Rules[i].Validate(); err != nil {
+			return err
+		}
+	}
+	if g.AvailableScopes == nil {
+		g.AvailableScopes = []Scope{}
+	}
+	return nil
+}
+
+// Merge merges the permissions, commands, rules and scopes of the given
+// groups into the user. Permissions are granted if the user or any of its
+// groups grants them. Group rules are evaluated before the user's own rules
+// so the latter always have the final word.
+func (u *User) Merge(groups ...*Group) {
+	var groupRules []rules.Rule
+	for _, g := range groups {
+		u.Perm = u.Perm.Merge(g.Perm)
+
+		for _, command := range g.Commands {
+			if !slices.Contains(u.Commands, command) {
+				u.Commands = append(u.Commands, command)
+			}
+		}
+
+		for _, scope := range g.AvailableScopes {
+			if !slices.ContainsFunc(u.AvailableScopes, func(s Scope) bool { return s.Name == scope.Name }) {
+				u.AvailableScopes = append(u.AvailableScopes, scope)
+			}
+		}
+
+		groupRules = append(groupRules, g.Rules...)
+	}
+
+	u.Rules = append(groupRules, u.Rules...)
+	if len(u.AvailableScopes) > 0 && u.CurrentScope.Name == "" {
+		u.CurrentScope = u.AvailableScopes[0]
+	}
+}
+
+// GroupStorageBackend is the interface to implement for a groups storage.
+type GroupStorageBackend interface {
+	GetBy(interface{}) (*Group, error)
+	Gets() ([]*Group, error)
+	Save(g *Group) error
+	DeleteByID(uint) error
+}
+
+// GroupStorage is a groups storage.
+type GroupStorage struct {
+	back GroupStorageBackend
+}
+
+// NewGroupStorage creates a groups storage from a backend.
+func NewGroupStorage(back GroupStorageBackend) *GroupStorage {
+	return &GroupStorage{back: back}
+}
+
+// Get allows you to get a group by its name or id. The provided
+// id must be a string for name lookup or a uint for id lookup.
+func (s *GroupStorage) Get(id interface{}) (*Group, error) {
+	return s.back.GetBy(id)
+}
+
+// Gets gets a list of all groups.
+func (s *GroupStorage) Gets() ([]*Group, error) {
+	return s.back.Gets()
+}
+
+// Save saves the group in a storage.
+func (s *GroupStorage) Save(g *Group) error {
+	if err := g.Clean(); err != nil {
+		return err
+	}
+	return s.back.Save(g)
+}
+
+// Delete allows you to delete a group by its name or id.
+func (s *GroupStorage) Delete(id interface{}) error {
+	g, err := s.back.GetBy(id)
+	if err != nil {
+		return err
+	}
+	return s.back.DeleteByID(g.ID)
+}
+
+// Apply merges the groups the user belongs to into it, so that the
+// user holds its effective permissions. Groups that no longer exist
+// are ignored. The result must not be saved back into the storage.
+func (s *GroupStorage) Apply(u *User) error {
+	groups := make([]*Group, 0, len(u.Groups))
+	for _, id := range u.Groups {
+		g, err := s.back.GetBy(id)
+		if errors.Is(err, fbErrors.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		groups = append(groups, g)
+	}
+
+	u.Merge(groups...)
+	return nil
+}
@@ -0,0 +1,54 @@
+package users
+
+import (
+	"testing"
+
+	"github.com/futureharmony/storagebrowser/v2/rules"
+)
+
+func TestUserMerge(t *testing.T) {
+	u := &User{
+		Perm:            Permissions{Download: true},
+		Commands:        []string{"ls"},
+		Rules:           []rules.Rule{{Path: "/private", Allow: true}},
+		AvailableScopes: []Scope{{Name: "scratch", RootPrefix: "/"}},
+	}
+
+	u.Merge(
+		&Group{
+			Perm:            Permissions{Create: true},
+			Commands:        []string{"ls", "git"},
+			Rules:           []rules.Rule{{Path: "/private", Allow: false}},
+			AvailableScopes: []Scope{{Name: "scratch", RootPrefix: "/other"}, {Name: "prod-backups", RootPrefix: "/"}},
+		},
+		&Group{Perm: Permissions{Share: true}},
+	)
+
+	want := Permissions{Download: true, Create: true, Share: true}
+	if u.Perm != want {
+		t.Errorf("Perm=%+v; want %+v", u.Perm, want)
+	}
+
+	if len(u.Commands) != 2 || u.Commands[1] != "git" {
+		t.Errorf("Commands=%v; want [ls git]", u.Commands)
+	}
+
+	if len(u.AvailableScopes) != 2 || u.AvailableScopes[0].RootPrefix != "/" || u.AvailableScopes[1].Name != "prod-backups" {
+		t.Errorf("AvailableScopes=%+v; want user scratch scope followed by prod-backups", u.AvailableScopes)
+	}
+
+	if u.CurrentScope.Name != "scratch" {
+		t.Errorf("CurrentScope=%q; want scratch", u.CurrentScope.Name)
+	}
+
+	// The user's own rule comes last, so it wins over the group's.
+	allow := true
+	for _, rule := range u.Rules {
+		if rule.Matches("/private/file") {
+			allow = rule.Allow
+		}
+	}
+	if !allow {
+		t.Error("user rule should override group rule")
+	}
+}
@@ -11,3 +11,17 @@ type Permissions struct {
 	Share    bool `json:"share"`
 	Download bool `json:"download"`
 }
+
+// Merge returns the permissions granted by either p or other.
+func (p Permissions) Merge(other Permissions) Permissions {
+	return Permissions{
+		Admin:    p.Admin || other.Admin,
+		Execute:  p.Execute || other.Execute,
+		Create:   p.Create || other.Create,
+		Rename:   p.Rename || other.Rename,
+		Modify:   p.Modify || other.Modify,
+		Delete:   p.Delete || other.Delete,
+		Share:    p.Share || other.Share,
+		Download: p.Download || other.Download,
+	}
+}
@@ -2,7 +2,9 @@ package users
 
 import (
 	"log"
+	"path"
 	"path/filepath"
+	"strings"
 
 	aferos3 "github.com/futureharmony/afero-aws-s3"
 	"github.com/spf13/afero"
@@ -10,7 +12,9 @@ import (
 	"github.com/futureharmony/storagebrowser/v2/errors"
 	"github.com/futureharmony/storagebrowser/v2/files"
 	"github.com/futureharmony/storagebrowser/v2/minio"
+	"github.com/futureharmony/storagebrowser/v2/quota"
 	"github.com/futureharmony/storagebrowser/v2/rules"
+	"github.com/futureharmony/storagebrowser/v2/upload"
 )
 
 // ViewMode describes a view mode.
@@ -23,28 +27,129 @@ const (
 
 // User describes a user.
 type User struct {
-	ID              uint          `storm:"id,increment" json:"id"`
-	Username        string        `storm:"unique" json:"username"`
-	Password        string        `json:"password"`
-	AvailableScopes []Scope       `json:"availableScopes"`
-	CurrentScope    Scope         `json:"currentScope"`
-	Scope           string        `json:"scope"`
-	Locale          string        `json:"locale"`
-	LockPassword    bool          `json:"lockPassword"`
-	ViewMode        ViewMode      `json:"viewMode"`
-	SingleClick     bool          `json:"singleClick"`
-	Perm            Permissions   `json:"perm"`
-	Commands        []string      `json:"commands"`
-	Sorting         files.Sorting `json:"sorting"`
-	Fs              afero.Fs      `json:"-" yaml:"-"`
-	Rules           []rules.Rule  `json:"rules"`
-	HideDotfiles    bool          `json:"hideDotfiles"`
-	DateFormat      bool          `json:"dateFormat"`
+	ID              uint        `storm:"id,increment" json:"id"`
+	Username        string      `storm:"unique" json:"username"`
+	Password        string      `json:"password"`
+	AvailableScopes []Scope     `json:"availableScopes"`
+	CurrentScope    Scope       `json:"currentScope"`
+	Scope           string      `json:"scope"`
+	Locale          string      `json:"locale"`
+	LockPassword    bool        `json:"lockPassword"`
+	ViewMode        ViewMode    `json:"viewMode"`
+	SingleClick     bool        `json:"singleClick"`
+	Perm            Permissions `json:"perm"`
+	Quota           quota.Limit `json:"quota"`
+	// UploadPolicy, when set, replaces the global upload policy.
+	UploadPolicy *upload.Policy `json:"uploadPolicy,omitempty"`
+	Groups       []uint         `json:"groups"`
+	Commands     []string       `json:"commands"`
+	Sorting      files.Sorting  `json:"sorting"`
+	Fs           afero.Fs       `json:"-" yaml:"-"`
+	Rules        []rules.Rule   `json:"rules"`
+	HideDotfiles bool           `json:"hideDotfiles"`
+	DateFormat   bool           `json:"dateFormat"`
 }
 
+// Scope describes a bucket, and a prefix within it, that a user can access.
 type Scope struct {
 	Name       string `json:"name"`
 	RootPrefix string `json:"rootPrefix"`
+	// Perm, when set, replaces the user's permissions inside this scope.
+	Perm *Permissions `json:"perm,omitempty"`
+	// Quota, when set, caps the storage used inside this scope by
+	// everyone who has access to it.
+	Quota *quota.Limit `json:"quota,omitempty"`
+	// UploadPolicy, when set, replaces the user's upload policy inside
+	// this scope.
+	UploadPolicy *upload.Policy `json:"uploadPolicy,omitempty"`
+}
+
+// Contains tells if a path, relative to the scope root, resolves to a
+// location inside the scope's root prefix. Paths that climb above the
+// root through ".." segments or contain NUL bytes are never contained.
+func (s Scope) Contains(p string) bool {
+	if strings.ContainsRune(p, 0) {
+		return false
+	}
+
+	depth := 0
+	for _, segment := range strings.Split(p, "/") {
+		switch segment {
+		case "", ".":
+		case "..":
+			depth--
+			if depth < 0 {
+				return false
+			}
+		default:
+			depth++
+		}
+	}
+
+	root := path.Clean("/" + s.RootPrefix)
+	full := path.Join(root, p)
+	return root == "/" || full == root || strings.HasPrefix(full, root+"/")
+}
+
+// HasScope tells if the user still has access to a scope: one of its
+// available scopes is in the same bucket and its root prefix holds the
+// scope's.
+func (u *User) HasScope(s Scope) bool {
+	root := path.Clean("/" + s.RootPrefix)
+	for _, scope := range u.AvailableScopes {
+		if scope.Name != s.Name {
+			continue
+		}
+
+		avail := path.Clean("/" + scope.RootPrefix)
+		if avail == "/" || root == avail || strings.HasPrefix(root, avail+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ScopePerm returns the permissions the user has inside the scope with
+// the given name. Admin is a global permission and is never changed by
+// a scope.
+func (u *User) ScopePerm(name string) Permissions {
+	for _, scope := range u.AvailableScopes {
+		if scope.Name != name || scope.Perm == nil {
+			continue
+		}
+
+		perm := *scope.Perm
+		perm.Admin = u.Perm.Admin
+		return perm
+	}
+
+	return u.Perm
+}
+
+// ScopeQuota returns the quota of the named scope as configured in the
+// user's available scopes, or nil if it has none.
+func (u *User) ScopeQuota(name string) *quota.Limit {
+	for _, scope := range u.AvailableScopes {
+		if scope.Name == name && scope.Quota != nil && !scope.Quota.IsZero() {
+			return scope.Quota
+		}
+	}
+
+	return nil
+}
+
+// ScopeUploadPolicy returns the upload policy of the named scope as
+// configured in the user's available scopes, falling back to the user's
+// own policy. It returns nil if neither has one.
+func (u *User) ScopeUploadPolicy(name string) *upload.Policy {
+	for _, scope := range u.AvailableScopes {
+		if scope.Name == name && scope.UploadPolicy != nil {
+			return scope.UploadPolicy
+		}
+	}
+
+	return u.UploadPolicy
 }
 
 // SetS3Scopes sets up available scopes for S3 storage type from an array of Scope objects
@@ -69,6 +174,7 @@ var checkableFields = []string{
 	"Commands",
 	"Sorting",
 	"Rules",
+	"Groups",
 	"AvailableScopes",
 	"CurrentScope",
 }
@@ -112,6 +218,15 @@ func (u *User) Clean(baseScope string, fields ...string) error {
 			if u.Rules == nil {
 				u.Rules = []rules.Rule{}
 			}
+			for i := range u.Rules {
+				if err := u.Rules[i].Validate(); err != nil {
+					return err
+				}
+			}
+		case "Groups":
+			if u.Groups == nil {
+				u.Groups = []uint{}
+			}
 		case "AvailableScopes":
 			if u.AvailableScopes == nil {
 				u.AvailableScopes = []Scope{}
@@ -0,0 +1,83 @@
+package users
+
+import "testing"
+
+func TestUserScopePerm(t *testing.T) {
+	u := &User{
+		Perm: Permissions{Admin: true, Create: true, Delete: true, Download: true},
+		AvailableScopes: []Scope{
+			{Name: "prod-backups", Perm: &Permissions{Download: true}},
+			{Name: "scratch"},
+		},
+	}
+
+	cases := map[string]Permissions{
+		"prod-backups": {Admin: true, Download: true},
+		"scratch":      u.Perm,
+		"unknown":      u.Perm,
+	}
+
+	for name, want := range cases {
+		if got := u.ScopePerm(name); got != want {
+			t.Errorf("ScopePerm(%s)=%+v; want %+v", name, got, want)
+		}
+	}
+}
+
+func TestUserHasScope(t *testing.T) {
+	u := &User{
+		AvailableScopes: []Scope{
+			{Name: "photos", RootPrefix: "/2025"},
+			{Name: "docs"},
+		},
+	}
+
+	cases := []struct {
+		scope Scope
+		want  bool
+	}{
+		{Scope{Name: "photos", RootPrefix: "/2025"}, true},
+		{Scope{Name: "photos", RootPrefix: "/2025/trip/"}, true},
+		{Scope{Name: "photos", RootPrefix: "/"}, false},
+		{Scope{Name: "photos", RootPrefix: "/2025-old"}, false},
+		{Scope{Name: "docs", RootPrefix: "/any"}, true},
+		{Scope{Name: "revoked"}, false},
+	}
+
+	for _, tc := range cases {
+		if got := u.HasScope(tc.scope); got != tc.want {
+			t.Errorf("HasScope(%+v)=%v; want %v", tc.scope, got, tc.want)
+		}
+	}
+}
+
+func TestScopeContains(t *testing.T) {
+	cases := []struct {
+		rootPrefix string
+		path       string
+		want       bool
+	}{
+		{"/", "/", true},
+		{"/", "/docs/report.pdf", true},
+		{"/", "../other", false},
+		{"", "docs", true},
+		{"/team", "/", true},
+		{"/team", "", true},
+		{"/team", "/docs/./report.pdf", true},
+		{"/team", "/docs/../report.pdf", true},
+		{"/team", "/..", false},
+		{"/team", "/../team-secret/key", false},
+		{"/team", "docs/../../other", false},
+		{"/team", "/docs/..%2f..", true},
+		{"/team/", "/a/b/../../..", false},
+		{"team", "/a/b/../../c", true},
+		{"/team", "/docs\x00.txt", false},
+	}
+
+	for _, tc := range cases {
+		s := Scope{Name: "bucket", RootPrefix: tc.rootPrefix}
+		if got := s.Contains(tc.path); got != tc.want {
+			t.Errorf("Scope{RootPrefix: %q}.Contains(%q)=%v; want %v", tc.rootPrefix, tc.path, got, tc.want)
+		}
+	}
+}
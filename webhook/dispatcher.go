@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/futureharmony/storagebrowser/v2/events"
+	"github.com/futureharmony/storagebrowser/v2/settings"
+)
+
+// Dispatcher posts payloads to the webhooks of their event.
+type Dispatcher struct {
+	log    *Storage
+	client *http.Client
+	now    func() time.Time
+	sleep  func(time.Duration)
+}
+
+// NewDispatcher creates a dispatcher recording its deliveries in the
+// given storage, which may be nil.
+func NewDispatcher(log *Storage) *Dispatcher {
+	return &Dispatcher{
+		log:    log,
+		client: http.DefaultClient,
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// NewPayload creates a payload with a unique ID for an event.
+func (d *Dispatcher) NewPayload(evt, username, scope, path, dst string) *Payload {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return &Payload{
+		ID:          hex.EncodeToString(id),
+		Event:       evt,
+		Time:        d.now(),
+		Username:    username,
+		Scope:       scope,
+		Path:        path,
+		Destination: dst,
+	}
+}
+
+// Subscribe makes the dispatcher notify the webhooks, configured in the
+// current settings, of the successful operations published on the bus.
+func (d *Dispatcher) Subscribe(bus *events.Bus, st *settings.Storage) {
+	bus.Subscribe(func(e *events.Event) error {
+		if e.Err != nil {
+			return nil
+		}
+
+		set, err := st.Get()
+		if err != nil {
+			return err
+		}
+		if len(set.Webhooks.Events[e.Name()]) == 0 {
+			return nil
+		}
+
+		p := d.NewPayload(e.Name(), e.Username(), e.Scope.Name, e.Path, e.Destination)
+		p.Size = e.Size
+		p.ETag = e.ETag
+		d.Notify(set.Webhooks, p)
+		return nil
+	})
+}
+
+// Notify delivers the payload, in the background, to every webhook
+// configured for its event.
+func (d *Dispatcher) Notify(cfg settings.Webhooks, p *Payload) {
+	for _, url := range cfg.Events[p.Event] {
+		go d.Deliver(cfg, url, p)
+	}
+}
+
+// Deliver posts the payload to a webhook, retrying with an exponential
+// backoff until it answers with a 2xx status or the attempts run out.
+// The delivery is recorded in the log.
+func (d *Dispatcher) Deliver(cfg settings.Webhooks, url string, p *Payload) *Delivery {
+	delivery := &Delivery{
+		PayloadID: p.ID,
+		Event:     p.Event,
+		URL:       url,
+		Time:      d.now(),
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		delivery.Error = err.Error()
+		d.record(delivery)
+		return delivery
+	}
+
+	attempts := max(cfg.MaxAttempts, 1)
+	backoff := time.Duration(cfg.Backoff) * time.Second
+	for delivery.Attempts < attempts {
+		if delivery.Attempts > 0 {
+			d.sleep(backoff)
+			backoff *= 2
+		}
+		delivery.Attempts++
+
+		delivery.StatusCode, err = d.post(cfg, url, p, body)
+		if err == nil {
+			delivery.Success = true
+			delivery.Error = ""
+			break
+		}
+		delivery.Error = err.Error()
+	}
+
+	d.record(delivery)
+	return delivery
+}
+
+func (d *Dispatcher) post(cfg settings.Webhooks, url string, p *Payload, body []byte) (int, error) {
+	ctx := context.Background()
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, p.Event)
+	req.Header.Set(HeaderDelivery, p.ID)
+	if cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(cfg.Secret, body))
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+func (d *Dispatcher) record(delivery *Delivery) {
+	if d.log == nil {
+		return
+	}
+
+	if err := d.log.Save(delivery); err != nil {
+		log.Printf("WARNING: couldn't record webhook delivery: %v", err)
+	}
+}
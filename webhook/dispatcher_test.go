@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/futureharmony/storagebrowser/v2/settings"
+)
+
+type memBackend struct {
+	mux        sync.Mutex
+	deliveries []*Delivery
+}
+
+func (m *memBackend) Save(d *Delivery) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.deliveries = append(m.deliveries, d)
+	return nil
+}
+
+func (m *memBackend) Each(fn func(d *Delivery) error) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	for i := len(m.deliveries) - 1; i >= 0; i-- {
+		if err := fn(m.deliveries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type receiver struct {
+	mux      sync.Mutex
+	failures int
+	payloads []Payload
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if r.Header.Get(HeaderEvent) != p.Event || r.Header.Get(HeaderDelivery) != p.ID {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !Verify("s3cret", body, r.Header.Get(HeaderSignature)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	rc.payloads = append(rc.payloads, p)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newTestDispatcher() (*Dispatcher, *[]time.Duration) {
+	var sleeps []time.Duration
+	d := NewDispatcher(NewStorage(&memBackend{}))
+	d.sleep = func(dur time.Duration) { sleeps = append(sleeps, dur) }
+	return d, &sleeps
+}
+
+func TestDeliverRetries(t *testing.T) {
+	rc := &receiver{failures: 2}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	cfg := settings.Webhooks{Secret: "s3cret", MaxAttempts: 3, Backoff: 1}
+	d, sleeps := newTestDispatcher()
+
+	p := d.NewPayload("after_upload", "alice", "/", "/a.txt", "")
+	delivery := d.Deliver(cfg, srv.URL, p)
+
+	if !delivery.Success || delivery.Attempts != 3 || delivery.StatusCode != http.StatusNoContent {
+		t.Fatalf("unexpected delivery %+v", delivery)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second}; len(*sleeps) != 2 || (*sleeps)[0] != want[0] || (*sleeps)[1] != want[1] {
+		t.Fatalf("got backoff %v, want %v", *sleeps, want)
+	}
+	if len(rc.payloads) != 1 || rc.payloads[0].Path != "/a.txt" || rc.payloads[0].Username != "alice" {
+		t.Fatalf("unexpected payloads %+v", rc.payloads)
+	}
+
+	logged, err := d.log.List(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 1 || logged[0] != delivery {
+		t.Fatalf("unexpected delivery log %+v", logged)
+	}
+}
+
+func TestDeliverGivesUp(t *testing.T) {
+	rc := &receiver{failures: 5}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	cfg := settings.Webhooks{Secret: "s3cret", MaxAttempts: 2, Backoff: 1}
+	d, _ := newTestDispatcher()
+
+	delivery := d.Deliver(cfg, srv.URL, d.NewPayload("after_delete", "bob", "/", "/b.txt", ""))
+	if delivery.Success || delivery.Attempts != 2 || delivery.StatusCode != http.StatusInternalServerError || delivery.Error == "" {
+		t.Fatalf("unexpected delivery %+v", delivery)
+	}
+}
+
+func TestDeliverWrongSecret(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	cfg := settings.Webhooks{Secret: "other", MaxAttempts: 1}
+	d, _ := newTestDispatcher()
+
+	delivery := d.Deliver(cfg, srv.URL, d.NewPayload("after_save", "bob", "/", "/c.txt", ""))
+	if delivery.Success || delivery.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unexpected delivery %+v", delivery)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	cfg := settings.Webhooks{
+		Secret:      "s3cret",
+		MaxAttempts: 1,
+		Events: map[string][]string{
+			"after_upload": {srv.URL, srv.URL},
+		},
+	}
+	d, _ := newTestDispatcher()
+
+	d.Notify(cfg, d.NewPayload("after_delete", "alice", "/", "/a.txt", ""))
+	d.Notify(cfg, d.NewPayload("after_upload", "alice", "/", "/a.txt", ""))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		logged, err := d.log.List(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(logged) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d deliveries, want 2", len(logged))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rc.mux.Lock()
+	defer rc.mux.Unlock()
+	if len(rc.payloads) != 2 || rc.payloads[0].Event != "after_upload" {
+		t.Fatalf("unexpected payloads %+v", rc.payloads)
+	}
+}
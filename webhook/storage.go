@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"errors"
+)
+
+// ErrStop can be returned by the function given to StorageBackend.Each
+// to stop the iteration.
+var ErrStop = errors.New("stop iteration")
+
+// StorageBackend is the interface to implement for a delivery log
+// storage.
+type StorageBackend interface {
+	Save(d *Delivery) error
+	// Each calls fn for every delivery, newest first.
+	Each(fn func(d *Delivery) error) error
+}
+
+// Storage is a webhook delivery log storage.
+type Storage struct {
+	back StorageBackend
+}
+
+// NewStorage creates a delivery log storage from a backend.
+func NewStorage(back StorageBackend) *Storage {
+	return &Storage{back: back}
+}
+
+// Save wraps a StorageBackend.Save.
+func (s *Storage) Save(d *Delivery) error {
+	return s.back.Save(d)
+}
+
+// List returns the latest deliveries, newest first. A limit of 0
+// returns all of them.
+func (s *Storage) List(limit int) ([]*Delivery, error) {
+	deliveries := []*Delivery{}
+
+	err := s.back.Each(func(d *Delivery) error {
+		deliveries = append(deliveries, d)
+		if limit > 0 && len(deliveries) >= limit {
+			return ErrStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrStop) {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
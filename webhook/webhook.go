@@ -0,0 +1,54 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Payload is the JSON body posted to webhooks.
+type Payload struct {
+	ID          string    `json:"id"`
+	Event       string    `json:"event"`
+	Time        time.Time `json:"time"`
+	Username    string    `json:"username"`
+	Scope       string    `json:"scope"`
+	Path        string    `json:"path"`
+	Destination string    `json:"destination,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	ETag        string    `json:"etag,omitempty"`
+}
+
+// Delivery is the record of sending a payload to a webhook.
+type Delivery struct {
+	ID         uint64    `json:"id" storm:"id,increment"`
+	PayloadID  string    `json:"payloadId"`
+	Event      string    `json:"event"`
+	URL        string    `json:"url"`
+	Time       time.Time `json:"time"`
+	Attempts   uint      `json:"attempts"`
+	StatusCode int       `json:"statusCode"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Sign returns the signature of a payload body, as sent in the
+// HeaderSignature header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify tells if a signature matches a payload body.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,520 @@
+// Package zipstream serves store-only ZIP archives whose layout is
+// computed from the list of their entries before any content is read:
+// their size is known upfront and any range of them can be read, to
+// resume a download for instance.
+//
+// The checksums of the files are computed as their content is read, or
+// by reading them in full when a range needs them without going through
+// the whole file. A Cache spares reading files again for the following
+// requests of a resumed download.
+package zipstream
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	localHeaderLen      = 30
+	centralHeaderLen    = 46
+	descriptorLen       = 16
+	descriptor64Len     = 24
+	zip64ExtraLen       = 28
+	endLen              = 22
+	end64Len            = 56
+	end64LocatorLen     = 20
+	uint16max           = 0xffff
+	uint32max           = 0xffffffff
+	flagDataDescriptor  = 0x8
+	flagUTF8            = 0x800
+	creatorUnix         = 3
+	version20           = 20
+	version45           = 45
+	msdosDir            = 0x10
+	localHeaderSig      = 0x04034b50
+	centralHeaderSig    = 0x02014b50
+	descriptorSig       = 0x08074b50
+	endSig              = 0x06054b50
+	end64Sig            = 0x06064b50
+	end64LocatorSig     = 0x07064b50
+	zip64ExtraID        = 0x0001
+	unixModeDir         = 0o040000
+	unixModeRegularFile = 0o100000
+)
+
+// ErrChanged is returned when a file doesn't have the size it was
+// listed with anymore.
+var ErrChanged = errors.New("file changed while archived")
+
+// File is the content of a file, as opened by Entry.Open.
+type File interface {
+	io.Reader
+	io.Seeker
+	io.Closer
+}
+
+// Entry is a file or a directory of an archive.
+type Entry struct {
+	// Name is the slash-separated path of the entry in the archive,
+	// ending with a slash for directories.
+	Name    string
+	Size    int64
+	ModTime time.Time
+	Mode    fs.FileMode
+	// ID identifies the content of a file in the Cache, e.g. its path
+	// along with its ETag. The checksum of entries without an ID isn't
+	// cached.
+	ID string
+	// Open opens the content of a file.
+	Open func() (File, error)
+}
+
+// IsDir tells if the entry is a directory.
+func (e *Entry) IsDir() bool {
+	return strings.HasSuffix(e.Name, "/")
+}
+
+// Bytes returns the entry of a file with the given content.
+func Bytes(name string, content []byte, modTime time.Time) *Entry {
+	return &Entry{
+		Name:    name,
+		Size:    int64(len(content)),
+		ModTime: modTime,
+		Mode:    0o644,
+		Open: func() (File, error) {
+			return nopCloser{bytes.NewReader(content)}, nil
+		},
+	}
+}
+
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
+
+// Cache keeps the checksums of files by their ID.
+type Cache interface {
+	Get(id string) (uint32, bool)
+	Set(id string, crc uint32)
+}
+
+// entry is an Entry laid out in an archive.
+type entry struct {
+	*Entry
+	offset int64 // of the local header
+	header []byte
+	crc    uint32
+	hasCRC bool
+}
+
+func (e *entry) zip64() bool {
+	return e.Size >= uint32max
+}
+
+func (e *entry) dataOffset() int64 {
+	return e.offset + int64(len(e.header))
+}
+
+func (e *entry) descriptorLen() int64 {
+	switch {
+	case e.IsDir():
+		return 0
+	case e.zip64():
+		return descriptor64Len
+	default:
+		return descriptorLen
+	}
+}
+
+func (e *entry) end() int64 {
+	return e.dataOffset() + e.Size + e.descriptorLen()
+}
+
+// Archive reads a ZIP archive. It isn't safe for concurrent use.
+type Archive struct {
+	entries    []*entry
+	cache      Cache
+	central    int64 // offset of the central directory
+	centralLen int64
+	size       int64
+	etag       string
+	modTime    time.Time
+
+	centralDir []byte
+	pos        int64
+
+	// The file being read, at offset of its content, and its checksum
+	// when read from the start.
+	file   File
+	fileOf *entry
+	fileAt int64
+	hash   hash.Hash32
+}
+
+// New lays out an archive of the entries, in the order given. The cache
+// may be nil.
+func New(entries []*Entry, cache Cache) *Archive {
+	a := &Archive{cache: cache}
+	id := sha256.New()
+
+	var offset int64
+	for _, e := range entries {
+		le := &entry{Entry: e, offset: offset, header: localHeader(e)}
+		if le.IsDir() {
+			le.Size, le.hasCRC = 0, true
+		}
+		a.entries = append(a.entries, le)
+		offset = le.end()
+
+		fmt.Fprintf(id, "%s\x00%d\x00%d\x00%s\x00", e.Name, e.Size, e.ModTime.UnixNano(), e.ID)
+		if e.ModTime.After(a.modTime) {
+			a.modTime = e.ModTime
+		}
+	}
+
+	a.central = offset
+	for _, e := range a.entries {
+		a.centralLen += centralHeaderLen + int64(len(e.Name))
+		if e.zip64() || e.offset >= uint32max {
+			a.centralLen += zip64ExtraLen
+		}
+	}
+	a.size = a.central + a.centralLen + endLen
+	if a.needsEnd64() {
+		a.size += end64Len + end64LocatorLen
+	}
+	a.etag = hex.EncodeToString(id.Sum(nil)[:16])
+
+	return a
+}
+
+// Size returns the size of the archive.
+func (a *Archive) Size() int64 {
+	return a.size
+}
+
+// ETag returns an identifier of the layout and the files of the archive,
+// which changes when any of them does.
+func (a *Archive) ETag() string {
+	return a.etag
+}
+
+// ModTime returns the latest modification time of the entries.
+func (a *Archive) ModTime() time.Time {
+	return a.modTime
+}
+
+func (a *Archive) needsEnd64() bool {
+	return len(a.entries) >= uint16max || a.centralLen >= uint32max || a.central >= uint32max
+}
+
+// Seek implements io.Seeker.
+func (a *Archive) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += a.pos
+	case io.SeekEnd:
+		offset += a.size
+	default:
+		return 0, errors.New("zipstream: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("zipstream: negative position")
+	}
+
+	a.pos = offset
+	return offset, nil
+}
+
+// Read implements io.Reader.
+func (a *Archive) Read(p []byte) (int, error) {
+	if a.pos >= a.size {
+		return 0, io.EOF
+	}
+
+	var n int
+	var err error
+	if a.pos >= a.central {
+		n, err = a.readEnd(p)
+	} else {
+		i := sort.Search(len(a.entries), func(i int) bool { return a.entries[i].end() > a.pos })
+		n, err = a.readEntry(a.entries[i], p)
+	}
+
+	a.pos += int64(n)
+	return n, err
+}
+
+// Close closes the file being read, if any.
+func (a *Archive) Close() error {
+	if a.file == nil {
+		return nil
+	}
+
+	err := a.file.Close()
+	a.file, a.fileOf = nil, nil
+	return err
+}
+
+func (a *Archive) readEntry(e *entry, p []byte) (int, error) {
+	switch off := a.pos - e.offset; {
+	case off < int64(len(e.header)):
+		return copy(p, e.header[off:]), nil
+	case a.pos < e.dataOffset()+e.Size:
+		off = a.pos - e.dataOffset()
+		return a.readData(e, off, p[:min(int64(len(p)), e.Size-off)])
+	default:
+		if err := a.checksum(e); err != nil {
+			return 0, err
+		}
+		return copy(p, dataDescriptor(e)[a.pos-e.dataOffset()-e.Size:]), nil
+	}
+}
+
+// readData reads the content of a file from off, going on with the file
+// already opened when reading sequentially.
+func (a *Archive) readData(e *entry, off int64, p []byte) (int, error) {
+	if a.fileOf != e || a.fileAt != off {
+		if err := a.Close(); err != nil {
+			return 0, err
+		}
+
+		f, err := e.Open()
+		if err != nil {
+			return 0, err
+		}
+		if off > 0 {
+			if _, err := f.Seek(off, io.SeekStart); err != nil {
+				f.Close()
+				return 0, err
+			}
+		}
+
+		a.file, a.fileOf, a.fileAt, a.hash = f, e, off, nil
+		// The checksum is only known when reading the whole file.
+		if off == 0 && !e.hasCRC {
+			a.hash = crc32.NewIEEE()
+		}
+	}
+
+	n, err := io.ReadFull(a.file, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		err = fmt.Errorf("%w: %s", ErrChanged, e.Name)
+	}
+	if a.hash != nil {
+		a.hash.Write(p[:n])
+	}
+	a.fileAt += int64(n)
+
+	if a.fileAt == e.Size {
+		if a.hash != nil {
+			a.setChecksum(e, a.hash.Sum32())
+		}
+		if closeErr := a.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return n, err
+}
+
+// checksum makes sure the checksum of an entry is known, reading its
+// file if needed.
+func (a *Archive) checksum(e *entry) error {
+	if e.hasCRC {
+		return nil
+	}
+	if a.cache != nil && e.ID != "" {
+		if crc, ok := a.cache.Get(e.ID); ok {
+			e.crc, e.hasCRC = crc, true
+			return nil
+		}
+	}
+
+	f, err := e.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := crc32.NewIEEE()
+	n, err := io.Copy(h, io.LimitReader(f, e.Size))
+	if err != nil {
+		return err
+	}
+	if n != e.Size {
+		return fmt.Errorf("%w: %s", ErrChanged, e.Name)
+	}
+
+	a.setChecksum(e, h.Sum32())
+	return nil
+}
+
+func (a *Archive) setChecksum(e *entry, crc uint32) {
+	e.crc, e.hasCRC = crc, true
+	if a.cache != nil && e.ID != "" {
+		a.cache.Set(e.ID, crc)
+	}
+}
+
+func (a *Archive) readEnd(p []byte) (int, error) {
+	if a.centralDir == nil {
+		for _, e := range a.entries {
+			if err := a.checksum(e); err != nil {
+				return 0, err
+			}
+		}
+		a.centralDir = a.end()
+	}
+
+	return copy(p, a.centralDir[a.pos-a.central:]), nil
+}
+
+// end returns the central directory and the end records.
+func (a *Archive) end() []byte {
+	b := make([]byte, 0, a.size-a.central)
+	for _, e := range a.entries {
+		b = append(b, centralHeader(e)...)
+	}
+
+	records, centralLen, central := uint64(len(a.entries)), uint64(a.centralLen), uint64(a.central) //nolint:gosec
+	if a.needsEnd64() {
+		end64 := uint64(a.central + a.centralLen) //nolint:gosec
+		b = binary.LittleEndian.AppendUint32(b, end64Sig)
+		b = binary.LittleEndian.AppendUint64(b, end64Len-12)
+		b = binary.LittleEndian.AppendUint16(b, creatorUnix<<8|version45)
+		b = binary.LittleEndian.AppendUint16(b, version45)
+		b = binary.LittleEndian.AppendUint32(b, 0)
+		b = binary.LittleEndian.AppendUint32(b, 0)
+		b = binary.LittleEndian.AppendUint64(b, records)
+		b = binary.LittleEndian.AppendUint64(b, records)
+		b = binary.LittleEndian.AppendUint64(b, centralLen)
+		b = binary.LittleEndian.AppendUint64(b, central)
+
+		b = binary.LittleEndian.AppendUint32(b, end64LocatorSig)
+		b = binary.LittleEndian.AppendUint32(b, 0)
+		b = binary.LittleEndian.AppendUint64(b, end64)
+		b = binary.LittleEndian.AppendUint32(b, 1)
+
+		records, centralLen, central = min(records, uint16max), min(centralLen, uint32max), min(central, uint32max)
+	}
+
+	b = binary.LittleEndian.AppendUint32(b, endSig)
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	b = binary.LittleEndian.AppendUint16(b, uint16(records)) //nolint:gosec
+	b = binary.LittleEndian.AppendUint16(b, uint16(records)) //nolint:gosec
+	b = binary.LittleEndian.AppendUint32(b, uint32(centralLen))
+	b = binary.LittleEndian.AppendUint32(b, uint32(central))
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	return b
+}
+
+// localHeader returns the local header of an entry. The checksum and
+// sizes of files follow their content, in a data descriptor.
+func localHeader(e *Entry) []byte {
+	flags, version := uint16(flagUTF8|flagDataDescriptor), uint16(version20)
+	if e.IsDir() {
+		flags = flagUTF8
+	}
+	if e.Size >= uint32max {
+		version = version45
+	}
+	modTime, modDate := msdosTime(e.ModTime)
+
+	b := make([]byte, 0, localHeaderLen+len(e.Name))
+	b = binary.LittleEndian.AppendUint32(b, localHeaderSig)
+	b = binary.LittleEndian.AppendUint16(b, version)
+	b = binary.LittleEndian.AppendUint16(b, flags)
+	b = binary.LittleEndian.AppendUint16(b, 0) // stored
+	b = binary.LittleEndian.AppendUint16(b, modTime)
+	b = binary.LittleEndian.AppendUint16(b, modDate)
+	b = binary.LittleEndian.AppendUint32(b, 0)                   // checksum
+	b = binary.LittleEndian.AppendUint32(b, 0)                   // compressed size
+	b = binary.LittleEndian.AppendUint32(b, 0)                   // size
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(e.Name))) //nolint:gosec
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	return append(b, e.Name...)
+}
+
+func dataDescriptor(e *entry) []byte {
+	b := make([]byte, 0, descriptor64Len)
+	b = binary.LittleEndian.AppendUint32(b, descriptorSig)
+	b = binary.LittleEndian.AppendUint32(b, e.crc)
+	if e.zip64() {
+		b = binary.LittleEndian.AppendUint64(b, uint64(e.Size))    //nolint:gosec
+		return binary.LittleEndian.AppendUint64(b, uint64(e.Size)) //nolint:gosec
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(e.Size))    //nolint:gosec
+	return binary.LittleEndian.AppendUint32(b, uint32(e.Size)) //nolint:gosec
+}
+
+func centralHeader(e *entry) []byte {
+	flags, version := uint16(flagUTF8|flagDataDescriptor), uint16(version20)
+	mode, attrs := uint32(unixModeRegularFile), uint32(0)
+	if e.IsDir() {
+		flags, mode, attrs = flagUTF8, unixModeDir, msdosDir
+	}
+	mode |= uint32(e.Mode.Perm())
+
+	size, offset := uint32(e.Size), uint32(e.offset) //nolint:gosec
+	var extra []byte
+	if e.zip64() || e.offset >= uint32max {
+		version, size, offset = version45, uint32max, uint32max
+		extra = binary.LittleEndian.AppendUint16(extra, zip64ExtraID)
+		extra = binary.LittleEndian.AppendUint16(extra, zip64ExtraLen-4)
+		extra = binary.LittleEndian.AppendUint64(extra, uint64(e.Size))   //nolint:gosec
+		extra = binary.LittleEndian.AppendUint64(extra, uint64(e.Size))   //nolint:gosec
+		extra = binary.LittleEndian.AppendUint64(extra, uint64(e.offset)) //nolint:gosec
+	}
+	modTime, modDate := msdosTime(e.ModTime)
+
+	b := make([]byte, 0, centralHeaderLen+len(e.Name)+len(extra))
+	b = binary.LittleEndian.AppendUint32(b, centralHeaderSig)
+	b = binary.LittleEndian.AppendUint16(b, creatorUnix<<8|version)
+	b = binary.LittleEndian.AppendUint16(b, version)
+	b = binary.LittleEndian.AppendUint16(b, flags)
+	b = binary.LittleEndian.AppendUint16(b, 0) // stored
+	b = binary.LittleEndian.AppendUint16(b, modTime)
+	b = binary.LittleEndian.AppendUint16(b, modDate)
+	b = binary.LittleEndian.AppendUint32(b, e.crc)
+	b = binary.LittleEndian.AppendUint32(b, size)
+	b = binary.LittleEndian.AppendUint32(b, size)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(e.Name))) //nolint:gosec
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(extra)))  //nolint:gosec
+	b = binary.LittleEndian.AppendUint16(b, 0)                   // comment
+	b = binary.LittleEndian.AppendUint16(b, 0)                   // disk
+	b = binary.LittleEndian.AppendUint16(b, 0)                   // internal attributes
+	b = binary.LittleEndian.AppendUint32(b, mode<<16|attrs)
+	b = binary.LittleEndian.AppendUint32(b, offset)
+	b = append(b, e.Name...)
+	return append(b, extra...)
+}
+
+// msdosTime returns the MS-DOS time and date of t, in UTC and clamped
+// to the years it can represent.
+func msdosTime(t time.Time) (uint16, uint16) {
+	t = t.UTC()
+	switch {
+	case t.Year() < 1980:
+		return 0, 1<<5 | 1
+	case t.Year() > 2107:
+		return 23<<11 | 59<<5 | 29, 127<<9 | 12<<5 | 31
+	}
+
+	return uint16(t.Hour()<<11 | t.Minute()<<5 | t.Second()>>1), //nolint:gosec
+		uint16((t.Year()-1980)<<9 | int(t.Month())<<5 | t.Day()) //nolint:gosec
+}
@@ -0,0 +1,216 @@
+package zipstream
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mapCache map[string]uint32
+
+func (c mapCache) Get(id string) (uint32, bool) {
+	crc, ok := c[id]
+	return crc, ok
+}
+
+func (c mapCache) Set(id string, crc uint32) {
+	c[id] = crc
+}
+
+// zeros is a file of the given size full of zeros, whose reads are
+// counted.
+type zeros struct {
+	size, pos int64
+	read      *int64
+}
+
+func (z *zeros) Read(p []byte) (int, error) {
+	if z.pos >= z.size {
+		return 0, io.EOF
+	}
+	n := int(min(int64(len(p)), z.size-z.pos))
+	clear(p[:n])
+	z.pos += int64(n)
+	*z.read += int64(n)
+	return n, nil
+}
+
+func (z *zeros) Seek(offset int64, _ int) (int64, error) {
+	z.pos = offset
+	return offset, nil
+}
+
+func (z *zeros) Close() error { return nil }
+
+func testEntries(read *int64) []*Entry {
+	modTime := time.Date(2026, 5, 4, 3, 2, 10, 0, time.UTC)
+	entries := []*Entry{
+		{Name: "docs/", ModTime: modTime, Mode: 0o755},
+		Bytes("docs/a.txt", []byte("hello"), modTime),
+		Bytes("docs/empty.txt", nil, modTime),
+		Bytes("é.txt", bytes.Repeat([]byte("0123456789"), 1000), modTime),
+		{Name: "zeros.bin", Size: 3000, ModTime: modTime, ID: "zeros", Open: func() (File, error) {
+			return &zeros{size: 3000, read: read}, nil
+		}},
+	}
+	return entries
+}
+
+func readAll(t *testing.T, a *Archive) []byte {
+	t.Helper()
+	content, err := io.ReadAll(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestArchive(t *testing.T) {
+	var read int64
+	a := New(testEntries(&read), nil)
+	content := readAll(t, a)
+	if int64(len(content)) != a.Size() {
+		t.Fatalf("read %d bytes, size is %d", len(content), a.Size())
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Reading to the end checks the checksum.
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		got = append(got, fmt.Sprintf("%s:%d:%v", f.Name, len(data), f.Modified.UTC().Format(time.DateTime)))
+	}
+	want := "[docs/:0:2026-05-04 03:02:10 docs/a.txt:5:2026-05-04 03:02:10 docs/empty.txt:0:2026-05-04 03:02:10 " +
+		"é.txt:10000:2026-05-04 03:02:10 zeros.bin:3000:2026-05-04 03:02:10]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("archive files = %v, want %v", got, want)
+	}
+	if read != 3000 {
+		t.Errorf("read %d bytes of zeros.bin, want 3000", read)
+	}
+}
+
+func TestArchiveRanges(t *testing.T) {
+	var read int64
+	full := readAll(t, New(testEntries(&read), nil))
+
+	for _, start := range []int64{0, 1, 40, 100, 5000, 12000, int64(len(full)) - 30} {
+		a := New(testEntries(&read), nil)
+		if _, err := a.Seek(start, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		part := readAll(t, a)
+		if !bytes.Equal(part, full[start:]) {
+			t.Errorf("content from %d differs", start)
+		}
+	}
+}
+
+func TestArchiveResumeWithCache(t *testing.T) {
+	var read int64
+	cache := mapCache{}
+	a := New(testEntries(&read), cache)
+	full := readAll(t, a)
+
+	// The resumed download needs the checksum of zeros.bin, which it
+	// starts in the middle of.
+	read = 0
+	resumed := New(testEntries(&read), cache)
+	start := a.entries[4].dataOffset() + 1000
+	if _, err := resumed.Seek(start, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if part := readAll(t, resumed); !bytes.Equal(part, full[start:]) {
+		t.Error("resumed content differs")
+	}
+	if read != 2000 {
+		t.Errorf("read %d bytes of zeros.bin, want 2000", read)
+	}
+
+	// Without the cache, the whole file is read again.
+	read = 0
+	resumed = New(testEntries(&read), nil)
+	_, _ = resumed.Seek(start, io.SeekStart)
+	readAll(t, resumed)
+	if read != 5000 {
+		t.Errorf("read %d bytes of zeros.bin without cache, want 5000", read)
+	}
+}
+
+func TestArchiveChangedFile(t *testing.T) {
+	var read int64
+	entries := testEntries(&read)
+	entries[4].Size = 4000
+
+	if _, err := io.ReadAll(New(entries, nil)); !errors.Is(err, ErrChanged) {
+		t.Errorf("reading a shrunk file: %v", err)
+	}
+}
+
+// readerAt reads an archive at random offsets.
+type readerAt struct {
+	mu sync.Mutex
+	a  *Archive
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.a.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(r.a, p)
+}
+
+func TestArchiveZip64(t *testing.T) {
+	const bigSize = 5 << 30
+
+	var read int64
+	modTime := time.Date(2026, 5, 4, 3, 2, 10, 0, time.UTC)
+	entries := []*Entry{
+		Bytes("a.txt", []byte("first"), modTime),
+		{Name: "big.bin", Size: bigSize, ModTime: modTime, ID: "big", Open: func() (File, error) {
+			return &zeros{size: bigSize, read: &read}, nil
+		}},
+		Bytes("c.txt", []byte("after"), modTime),
+	}
+	// The checksum of the big file is known, so it's never read.
+	a := New(entries, mapCache{"big": 0xdeadbeef})
+
+	r, err := zip.NewReader(&readerAt{a: a}, a.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.File) != 3 || r.File[1].UncompressedSize64 != bigSize {
+		t.Fatalf("unexpected files %v", r.File)
+	}
+
+	rc, err := r.File[2].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	if err != nil || string(data) != "after" {
+		t.Errorf("c.txt = %q, %v", data, err)
+	}
+	// Only the tail of big.bin is read, when looking for the end records.
+	if read > 64<<10 {
+		t.Errorf("read %d bytes of big.bin", read)
+	}
+}